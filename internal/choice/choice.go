@@ -0,0 +1,130 @@
+// Package choice builds multiple-choice questions from flashcards: prompting
+// the model for distractors, parsing its reply and mixing the options
+package choice
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Distractors is the number of wrong options shown next to the answer
+const Distractors = 3
+
+// MinDistractors is the least number of wrong options a question needs to be
+// asked as multiple choice, with fewer it is self-graded
+const MinDistractors = 3
+
+// Set is a multiple-choice question: the options in display order and the
+// index of the correct one
+type Set struct {
+	Options []string
+	Correct int
+}
+
+// New mixes the answer with up to Distractors wrong options in random order.
+// Distractors equal to the answer or to each other are dropped.
+func New(answer string, distractors []string) Set {
+	options := []string{answer}
+	seen := map[string]bool{normalize(answer): true}
+	for _, d := range distractors {
+		key := normalize(d)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, strings.TrimSpace(d))
+		if len(options) > Distractors {
+			break
+		}
+	}
+
+	// Fisher-Yates shuffle, tracking where the answer ends up
+	correct := 0
+	for i := len(options) - 1; i > 0; i-- {
+		j := randIntn(i + 1)
+		options[i], options[j] = options[j], options[i]
+		switch correct {
+		case i:
+			correct = j
+		case j:
+			correct = i
+		}
+	}
+	return Set{Options: options, Correct: correct}
+}
+
+// Valid reports whether the set has enough options to be asked
+func (s Set) Valid() bool {
+	return len(s.Options) > MinDistractors
+}
+
+// Prompt asks the model for plausible but wrong answers to a flashcard
+func Prompt(question, answer string) string {
+	return fmt.Sprintf(`You are writing a multiple-choice quiz.
+Write %d plausible but incorrect answers to the question below. They must be clearly wrong
+to someone who knows the material, similar in length and style to the correct answer, and
+must not repeat it. Output one answer per line starting with "- " and nothing else.
+
+Question: %s
+Correct answer: %s
+`, Distractors, question, answer)
+}
+
+// Parse extracts the distractors from a model reply written as a list
+func Parse(response string) []string {
+	var result []string
+	for _, line := range strings.Split(response, "\n") {
+		l := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(l, "- "), strings.HasPrefix(l, "* "):
+			l = l[2:]
+		case len(l) > 2 && l[0] >= '0' && l[0] <= '9' && (l[1] == '.' || l[1] == ')'):
+			l = l[2:]
+		default:
+			continue
+		}
+		if l = strings.TrimSpace(l); l != "" {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// Fallback picks distractors from other answers, used when the model is not
+// available. Candidates are usually answers of other cards in the same deck.
+func Fallback(answer string, candidates []string) []string {
+	var result []string
+	seen := map[string]bool{normalize(answer): true}
+	for _, c := range candidates {
+		key := normalize(c)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, c)
+	}
+	// Shuffle so the same cards are not always used as distractors
+	for i := len(result) - 1; i > 0; i-- {
+		j := randIntn(i + 1)
+		result[i], result[j] = result[j], result[i]
+	}
+	if len(result) > Distractors {
+		result = result[:Distractors]
+	}
+	return result
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// randIntn returns a random number in [0, n)
+func randIntn(n int) int {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint32(b) % uint32(n)) // #nosec G115 -- n is a small positive slice length
+}
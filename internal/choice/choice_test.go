@@ -0,0 +1,52 @@
+package choice
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	for range 20 {
+		s := New("Paris", []string{"Lyon", " paris ", "Nice", "Lyon", "Lille", "Brest"})
+		if len(s.Options) != Distractors+1 {
+			t.Fatalf("Expected %d options, got %v", Distractors+1, s.Options)
+		}
+		if s.Options[s.Correct] != "Paris" {
+			t.Fatalf("Correct index %d points to %q", s.Correct, s.Options[s.Correct])
+		}
+		got := append([]string(nil), s.Options...)
+		sort.Strings(got)
+		if want := []string{"Lille", "Lyon", "Nice", "Paris"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Options = %v, want %v", got, want)
+		}
+	}
+
+	if New("Paris", []string{"Lyon", "Nice"}).Valid() {
+		t.Error("Two distractors should not make a valid set")
+	}
+	if !New("Paris", []string{"Lyon", "Nice", "Lille"}).Valid() {
+		t.Error("Three distractors should make a valid set")
+	}
+}
+
+func TestParse(t *testing.T) {
+	response := "Here are some options:\n- Lyon\n* Marseille\n3. Nice\n4) Lille\n-\nNot a list item\n"
+	want := []string{"Lyon", "Marseille", "Nice", "Lille"}
+	if got := Parse(response); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	got := Fallback("Paris", []string{"Paris", "Lyon", "", "lyon", "Nice"})
+	sort.Strings(got)
+	if want := []string{"Lyon", "Nice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fallback() = %v, want %v", got, want)
+	}
+
+	many := Fallback("a", []string{"b", "c", "d", "e", "f"})
+	if len(many) != Distractors {
+		t.Errorf("Expected at most %d distractors, got %d", Distractors, len(many))
+	}
+}
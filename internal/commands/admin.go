@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 
+	"catv/internal/config"
 	"catv/internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -19,7 +20,12 @@ var AdminCmd = &cobra.Command{
 			fmt.Println("DB error:", err)
 			return
 		}
-		model := tui.NewAdminModel(Store, list)
+		cfg := config.LoadConfig()
+		opts := []tui.AdminOption{tui.WithRolloverHour(cfg.Review.RolloverHour)}
+		if cfg.Display.PlainText {
+			opts = append(opts, tui.WithAdminPlainText())
+		}
+		model := tui.NewAdminModel(Store, list, opts...)
 		if _, err := tea.NewProgram(model).Run(); err != nil {
 			fmt.Println("Error running admin TUI:", err)
 		}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"catv/internal/choice"
+	"catv/internal/config"
+	"catv/internal/notes"
+	"catv/internal/ollama"
+	"catv/internal/store"
+	"catv/internal/tui"
+)
+
+// distractorTimeout bounds a single distractor request to the model
+const distractorTimeout = 30 * time.Second
+
+// distractorWorkers is the number of distractor requests sent to the model at
+// once
+const distractorWorkers = 4
+
+// distractorFunc asks the model for distractors of a flashcard
+type distractorFunc func(ctx context.Context, fc store.Flashcard) ([]string, error)
+
+// ollamaDistractors returns a distractorFunc backed by the configured Ollama model
+func ollamaDistractors(cfg *config.Config, model string) distractorFunc {
+	return func(ctx context.Context, fc store.Flashcard) ([]string, error) {
+		ctx, cancel := context.WithTimeout(ctx, distractorTimeout)
+		defer cancel()
+		response, err := ollama.GenerateQA(ctx, model, cfg.OllamaURL, choice.Prompt(choiceQuestion(fc), fc.Answer))
+		if err != nil {
+			return nil, err
+		}
+		return choice.Parse(response), nil
+	}
+}
+
+// prepareChoices builds the multiple-choice options of each flashcard. Cached
+// distractors are reused; missing ones are generated once with the model and
+// cached, or taken from answers of other cards in the same deck when the model
+// is unavailable (generate nil). Cards without enough distractors get an
+// empty set and are self-graded.
+func prepareChoices(ctx context.Context, flashcards []store.Flashcard, generate distractorFunc) []choice.Set {
+	distractors := make([][]string, len(flashcards))
+	var missing []int
+	for i, fc := range flashcards {
+		cached, err := Store.GetDistractors(fc.ID)
+		if err != nil {
+			tui.PrintError("DB query error:", err)
+		}
+		distractors[i] = cached
+		if len(cached) < choice.MinDistractors {
+			missing = append(missing, i)
+		}
+	}
+
+	if generate != nil && len(missing) > 0 {
+		generated, err := generateDistractors(ctx, flashcards, missing, generate)
+		if err != nil {
+			tui.PrintInfo(fmt.Sprintf("Could not generate distractors (%v), using answers from the same deck", err))
+		}
+		for _, i := range missing {
+			if len(generated[i]) < choice.MinDistractors {
+				continue
+			}
+			if err := Store.SaveDistractors(flashcards[i].ID, generated[i]); err != nil {
+				tui.PrintError("DB insert error:", err)
+			}
+			distractors[i] = generated[i]
+		}
+	}
+
+	sets := make([]choice.Set, len(flashcards))
+	for i, fc := range flashcards {
+		if len(distractors[i]) < choice.MinDistractors {
+			candidates, err := Store.GetDeckAnswers(fc)
+			if err != nil {
+				tui.PrintError("DB query error:", err)
+				continue
+			}
+			distractors[i] = choice.Fallback(fc.Answer, candidates)
+		}
+		if set := choice.New(fc.Answer, distractors[i]); set.Valid() {
+			sets[i] = set
+		}
+	}
+	return sets
+}
+
+// generateDistractors asks the model for the distractors of the flashcards at
+// the given indexes, distractorWorkers at a time. The first failure stops the
+// remaining requests rather than waiting on an unreachable model for every
+// card; it is returned with the distractors generated until then, by index.
+func generateDistractors(ctx context.Context, flashcards []store.Flashcard, indexes []int, generate distractorFunc) ([][]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	generated := make([][]string, len(flashcards))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for range min(distractorWorkers, len(indexes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := generate(ctx, flashcards[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				generated[i] = result
+			}
+		}()
+	}
+send:
+	for _, i := range indexes {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return generated, firstErr
+}
+
+// choiceQuestion is the question text sent to the model, with the deletion
+// under review blanked out for cloze cards
+func choiceQuestion(fc store.Flashcard) string {
+	if fc.IsCloze() {
+		return notes.RenderCloze(fc.Question, fc.Ordinal, false, nil)
+	}
+	return fc.Question
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"catv/internal/store"
+)
+
+func TestPrepareChoices(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	original := Store
+	Store = s
+	defer func() { Store = original }()
+
+	for _, fc := range []store.Flashcard{
+		{File: "/geo.md", Question: "Capital of France?", Answer: "Paris", Deck: "geo"},
+		{File: "/geo.md", Question: "Capital of Italy?", Answer: "Rome", Deck: "geo"},
+		{File: "/geo.md", Question: "Capital of Spain?", Answer: "Madrid", Deck: "geo"},
+		{File: "/geo.md", Question: "Capital of Greece?", Answer: "Athens", Deck: "geo"},
+		{File: "/go.md", Question: "What is Go?", Answer: "A language", Deck: "code"},
+	} {
+		if err := s.InsertFlashcard(fc); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+	cards, _ := s.GetAllFlashcards()
+
+	calls := 0
+	generate := func(ctx context.Context, fc store.Flashcard) ([]string, error) {
+		calls++
+		return []string{"Lyon", "Nice", "Lille"}, nil
+	}
+	sets := prepareChoices(context.Background(), cards[:1], generate)
+	if len(sets[0].Options) != 4 || sets[0].Options[sets[0].Correct] != "Paris" {
+		t.Fatalf("Unexpected choices from the model: %+v", sets[0])
+	}
+
+	// Distractors are cached and not generated again
+	prepareChoices(context.Background(), cards[:1], generate)
+	if calls != 1 {
+		t.Errorf("Expected distractors to be generated once, got %d calls", calls)
+	}
+
+	// Without the model, other answers from the same deck are used; a deck
+	// with no other cards falls back to self-grading
+	failing := func(ctx context.Context, fc store.Flashcard) ([]string, error) {
+		return nil, errors.New("connection refused")
+	}
+	sets = prepareChoices(context.Background(), cards[1:], failing)
+	if !sets[0].Valid() || len(sets[0].Options) != 4 {
+		t.Errorf("Expected offline choices from the deck, got %+v", sets[0])
+	}
+	if sets[3].Valid() {
+		t.Errorf("Expected no choices for a card alone in its deck, got %+v", sets[3])
+	}
+
+	// Two wrong options are not enough, the card is self-graded
+	few := func(ctx context.Context, fc store.Flashcard) ([]string, error) {
+		return []string{"Python", "Rust"}, nil
+	}
+	if sets = prepareChoices(context.Background(), cards[4:], few); sets[0].Valid() {
+		t.Errorf("Expected no choices with two distractors, got %+v", sets[0])
+	}
+}
+
+func TestPrepareChoicesConcurrent(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	original := Store
+	Store = s
+	defer func() { Store = original }()
+
+	for i := range 12 {
+		if err := s.InsertFlashcard(store.Flashcard{File: "/go.md", Question: fmt.Sprintf("Question %d?", i), Answer: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+	cards, _ := s.GetAllFlashcards()
+
+	var inFlight, busiest, calls atomic.Int32
+	generate := func(ctx context.Context, fc store.Flashcard) ([]string, error) {
+		calls.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			b := busiest.Load()
+			if n <= b || busiest.CompareAndSwap(b, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return []string{"wrong " + fc.Answer, "off " + fc.Answer, "not " + fc.Answer}, nil
+	}
+	sets := prepareChoices(context.Background(), cards, generate)
+	for i, set := range sets {
+		if !set.Valid() || set.Options[set.Correct] != cards[i].Answer {
+			t.Fatalf("Unexpected choices for card %d: %+v", i, set)
+		}
+	}
+	if calls.Load() != 12 || busiest.Load() < 2 || busiest.Load() > distractorWorkers {
+		t.Errorf("Expected 12 requests, at most %d at once and some in parallel, got %d with %d at once", distractorWorkers, calls.Load(), busiest.Load())
+	}
+
+	// A failing model is not asked for every remaining card
+	var failed atomic.Int32
+	failing := func(ctx context.Context, fc store.Flashcard) ([]string, error) {
+		failed.Add(1)
+		return nil, errors.New("connection refused")
+	}
+	for i := range 20 {
+		if err := s.InsertFlashcard(store.Flashcard{File: "/new.md", Question: fmt.Sprintf("New %d?", i), Answer: "A"}); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+	fresh, _ := s.GetFlashcardsMatching(store.Filter{File: "/new.md"})
+	prepareChoices(context.Background(), fresh, failing)
+	if n := failed.Load(); n == 0 || n > distractorWorkers {
+		t.Errorf("Expected the requests to stop after the first failure, got %d", n)
+	}
+}
@@ -1,11 +1,21 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"catv/internal/config"
+	"catv/internal/notes"
+	"catv/internal/store"
+
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -79,7 +89,7 @@ func TestGetMarkdownFiles(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := getMarkdownFiles(tt.path)
+			result, err := getMarkdownFiles(tt.path, discoverOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getMarkdownFiles() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -109,6 +119,150 @@ func TestGetMarkdownFiles(t *testing.T) {
 	}
 }
 
+func TestGetMarkdownFilesIgnore(t *testing.T) {
+	tempDir := t.TempDir()
+	mustWrite := func(rel, content string) string {
+		p := filepath.Join(tempDir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		return p
+	}
+
+	keep := mustWrite("topics/go.md", "# Go")
+	draft := mustWrite("topics/drafts/idea.md", "# Idea")
+	mustWrite("node_modules/pkg/README.md", "# Pkg")
+	mustWrite(".git/notes.md", "# Git")
+	mustWrite("topics/.catvignore", "drafts/\n")
+	mustWrite("other/.catvignore", "!todo.md\n")
+	vendored := mustWrite("vendor/lib.md", "# Lib")
+	mustWrite(".gitignore", "vendor/\n")
+	other := mustWrite("other/todo.md", "# Todo")
+
+	tests := []struct {
+		name     string
+		opts     discoverOptions
+		expected []string
+	}{
+		{
+			name:     "defaults and catvignore",
+			opts:     discoverOptions{},
+			expected: []string{other, keep, vendored},
+		},
+		{
+			name:     "gitignore",
+			opts:     discoverOptions{Gitignore: true},
+			expected: []string{other, keep},
+		},
+		{
+			name:     "exclude",
+			opts:     discoverOptions{Exclude: []string{"other/"}},
+			expected: []string{keep, vendored},
+		},
+		{
+			name:     "include",
+			opts:     discoverOptions{Include: []string{"topics/**"}},
+			expected: []string{keep},
+		},
+		{
+			name:     "include folder",
+			opts:     discoverOptions{Include: []string{"topics"}},
+			expected: []string{keep},
+		},
+		{
+			name:     "include folder with slash",
+			opts:     discoverOptions{Include: []string{"topics/"}},
+			expected: []string{keep},
+		},
+		{
+			// An ignore file re-including a path cannot undo an explicit exclude
+			name:     "exclude over ignore file",
+			opts:     discoverOptions{Exclude: []string{"*.md"}, Include: []string{"other"}},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := getMarkdownFiles(tempDir, tt.opts)
+			if err != nil {
+				t.Fatalf("getMarkdownFiles() error = %v", err)
+			}
+			if strings.Join(result, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("getMarkdownFiles() = %v, expected %v", result, tt.expected)
+			}
+			for _, r := range result {
+				if r == draft {
+					t.Errorf("getMarkdownFiles() should skip ignored draft %s", draft)
+				}
+			}
+		})
+	}
+}
+
+func TestDirIgnored(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "topics"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "topics", ".catvignore"), []byte("drafts/\n"), 0600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		dir  string
+		opts discoverOptions
+		want bool
+	}{
+		{"topics", discoverOptions{}, false},
+		{"topics/go", discoverOptions{}, false},
+		{"node_modules", discoverOptions{}, true},
+		{"node_modules/pkg/lib", discoverOptions{}, true},
+		{"topics/drafts/2024", discoverOptions{}, true},
+		{"other", discoverOptions{Exclude: []string{"other/"}}, true},
+	}
+	for _, tt := range tests {
+		got, err := dirIgnored(tempDir, filepath.Join(tempDir, tt.dir), tt.opts)
+		if err != nil || got != tt.want {
+			t.Errorf("dirIgnored(%s) = %v, %v, want %v", tt.dir, got, err, tt.want)
+		}
+	}
+}
+
+// TestGenerateCmdFlags checks that every flag generate reads is registered,
+// cobra rejects unknown flags before the command runs
+func TestGenerateCmdFlags(t *testing.T) {
+	for _, name := range []string{"path", "watch", "interval", "debounce", "include", "exclude", "gitignore", "list-files", "no-llm", "reverse"} {
+		if GenerateCmd.Flags().Lookup(name) == nil {
+			t.Errorf("generate is missing the --%s flag", name)
+		}
+	}
+}
+
+// TestCommandFlags checks the flags of the other commands, generate's are
+// checked by TestGenerateCmdFlags
+func TestCommandFlags(t *testing.T) {
+	for _, name := range []string{"mode", "llm-grade", "order", "seed", "new-mix", "report"} {
+		if ReviewCmd.Flags().Lookup(name) == nil {
+			t.Errorf("review is missing the --%s flag", name)
+		}
+	}
+	for _, name := range []string{"deck", "file", "tag", "failed-since", "mode", "llm-grade", "report"} {
+		if CramCmd.Flags().Lookup(name) == nil {
+			t.Errorf("cram is missing the --%s flag", name)
+		}
+	}
+	if StatsCmd.Flags().Lookup("json") == nil {
+		t.Error("stats is missing the --json flag")
+	}
+	if LeechesCmd.Flags().Lookup("fix") == nil {
+		t.Error("leeches is missing the --fix flag")
+	}
+}
+
 func TestSpinnerModelInit(t *testing.T) {
 	sm := spinnerModel{
 		spinner: spinner.New(),
@@ -197,3 +351,234 @@ func TestExecute(t *testing.T) {
 	// This test just verifies the function compiles
 	t.Log("Execute function exists and compiles")
 }
+
+func TestWatchModelUpdate(t *testing.T) {
+	m := watchModel{spinner: spinner.New(), root: "notes", status: "waiting"}
+
+	newModel, _ := m.Update(watchStatusMsg("last: a.md (+2)"))
+	m = newModel.(watchModel)
+	if m.status != "last: a.md (+2)" {
+		t.Errorf("status = %q, want it to be updated", m.status)
+	}
+	if !strings.Contains(m.View(), "last: a.md (+2)") {
+		t.Errorf("View() should contain the status line, got: %s", m.View())
+	}
+
+	_, cmd := m.Update(watchLogMsg{text: "generated"})
+	if cmd == nil {
+		t.Error("Update() should print log messages")
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if cmd == nil {
+		t.Error("Update() should quit on q")
+	}
+}
+
+func TestBuildGeneratePrompt(t *testing.T) {
+	prompt := buildGeneratePrompt("# Body", notes.Directives{}, nil)
+	if !strings.HasSuffix(prompt, "Markdown:\n# Body") {
+		t.Errorf("prompt should end with the note body, got: %s", prompt)
+	}
+
+	prompt = buildGeneratePrompt("# Body", notes.Directives{
+		MaxCards: 3,
+		Language: "Spanish",
+		Prompt:   "Focus on dates.",
+	}, []string{"What is Go?"})
+	for _, want := range []string{"at most 3 flashcards", "in Spanish", "Focus on dates.", "already exist", "- What is Go?\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt should contain %q, got: %s", want, prompt)
+		}
+	}
+}
+
+func TestGenerateForFileInlineCards(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	original := Store
+	Store = s
+	defer func() { Store = original }()
+
+	note := filepath.Join(t.TempDir(), "net.md")
+	write := func(content string) {
+		if err := os.WriteFile(note, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write note: %v", err)
+		}
+	}
+	cfg := &config.Config{}
+
+	write("---\ncatv.deck: net\n---\nTCP :: Transmission Control Protocol\nUDP :: User Datagram Protocol\n")
+	count, err := generateForFile(context.Background(), cfg, "", note, generateOptions{})
+	if err != nil || count != 2 {
+		t.Fatalf("generateForFile() = %d, %v, want 2 inline cards", count, err)
+	}
+
+	// Re-running keeps existing cards and removes the ones deleted from the note
+	write("---\ncatv.deck: net\n---\nTCP :: Transmission Control Protocol\nIP :: Internet Protocol\n")
+	count, err = generateForFile(context.Background(), cfg, "", note, generateOptions{})
+	if err != nil || count != 1 {
+		t.Fatalf("generateForFile() = %d, %v, want 1 new inline card", count, err)
+	}
+
+	cards, err := s.GetAllFlashcards()
+	if err != nil {
+		t.Fatalf("GetAllFlashcards() error = %v", err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("Expected 2 cards after sync, got %d", len(cards))
+	}
+	for _, c := range cards {
+		if c.Question == "UDP" {
+			t.Error("Removed inline card should have been deleted")
+		}
+		if c.Deck != "net" {
+			t.Errorf("Inline card should use front matter deck, got %q", c.Deck)
+		}
+	}
+
+	processed, _ := s.IsFileProcessed(note)
+	if processed {
+		t.Error("Inline cards alone should not mark a file as processed by the model")
+	}
+
+	write("---\ncatv: skip\n---\nTCP :: Transmission Control Protocol\n")
+	if _, err := generateForFile(context.Background(), cfg, "", note, generateOptions{}); !errors.Is(err, errSkipped) {
+		t.Errorf("generateForFile() error = %v, want errSkipped", err)
+	}
+}
+
+func TestGenerateForFileReverse(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	original := Store
+	Store = s
+	defer func() { Store = original }()
+
+	note := filepath.Join(t.TempDir(), "fr.md")
+	content := "---\ncatv.reverse: true\n---\nbonjour :: hello\nThe {{c1::Seine}} flows through Paris.\n"
+	if err := os.WriteFile(note, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	// Cloze cards are never reversed
+	count, err := generateForFile(context.Background(), &config.Config{}, "", note, generateOptions{})
+	if err != nil || count != 3 {
+		t.Fatalf("generateForFile() = %d, %v, want 3 cards", count, err)
+	}
+	cards, _ := s.GetAllFlashcards()
+	if cards[1].Question != "hello" || cards[1].Answer != "bonjour" || cards[1].SiblingOf != cards[0].ID {
+		t.Errorf("Unexpected reverse card: %+v", cards[1])
+	}
+
+	// Re-running is idempotent and turning reverse off removes the reverse card
+	if count, _ = generateForFile(context.Background(), &config.Config{}, "", note, generateOptions{}); count != 0 {
+		t.Errorf("Expected no new cards on re-run, got %d", count)
+	}
+	if err := os.WriteFile(note, []byte("bonjour :: hello\n"), 0600); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+	if _, err := generateForFile(context.Background(), &config.Config{}, "", note, generateOptions{}); err != nil {
+		t.Fatalf("generateForFile() error = %v", err)
+	}
+	if cards, _ = s.GetAllFlashcards(); len(cards) != 1 || cards[0].Question != "bonjour" {
+		t.Errorf("Expected only the original card to remain, got %+v", cards)
+	}
+}
+
+func TestGenerateForFileSkipsUnchangedNotes(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	original := Store
+	Store = s
+	defer func() { Store = original }()
+
+	// The model rewords the cards it already made unless it is told about them
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		response := fmt.Sprintf("Q: What does TCP stand for? (take %d)\nA: Transmission Control Protocol", requests)
+		if strings.Contains(req.Prompt, "- What does TCP stand for? (take 1)\n") {
+			response = "Q: Is TCP connection-oriented?\nA: Yes"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	defer server.Close()
+	cfg := &config.Config{OllamaURL: server.URL}
+
+	note := filepath.Join(t.TempDir(), "net.md")
+	write := func(content string) {
+		if err := os.WriteFile(note, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write note: %v", err)
+		}
+	}
+	generate := func() int {
+		t.Helper()
+		count, err := generateForFile(context.Background(), cfg, "test-model", note, generateOptions{UseLLM: true})
+		if err != nil {
+			t.Fatalf("generateForFile() error = %v", err)
+		}
+		return count
+	}
+
+	write("# TCP\nTCP is a reliable transport protocol.\n")
+	if count := generate(); count != 1 || requests != 1 {
+		t.Fatalf("generateForFile() = %d after %d request(s), want 1 card from 1 request", count, requests)
+	}
+	// Saving the note unchanged, as editors do, doesn't ask the model again
+	write("# TCP\nTCP is a reliable transport protocol.\n")
+	if count := generate(); count != 0 || requests != 1 {
+		t.Errorf("generateForFile() = %d after %d request(s), want the unchanged note skipped", count, requests)
+	}
+
+	// An edited note only gets cards for what is new in it
+	write("# TCP\nTCP is a reliable, connection-oriented transport protocol.\n")
+	if count := generate(); count != 1 || requests != 2 {
+		t.Errorf("generateForFile() = %d after %d request(s), want the edited note sent again", count, requests)
+	}
+	cards, _ := s.GetAllFlashcards()
+	if len(cards) != 2 || cards[1].Question != "Is TCP connection-oriented?" {
+		t.Errorf("Expected the new card without a reworded duplicate, got %+v", cards)
+	}
+	if hash, _ := s.GetGenerationHash(note); hash == "" {
+		t.Error("Expected the content hash of the generation to be stored")
+	}
+	// The new card is listed to the model, but saving unchanged still skips it
+	if count := generate(); count != 0 || requests != 2 {
+		t.Errorf("generateForFile() = %d after %d request(s), want the unchanged note skipped", count, requests)
+	}
+}
+
+func TestFlashcardsFromQAs(t *testing.T) {
+	qas := []map[string]string{
+		{"question": "What is Go?", "answer": "A language"},
+		{"question": "A {{c1::goroutine}} is managed by the {{c2::runtime}}", "answer": "", "type": "cloze"},
+	}
+
+	cards := flashcardsFromQAs(qas, "/notes/go.md")
+	if len(cards) != 3 {
+		t.Fatalf("Expected 3 flashcards, got %d", len(cards))
+	}
+	if cards[0].Type != store.CardTypeBasic || cards[0].Answer != "A language" {
+		t.Errorf("Unexpected basic card: %+v", cards[0])
+	}
+	if !cards[1].IsCloze() || cards[1].Ordinal != 1 || cards[1].Answer != "goroutine" {
+		t.Errorf("Unexpected first cloze card: %+v", cards[1])
+	}
+	if !cards[2].IsCloze() || cards[2].Ordinal != 2 || cards[2].Answer != "runtime" {
+		t.Errorf("Unexpected second cloze card: %+v", cards[2])
+	}
+}
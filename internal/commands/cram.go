@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"catv/internal/config"
+	"catv/internal/scheduler"
+	"catv/internal/store"
+	"catv/internal/tui"
+
+	"github.com/spf13/cobra"
+)
+
+var CramCmd = &cobra.Command{
+	Use:   "cram",
+	Short: "Drill flashcards without changing their schedule",
+	Long: `Drill the flashcards matching the filters, whether they are due or not, for
+example before an exam. Filters combine: --deck, --file and --tag select cards
+by deck, source file and tag, --failed-since 7d the cards answered incorrectly
+in the last 7 days (hours work too, such as 12h).
+
+The review works as usual, with --mode and --llm-grade, but correct answers
+move straight on and failed cards come back at the learning steps until
+answered correctly. Answers are logged as cram: due dates and intervals are
+left unchanged and cram reviews don't count towards the daily limits.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		mode, llmGrade, err := reviewMode(cmd)
+		if err != nil {
+			tui.PrintError("Invalid review mode:", err)
+			return
+		}
+		var filter store.Filter
+		filter.Deck, _ = cmd.Flags().GetString("deck")
+		if file, _ := cmd.Flags().GetString("file"); file != "" {
+			// Files are stored with their absolute path
+			filter.File, _ = filepath.Abs(file)
+		}
+		filter.Tag, _ = cmd.Flags().GetString("tag")
+		if since, _ := cmd.Flags().GetString("failed-since"); since != "" {
+			d, err := parseSince(since)
+			if err != nil {
+				tui.PrintError("Invalid --failed-since:", err)
+				return
+			}
+			filter.FailedSince = time.Now().Add(-d)
+		}
+
+		flashcards, err := Store.GetFlashcardsMatching(filter)
+		if err != nil {
+			tui.PrintError("DB query error:", err)
+			return
+		}
+		if len(flashcards) == 0 {
+			tui.PrintInfo("No flashcards match the filters.")
+			return
+		}
+
+		cfg := config.LoadConfig()
+		// The session only keeps the time spent for the daily goal, cram is never resumed
+		sessionID, err := Store.StartCramSession(mode)
+		if err != nil {
+			tui.PrintError("Failed to record the cram session, it won't count towards the daily goal:", err)
+		}
+		recorder := newCramRecorder(Store, sessionID, mode, scheduler.NextDayStart(time.Now(), cfg.Review.RolloverHour))
+		opts := append(reviewOptions(cfg, mode, llmGrade, flashcards),
+			tui.WithCram(),
+			tui.WithRecorder(recorder),
+			tui.WithMarker(recorder),
+			tui.WithEditor(recorder.Edit),
+		)
+		model, err := runReview(flashcards, opts)
+		if err != nil {
+			return
+		}
+		if sessionID > 0 {
+			if err := Store.FinishSession(sessionID); err != nil {
+				tui.PrintError("DB update error:", err)
+			}
+		}
+
+		finishReview(cmd, model, "Crammed")
+	},
+}
+
+// parseSince parses a positive duration given in days such as 7d, or as a Go
+// duration such as 12h
+func parseSince(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
+
+func init() {
+	CramCmd.Flags().String("deck", "", "Cram the cards of this deck")
+	CramCmd.Flags().String("file", "", "Cram the cards generated from this file")
+	CramCmd.Flags().String("tag", "", "Cram the cards with this tag")
+	CramCmd.Flags().String("failed-since", "", "Cram the cards answered incorrectly within this time, such as 7d or 12h")
+	CramCmd.Flags().String("mode", reviewModeClassic, "Review mode: classic (self-graded), mc (multiple choice) or typed (typed answers)")
+	CramCmd.Flags().Bool("llm-grade", false, "Have the Ollama model grade typed answers (implies --mode typed)")
+	CramCmd.Flags().String("report", "", "Save a markdown report of the session to this file")
+}
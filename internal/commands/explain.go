@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"catv/internal/config"
+	"catv/internal/notes"
+	"catv/internal/ollama"
+	"catv/internal/store"
+	"catv/internal/tui"
+)
+
+// excerptLength bounds the note excerpt sent to the model with a card
+const excerptLength = 1500
+
+// ollamaChat streams explanations from the configured Ollama model
+func ollamaChat(cfg *config.Config, model string) tui.Chat {
+	url := ollama.ChatURL(cfg.OllamaURL)
+	return func(ctx context.Context, messages []tui.ChatMessage, onToken func(string)) error {
+		converted := make([]ollama.ChatMessage, len(messages))
+		for i, msg := range messages {
+			converted[i] = ollama.ChatMessage{Role: msg.Role, Content: msg.Content}
+		}
+		_, err := ollama.Chat(ctx, model, url, converted, onToken)
+		return err
+	}
+}
+
+// cardExcerpt returns the part of the card's note that best matches it, or
+// "" when the note can no longer be read
+func cardExcerpt(fc store.Flashcard) string {
+	data, err := os.ReadFile(fc.File) // #nosec G304 -- file paths come from the flashcards database
+	if err != nil {
+		return ""
+	}
+	query := strings.Join([]string{notes.RenderCloze(fc.Question, fc.Ordinal, true, nil), fc.Answer}, " ")
+	return notes.Excerpt(string(data), query, excerptLength)
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"catv/internal/store"
+)
+
+func TestCardExcerpt(t *testing.T) {
+	note := filepath.Join(t.TempDir(), "go.md")
+	content := "# Go\n\nGoroutines are lightweight threads managed by the runtime.\n\nChannels connect goroutines.\n"
+	if err := os.WriteFile(note, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	fc := store.Flashcard{File: note, Question: "A {{c1::goroutine}} is a lightweight thread", Answer: "goroutine", Type: store.CardTypeCloze, Ordinal: 1}
+	if got := cardExcerpt(fc); !strings.Contains(got, "lightweight threads managed by the runtime") {
+		t.Errorf("cardExcerpt() = %q", got)
+	}
+
+	fc.File = filepath.Join(t.TempDir(), "missing.md")
+	if got := cardExcerpt(fc); got != "" {
+		t.Errorf("Expected no excerpt for a missing note, got %q", got)
+	}
+}
@@ -2,13 +2,17 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"catv/internal/config"
+	"catv/internal/ignore"
+	"catv/internal/notes"
 	"catv/internal/ollama"
 	"catv/internal/security"
 	"catv/internal/store"
@@ -55,7 +59,13 @@ var GenerateCmd = &cobra.Command{
 	
 This command processes markdown files (or directories containing markdown files)
 and automatically generates question-answer pairs using the configured Ollama model.
-Each flashcard is stored in the local SQLite database for review.`,
+Each flashcard is stored in the local SQLite database for review.
+
+Cards written directly in notes ("Question :: Answer", Q:/A: blocks and
+{{c1::cloze}} deletions) are extracted as-is; use --no-llm to only extract those.
+
+With --watch the command keeps running after the initial pass and regenerates
+flashcards for a note whenever it is saved.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		path, _ := cmd.Flags().GetString("path")
 		if path == "" {
@@ -69,6 +79,25 @@ Each flashcard is stored in the local SQLite database for review.`,
 			os.Exit(1)
 		}
 
+		var opts discoverOptions
+		opts.Include, _ = cmd.Flags().GetStringSlice("include")
+		opts.Exclude, _ = cmd.Flags().GetStringSlice("exclude")
+		opts.Gitignore, _ = cmd.Flags().GetBool("gitignore")
+
+		// Preview discovery without contacting Ollama
+		if listFiles, _ := cmd.Flags().GetBool("list-files"); listFiles {
+			files, err := getMarkdownFiles(path, opts)
+			if err != nil {
+				tui.PrintError("File error:", err)
+				os.Exit(1)
+			}
+			for _, f := range files {
+				fmt.Println(f)
+			}
+			tui.PrintInfo(fmt.Sprintf("%d file(s) would be processed", len(files)))
+			return
+		}
+
 		// Load configuration
 		cfg := config.LoadConfig()
 		model := Model // Use command line flag if provided, otherwise default
@@ -86,12 +115,16 @@ Each flashcard is stored in the local SQLite database for review.`,
 		tui.PrintInfo(fmt.Sprintf("Database: %s", cfg.DatabasePath))
 		tui.PrintInfo(fmt.Sprintf("API Target: %s", cfg.OllamaURL))
 
-		files, err := getMarkdownFiles(path)
+		files, err := getMarkdownFiles(path, opts)
 		if err != nil {
 			tui.PrintError("File error:", err)
 			os.Exit(1)
 		}
 
+		noLLM, _ := cmd.Flags().GetBool("no-llm")
+		reverse, _ := cmd.Flags().GetBool("reverse")
+		genOpts := generateOptions{UseLLM: true, Reverse: reverse}
+		inlineOpts := generateOptions{UseLLM: false, Reverse: reverse}
 		for _, f := range files {
 			absPath, _ := filepath.Abs(f)
 			processed, err := Store.IsFileProcessed(absPath)
@@ -99,71 +132,37 @@ Each flashcard is stored in the local SQLite database for review.`,
 				tui.PrintError("DB query error:", err)
 				continue
 			}
-			if processed {
-				tui.PrintInfo(fmt.Sprintf("Skipping already processed: %s", absPath))
-				continue
-			}
-
-			data, err := os.ReadFile(filepath.Clean(f))
-			if err != nil {
-				tui.PrintError("Read error:", err)
+			if processed || noLLM {
+				// Inline cards are cheap to extract, keep them in sync even when the model is skipped
+				count, err := generateForFile(context.Background(), cfg, model, absPath, inlineOpts)
+				switch {
+				case errors.Is(err, errSkipped):
+					tui.PrintInfo(fmt.Sprintf("Skipping %s: %v", absPath, err))
+				case err != nil:
+					tui.PrintError("Inline cards error:", err)
+				case processed:
+					tui.PrintInfo(fmt.Sprintf("Skipping already processed: %s (%d new inline flashcards)", absPath, count))
+				default:
+					tui.PrintSuccess(fmt.Sprintf("Processed: %s (%d inline flashcards)", absPath, count))
+				}
 				continue
 			}
 
-			prompt := fmt.Sprintf(`You are an expert flashcard generator. Your task is to extract spaced repetition flashcards from the following markdown content.
-
-Strictly output ONLY pairs in this format, with no extra text, explanations, or numbering:
-Q: <question>
-A: <answer>
-
-Repeat for each flashcard. Do not include any other text, headers, or formatting. Do not add explanations, summaries, or comments. Only output Q: and A: pairs, one after another.
-
-Example:
-Q: What is the capital of France?
-A: Paris
-Q: What is 2+2?
-A: 4
-
-Markdown:
-%s`, string(data))
-
 			doneChan := make(chan string)
 			go func() {
 				// Create context with timeout for Ollama request
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 				defer cancel()
 
-				resp, err := ollama.GenerateQA(ctx, model, cfg.OllamaURL, prompt)
-				if err != nil {
-					doneChan <- fmt.Sprintf("Ollama error: %v", err)
-					return
-				}
-				// DEBUG: Print raw Ollama response for troubleshooting
-				// tui.PrintInfo("Raw Ollama response:")
-				// tui.PrintInfo(resp)
-				qas, err := ollama.ParseFlashcards(resp)
-				if err != nil {
-					doneChan <- fmt.Sprintf("Ollama parsing error: %v", err)
-					return
-				}
-				count := 0
-				for _, qa := range qas {
-					fc := store.Flashcard{
-						File:      absPath,
-						Question:  qa["question"],
-						Answer:    qa["answer"],
-						RevisitIn: 0, // Due immediately
-					}
-					err := Store.InsertFlashcard(fc)
-					if err != nil {
-						tui.PrintError("DB insert error:", err)
-					} else {
-						count++
-					}
-				}
-				if count > 0 {
+				count, err := generateForFile(ctx, cfg, model, absPath, genOpts)
+				switch {
+				case errors.Is(err, errSkipped):
+					doneChan <- fmt.Sprintf("Skipping %s: %v", absPath, err)
+				case err != nil:
+					doneChan <- err.Error()
+				case count > 0:
 					doneChan <- fmt.Sprintf("Processed: %s (%d flashcards generated)", absPath, count)
-				} else {
+				default:
 					doneChan <- fmt.Sprintf("No flashcards inserted for: %s", absPath)
 				}
 			}()
@@ -190,33 +189,417 @@ Markdown:
 				}
 			}
 		}
+
+		// In watch mode keep running after the initial pass and pick up edits
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			debounce, _ := cmd.Flags().GetDuration("debounce")
+			if err := runWatch(cfg, model, path, opts, generateOptions{UseLLM: !noLLM, Reverse: reverse}, interval, debounce); err != nil {
+				tui.PrintError("Watch error:", err)
+				os.Exit(1)
+			}
+		}
 	},
 }
 
 func init() {
 	GenerateCmd.Flags().StringP("path", "p", "", "Markdown file or folder to process")
+	GenerateCmd.Flags().BoolP("watch", "w", false, "Keep running and regenerate flashcards when notes change")
+	GenerateCmd.Flags().Duration("interval", 2*time.Second, "How often to check for changes in watch mode when filesystem notifications are unavailable")
+	GenerateCmd.Flags().Duration("debounce", time.Second, "How long a file must be unchanged before it is regenerated in watch mode")
+	GenerateCmd.Flags().StringSlice("include", nil, "Only process files, or files in folders, matching these glob patterns (e.g. 'topics/')")
+	GenerateCmd.Flags().StringSlice("exclude", nil, "Skip files or folders matching these glob patterns (e.g. 'drafts/')")
+	GenerateCmd.Flags().Bool("gitignore", false, "Also honor .gitignore files when discovering notes")
+	GenerateCmd.Flags().Bool("list-files", false, "List the markdown files that would be processed and exit")
+	GenerateCmd.Flags().Bool("no-llm", false, "Only extract cards written in the notes, without calling Ollama")
+	GenerateCmd.Flags().Bool("reverse", false, "Also create a reversed card (answer → question) for every basic card")
+}
+
+// errSkipped is returned by generateForFile when a note opts out via front matter
+var errSkipped = errors.New("skipped by front matter (catv: skip)")
+
+// generateOptions controls how generateForFile creates flashcards
+type generateOptions struct {
+	UseLLM  bool // ask Ollama for flashcards in addition to inline cards
+	Reverse bool // add a reversed sibling for every new basic card
+}
+
+// generateForFile syncs the author's inline cards for a markdown file and, when
+// UseLLM is set and the note changed since the model last saw it, asks Ollama
+// for more flashcards, storing the ones not already present for that file. It
+// returns how many flashcards were added.
+func generateForFile(ctx context.Context, cfg *config.Config, model, absPath string, opts generateOptions) (int, error) {
+	data, err := os.ReadFile(filepath.Clean(absPath))
+	if err != nil {
+		return 0, fmt.Errorf("read error: %w", err)
+	}
+
+	directives, body, err := notes.ParseFrontMatter(string(data))
+	if err != nil {
+		return 0, fmt.Errorf("front matter error: %w", err)
+	}
+	if directives.Skip {
+		return 0, errSkipped
+	}
+	reverse := opts.Reverse || directives.Reverse
+
+	count, err := syncInlineCards(absPath, body, directives, reverse)
+	if err != nil || !opts.UseLLM {
+		return count, err
+	}
+
+	// Only ask the model again when the note changed: saving a note in watch
+	// mode would otherwise add reworded duplicates of its cards
+	hash := notes.TextHash(model, buildGeneratePrompt(body, directives, nil))
+	last, err := Store.GetGenerationHash(absPath)
+	if err != nil {
+		return count, fmt.Errorf("DB query error: %w", err)
+	}
+	if last == hash {
+		return count, nil
+	}
+	// The model is told which cards the note already has so an edited note
+	// only gets cards for what they do not cover
+	existing, err := Store.GetFileQuestions(absPath)
+	if err != nil {
+		return count, fmt.Errorf("DB query error: %w", err)
+	}
+	prompt := buildGeneratePrompt(body, directives, existing)
+
+	resp, err := ollama.GenerateQA(ctx, model, cfg.OllamaURL, prompt)
+	if err != nil {
+		return count, fmt.Errorf("ollama error: %w", err)
+	}
+	qas, err := ollama.ParseFlashcards(resp)
+	if err != nil {
+		return count, fmt.Errorf("ollama parsing error: %w", err)
+	}
+	if directives.MaxCards > 0 && len(qas) > directives.MaxCards {
+		qas = qas[:directives.MaxCards]
+	}
+
+	for _, fc := range flashcardsFromQAs(qas, absPath) {
+		fc.Deck = directives.Deck
+		fc.Tags = directives.Tags
+		// Skip questions we already have so regenerating a file only adds new cards
+		exists, err := Store.FlashcardExists(fc)
+		if err != nil {
+			return count, fmt.Errorf("DB query error: %w", err)
+		}
+		if exists {
+			continue
+		}
+		id, err := Store.CreateFlashcard(fc)
+		if err != nil {
+			return count, fmt.Errorf("DB insert error: %w", err)
+		}
+		count++
+		if reverse && !fc.IsCloze() {
+			fc.ID = id
+			if _, err := Store.CreateReverse(fc); err != nil {
+				return count, fmt.Errorf("DB insert error: %w", err)
+			}
+			count++
+		}
+	}
+	if err := Store.SetGenerationHash(absPath, hash); err != nil {
+		return count, fmt.Errorf("DB update error: %w", err)
+	}
+	return count, nil
+}
+
+// flashcardsFromQAs converts parsed model output into flashcards, expanding
+// each cloze text into one card per deletion number
+func flashcardsFromQAs(qas []map[string]string, absPath string) []store.Flashcard {
+	flashcards := make([]store.Flashcard, 0, len(qas))
+	for _, qa := range qas {
+		if qa["type"] == string(store.CardTypeCloze) {
+			for _, ord := range notes.ClozeOrdinals(qa["question"]) {
+				flashcards = append(flashcards, store.Flashcard{
+					File:      absPath,
+					Question:  qa["question"],
+					Answer:    notes.ClozeAnswer(qa["question"], ord),
+					RevisitIn: 0, // Due immediately
+					Type:      store.CardTypeCloze,
+					Ordinal:   ord,
+				})
+			}
+			continue
+		}
+		flashcards = append(flashcards, store.Flashcard{
+			File:      absPath,
+			Question:  qa["question"],
+			Answer:    qa["answer"],
+			RevisitIn: 0, // Due immediately
+			Type:      store.CardTypeBasic,
+		})
+	}
+	return flashcards
+}
+
+// syncInlineCards stores the inline cards found in a note body. Cards are keyed
+// by their text hash, so unchanged cards keep their schedule across runs and
+// cards removed from the note are deleted. Cards replaced as leeches are not
+// imported again. It returns how many cards were added.
+func syncInlineCards(absPath, body string, directives notes.Directives, reverse bool) (int, error) {
+	existing, err := Store.GetSourceHashes(absPath)
+	if err != nil {
+		return 0, fmt.Errorf("DB query error: %w", err)
+	}
+	// Cards replaced as leeches stay out while their text is in the note
+	retired, err := Store.GetRetiredHashes(absPath)
+	if err != nil {
+		return 0, fmt.Errorf("DB query error: %w", err)
+	}
+
+	count := 0
+	seen := make(map[string]bool)
+	for _, card := range notes.ExtractInlineCards(body) {
+		seen[card.Hash] = true
+		if retired[card.Hash] {
+			continue
+		}
+		id, ok := existing[card.Hash]
+		if !ok {
+			fc := store.Flashcard{
+				File:       absPath,
+				Question:   card.Question,
+				Answer:     card.Answer,
+				RevisitIn:  0, // Due immediately
+				Deck:       directives.Deck,
+				Tags:       directives.Tags,
+				SourceHash: card.Hash,
+				Type:       store.CardTypeBasic,
+				Ordinal:    card.Ordinal,
+			}
+			if card.Ordinal > 0 {
+				fc.Type = store.CardTypeCloze
+			}
+			if id, err = Store.CreateFlashcard(fc); err != nil {
+				return count, fmt.Errorf("DB insert error: %w", err)
+			}
+			count++
+		}
+		if !reverse || card.Ordinal > 0 {
+			continue
+		}
+
+		// The reverse of an inline card is keyed on the original's hash so it
+		// follows the original and is removed when reversing is turned off
+		reverseHash := notes.TextHash(card.Hash, "reverse")
+		seen[reverseHash] = true
+		if _, ok := existing[reverseHash]; ok || retired[reverseHash] {
+			continue
+		}
+		rev := store.Flashcard{ID: id, File: absPath, Question: card.Question, Answer: card.Answer, Deck: directives.Deck, Tags: directives.Tags}.Reverse()
+		rev.SourceHash = reverseHash
+		if err := Store.InsertFlashcard(rev); err != nil {
+			return count, fmt.Errorf("DB insert error: %w", err)
+		}
+		count++
+	}
+
+	for hash, id := range existing {
+		if !seen[hash] {
+			if err := Store.DeleteFlashcard(id); err != nil {
+				return count, fmt.Errorf("DB delete error: %w", err)
+			}
+		}
+	}
+	for hash := range retired {
+		if !seen[hash] {
+			if err := Store.UnretireHash(absPath, hash); err != nil {
+				return count, fmt.Errorf("DB delete error: %w", err)
+			}
+		}
+	}
+	return count, nil
 }
 
-func getMarkdownFiles(path string) ([]string, error) {
+// buildGeneratePrompt creates the flashcard generation prompt for a note body,
+// applying the note's front matter directives and asking the model to leave
+// out the existing questions
+func buildGeneratePrompt(body string, d notes.Directives, existing []string) string {
+	var extra strings.Builder
+	if d.MaxCards > 0 {
+		extra.WriteString(fmt.Sprintf("Generate at most %d flashcards.\n", d.MaxCards))
+	}
+	if d.Language != "" {
+		extra.WriteString(fmt.Sprintf("Write every question and answer in %s.\n", d.Language))
+	}
+	if d.Prompt != "" {
+		extra.WriteString(d.Prompt + "\n")
+	}
+	if len(existing) > 0 {
+		extra.WriteString("These flashcards already exist for the notes. Do not output them again, not even reworded, only output flashcards for what they do not cover:\n")
+		for _, q := range existing {
+			extra.WriteString("- " + q + "\n")
+		}
+	}
+	if extra.Len() > 0 {
+		extra.WriteString("\n")
+	}
+
+	return fmt.Sprintf(`You are an expert flashcard generator. Your task is to extract spaced repetition flashcards from the following markdown content.
+
+Strictly output ONLY pairs in this format, with no extra text, explanations, or numbering:
+Q: <question>
+A: <answer>
+
+Repeat for each flashcard. Do not include any other text, headers, or formatting. Do not add explanations, summaries, or comments. Only output Q: and A: pairs, one after another.
+
+For definitions and key terms you may instead output a cloze card: a single line starting with C: containing a sentence from the notes where each term to recall is wrapped as {{c1::term}}, numbering separate terms c1, c2, and so on.
+
+Example:
+Q: What is the capital of France?
+A: Paris
+Q: What is 2+2?
+A: 4
+C: A {{c1::goroutine}} is a lightweight thread managed by the {{c2::Go runtime}}.
+
+%sMarkdown:
+%s`, extra.String(), body)
+}
+
+// ignoreFileName is the per-directory file listing notes to skip during discovery
+const ignoreFileName = ".catvignore"
+
+// defaultIgnores are skipped unless re-included by an ignore file
+var defaultIgnores = []string{".git/", "node_modules/"}
+
+// discoverOptions controls which markdown files getMarkdownFiles returns
+type discoverOptions struct {
+	Include   []string // glob patterns a file or one of its folders must match, empty means all
+	Exclude   []string // glob patterns that remove matching files or folders, over any ignore file
+	Gitignore bool     // also honor .gitignore files
+}
+
+// isMarkdown reports whether a path has a markdown extension
+func isMarkdown(path string) bool {
+	return filepath.Ext(path) == ".md" || filepath.Ext(path) == ".markdown"
+}
+
+func getMarkdownFiles(path string, opts discoverOptions) ([]string, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
-	if info.IsDir() {
-		// Pre-allocate slice with reasonable initial capacity to reduce allocations
-		files := make([]string, 0, 10)
-		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if !d.IsDir() && (filepath.Ext(p) == ".md" || filepath.Ext(p) == ".markdown") {
-				files = append(files, p)
+	if !info.IsDir() {
+		// An explicitly requested file is always processed
+		if isMarkdown(path) {
+			return []string{path}, nil
+		}
+		return nil, nil
+	}
+
+	root := filepath.Clean(path)
+	matcher, err := newDiscoverMatcher(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	include := ignore.NewMatcher()
+	if err := include.Add(root, opts.Include...); err != nil {
+		return nil, err
+	}
+
+	// Pre-allocate slice with reasonable initial capacity to reduce allocations
+	files := make([]string, 0, 10)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && matcher.skipped(p, true) {
+				return filepath.SkipDir
 			}
+			return matcher.addIgnoreFiles(p)
+		}
+		if !isMarkdown(p) || matcher.skipped(p, false) || !included(include, root, p) {
 			return nil
-		})
-		return files, err
-	} else if filepath.Ext(path) == ".md" || filepath.Ext(path) == ".markdown" {
-		return []string{path}, nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	return files, err
+}
+
+// included reports whether a file under root matches the include patterns,
+// by itself or through one of its folders. Without patterns every file is.
+func included(include *ignore.Matcher, root, p string) bool {
+	if include.Len() == 0 || include.Match(p, false) {
+		return true
+	}
+	for dir := filepath.Dir(p); dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+		if include.Match(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverMatcher decides which paths under a notes folder are skipped: the
+// default ignores and the ignore files found, the last match winning, then the
+// exclude patterns, which an ignore file cannot override
+type discoverMatcher struct {
+	ignores   *ignore.Matcher
+	excludes  *ignore.Matcher
+	gitignore bool
+}
+
+// newDiscoverMatcher returns the matcher of the paths under root, ignore
+// files are added as the folders are walked
+func newDiscoverMatcher(root string, opts discoverOptions) (*discoverMatcher, error) {
+	m := &discoverMatcher{ignores: ignore.NewMatcher(), excludes: ignore.NewMatcher(), gitignore: opts.Gitignore}
+	if err := m.ignores.Add(root, defaultIgnores...); err != nil {
+		return nil, err
+	}
+	if err := m.excludes.Add(root, opts.Exclude...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// skipped reports whether a path is left out of discovery
+func (m *discoverMatcher) skipped(p string, isDir bool) bool {
+	return m.excludes.Match(p, isDir) || m.ignores.Match(p, isDir)
+}
+
+// addIgnoreFiles adds the ignore files of dir. They apply to the directory
+// they live in and everything below it.
+func (m *discoverMatcher) addIgnoreFiles(dir string) error {
+	if err := m.ignores.AddFile(filepath.Join(dir, ignoreFileName)); err != nil {
+		return err
+	}
+	if m.gitignore {
+		return m.ignores.AddFile(filepath.Join(dir, ".gitignore"))
+	}
+	return nil
+}
+
+// dirIgnored reports whether getMarkdownFiles skips dir, a directory under
+// root, by itself or through one of its parents
+func dirIgnored(root, dir string, opts discoverOptions) (bool, error) {
+	root = filepath.Clean(root)
+	rel, err := filepath.Rel(root, filepath.Clean(dir))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, err
+	}
+	matcher, err := newDiscoverMatcher(root, opts)
+	if err != nil {
+		return false, err
+	}
+	if err := matcher.addIgnoreFiles(root); err != nil {
+		return false, err
+	}
+	p := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		p = filepath.Join(p, part)
+		if matcher.skipped(p, true) {
+			return true, nil
+		}
+		if err := matcher.addIgnoreFiles(p); err != nil {
+			return false, err
+		}
 	}
-	return nil, nil
+	return false, nil
 }
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"catv/internal/config"
+	"catv/internal/grading"
+	"catv/internal/hint"
+	"catv/internal/ollama"
+	"catv/internal/tui"
+)
+
+// gradeTimeout is how long review waits for the model's verdict before
+// leaving the grade to the user
+const gradeTimeout = 20 * time.Second
+
+// hintTimeout is how long review waits for the model's nudge
+const hintTimeout = 20 * time.Second
+
+// ollamaGrader grades typed answers with the configured Ollama model
+func ollamaGrader(cfg *config.Config, model string) tui.Grader {
+	return func(ctx context.Context, question, expected, given string) (grading.Verdict, error) {
+		response, err := ollama.GenerateJSON(ctx, model, cfg.OllamaURL, grading.VerdictPrompt(question, expected, given))
+		if err != nil {
+			return grading.Verdict{}, err
+		}
+		return grading.ParseVerdict(response)
+	}
+}
+
+// ollamaHinter writes nudges towards the answer with the configured Ollama model
+func ollamaHinter(cfg *config.Config, model string) tui.Hinter {
+	return func(ctx context.Context, question, answer string) (string, error) {
+		response, err := ollama.GenerateQA(ctx, model, cfg.OllamaURL, hint.Prompt(question, answer))
+		if err != nil {
+			return "", err
+		}
+		return hint.Clean(response, answer), nil
+	}
+}
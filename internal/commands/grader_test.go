@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"catv/internal/config"
+	"catv/internal/grading"
+)
+
+func TestOllamaGrader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"response": `{"verdict": "partial", "feedback": "Mention reliability."}`,
+			"done":     true,
+		})
+	}))
+	defer server.Close()
+
+	grader := ollamaGrader(&config.Config{OllamaURL: server.URL}, "test-model")
+	verdict, err := grader(context.Background(), "What is TCP?", "A reliable transport protocol", "A protocol")
+	if err != nil {
+		t.Fatalf("grader() error = %v", err)
+	}
+	if verdict.Grade != grading.GradePartial || verdict.Feedback != "Mention reliability." {
+		t.Errorf("grader() = %+v", verdict)
+	}
+}
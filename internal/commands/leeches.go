@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"catv/internal/config"
+	"catv/internal/notes"
+	"catv/internal/ollama"
+	"catv/internal/security"
+	"catv/internal/store"
+	"catv/internal/tui"
+	"catv/internal/tui/markdown"
+	"catv/internal/tui/theme"
+
+	"github.com/spf13/cobra"
+)
+
+// rewriteFunc asks a model to answer a prompt
+type rewriteFunc func(ctx context.Context, prompt string) (string, error)
+
+var LeechesCmd = &cobra.Command{
+	Use:   "leeches",
+	Short: "List and fix cards you keep forgetting",
+	Long: `List the flashcards tagged as leeches: cards forgotten review.leech_threshold
+times or more (8 by default).
+
+With --fix each leech is shown with the part of its note it came from, and the
+Ollama model is asked to rewrite it into smaller, clearer cards. Once you
+accept them they replace the leech, which keeps its review history.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		leeches, err := Store.GetLeeches()
+		if err != nil {
+			tui.PrintError("DB query error:", err)
+			return
+		}
+		if len(leeches) == 0 {
+			tui.PrintInfo("No leeches. Well done!")
+			return
+		}
+		out := cmd.OutOrStdout()
+		if fix, _ := cmd.Flags().GetBool("fix"); !fix {
+			printLeeches(out, leeches)
+			return
+		}
+
+		cfg := config.LoadConfig()
+		if err := security.ValidateURL(cfg.OllamaURL); err != nil {
+			tui.PrintError("Invalid Ollama URL, leeches cannot be rewritten:", err)
+			return
+		}
+		rewrite := func(ctx context.Context, prompt string) (string, error) {
+			return ollama.GenerateQA(ctx, Model, cfg.OllamaURL, prompt)
+		}
+		in := commandInput(cmd)
+		fixed := 0
+		for _, fc := range leeches {
+			replaced, err := fixLeech(context.Background(), fc, rewrite, cfg.Display.PlainText, in, out)
+			if err != nil {
+				tui.PrintError(fmt.Sprintf("Failed to fix leech %d:", fc.ID), err)
+				continue
+			}
+			if replaced {
+				fixed++
+			}
+		}
+		tui.PrintSuccess(fmt.Sprintf("Replaced %d of %d leech(es)", fixed, len(leeches)))
+	},
+}
+
+// fixLeech shows a leech with its source, asks the model to rewrite it into
+// smaller cards and replaces the leech with them once confirmed. Cards are
+// shown as written when plain is set. It reports whether the leech was replaced.
+func fixLeech(ctx context.Context, fc store.Flashcard, rewrite rewriteFunc, plain bool, in *bufio.Reader, out io.Writer) (bool, error) {
+	question, answer := leechText(fc)
+	excerpt := cardExcerpt(fc)
+	_, _ = fmt.Fprintf(out, "\nLeech %d, forgotten %d times (%s)\nQ: %s\nA: %s\n", fc.ID, fc.Lapses, fc.File,
+		previewText(question, 3, plain), previewText(answer, 3, plain))
+	if excerpt != "" {
+		_, _ = fmt.Fprintf(out, "\nSource:\n%s\n", excerpt)
+	}
+
+	_, _ = fmt.Fprintln(out, "\nAsking the model for a rewrite...")
+	response, err := rewrite(ctx, buildLeechPrompt(question, answer, excerpt))
+	if err != nil {
+		return false, fmt.Errorf("ollama error: %w", err)
+	}
+	qas, err := ollama.ParseFlashcards(response)
+	if err != nil {
+		return false, fmt.Errorf("ollama parsing error: %w", err)
+	}
+	replacements := flashcardsFromQAs(qas, fc.File)
+	if len(replacements) == 0 {
+		return false, fmt.Errorf("the model did not suggest any card")
+	}
+
+	// The new cards start over, without the leech tag
+	tags := slices.DeleteFunc(slices.Clone(fc.Tags), func(t string) bool { return t == store.LeechTag })
+	_, _ = fmt.Fprintln(out, "\nSuggested cards:")
+	for i := range replacements {
+		replacements[i].Deck = fc.Deck
+		replacements[i].Tags = tags
+		q, a := leechText(replacements[i])
+		prefix := fmt.Sprintf("%d. Q: ", i+1)
+		_, _ = fmt.Fprintf(out, "%s%s\n%*sA: %s\n", prefix, previewText(q, len(prefix), plain),
+			len(prefix)-3, "", previewText(a, len(prefix), plain))
+	}
+	if !confirm(in, out, fmt.Sprintf("Replace leech %d with these %d card(s)?", fc.ID, len(replacements))) {
+		return false, nil
+	}
+	if err := Store.ReplaceFlashcard(fc.ID, replacements); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// leechText returns a card's question and answer as plain text, with the
+// deletion of a cloze card blanked out
+func leechText(fc store.Flashcard) (question, answer string) {
+	if fc.IsCloze() {
+		return notes.RenderCloze(fc.Question, fc.Ordinal, false, nil), fc.Answer
+	}
+	return fc.Question, fc.Answer
+}
+
+// printLeeches lists leeches one per line, their question shown as asked
+func printLeeches(out io.Writer, leeches []store.Flashcard) {
+	for _, fc := range leeches {
+		state := ""
+		if fc.Suspended {
+			state = ", suspended"
+		}
+		question, _ := leechText(fc)
+		_, _ = fmt.Fprintf(out, "%d\t%d lapses%s\t%s\n", fc.ID, fc.Lapses, state, strings.Join(strings.Fields(question), " "))
+	}
+}
+
+// previewText renders a card's text for the terminal, the lines after the
+// first indented by indent columns to line up behind a label
+func previewText(text string, indent int, plain bool) string {
+	width := theme.MaxContentWidth - indent
+	rendered := markdown.Render(text, width)
+	if plain {
+		rendered = markdown.Plain(text, width)
+	}
+	lines := strings.Split(rendered, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = strings.Repeat(" ", indent) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// buildLeechPrompt asks the model to split a card that keeps being forgotten
+// into smaller ones, in the flashcard generation format
+func buildLeechPrompt(question, answer, excerpt string) string {
+	source := ""
+	if excerpt != "" {
+		source = fmt.Sprintf("\nThe card was written from these notes:\n%s\n", excerpt)
+	}
+	return fmt.Sprintf(`You are an expert flashcard writer. The following flashcard is forgotten again and again, usually because it asks for too much at once or is ambiguous.
+
+Question: %s
+Answer: %s
+%s
+Rewrite it into 2 to 4 smaller, clearer flashcards that together cover the same knowledge. Each card must test a single fact, have a short unambiguous answer, and make sense on its own.
+
+Strictly output ONLY pairs in this format, with no extra text, explanations, or numbering:
+Q: <question>
+A: <answer>
+
+For a definition or key term you may instead output a cloze card: a single line starting with C: containing a sentence where the term to recall is wrapped as {{c1::term}}.`, question, answer, source)
+}
+
+func init() {
+	LeechesCmd.Flags().Bool("fix", false, "Rewrite each leech into smaller cards with the Ollama model")
+}
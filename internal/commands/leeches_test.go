@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"catv/internal/config"
+	"catv/internal/store"
+)
+
+func TestPrintLeeches(t *testing.T) {
+	leeches := []store.Flashcard{
+		{ID: 1, Question: "What does TCP\nguarantee?", Lapses: 8},
+		{ID: 2, Question: "{{c1::Go}} was created at {{c2::Google}}", Type: store.CardTypeCloze, Ordinal: 2, Lapses: 9, Suspended: true},
+	}
+	var out bytes.Buffer
+	printLeeches(&out, leeches)
+	want := "1\t8 lapses\tWhat does TCP guarantee?\n2\t9 lapses, suspended\tGo was created at [...]\n"
+	if out.String() != want {
+		t.Errorf("printLeeches() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestFixLeech(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	original := Store
+	Store = s
+	defer func() { Store = original }()
+
+	leech := store.Flashcard{File: "/net.md", Question: "What are the TCP handshake steps and their flags?", Answer: "SYN, SYN-ACK, ACK",
+		Deck: "net", Tags: []string{"tcp", store.LeechTag}}
+	id, err := s.CreateFlashcard(leech)
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	leech.ID = id
+	leech.Lapses = 9
+
+	var prompt string
+	rewrite := func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return "Q: First TCP handshake segment?\nA: SYN\nQ: Reply to a SYN?\nA: SYN-ACK\n", nil
+	}
+
+	// Declined suggestions leave the leech alone
+	var out bytes.Buffer
+	replaced, err := fixLeech(context.Background(), leech, rewrite, false, bufio.NewReader(strings.NewReader("n\n")), &out)
+	if err != nil || replaced {
+		t.Fatalf("fixLeech() = %v, %v, want the leech kept", replaced, err)
+	}
+	if !strings.Contains(prompt, leech.Question) || !strings.Contains(out.String(), "1. Q: First TCP handshake segment?") {
+		t.Errorf("Expected the leech in the prompt and the suggestions shown, got prompt %q, output %q", prompt, out.String())
+	}
+
+	replaced, err = fixLeech(context.Background(), leech, rewrite, false, bufio.NewReader(strings.NewReader("y\n")), &out)
+	if err != nil || !replaced {
+		t.Fatalf("fixLeech() = %v, %v, want the leech replaced", replaced, err)
+	}
+	all, _ := s.GetAllFlashcards()
+	if len(all) != 2 || all[0].Question != "First TCP handshake segment?" || all[0].Deck != "net" || !reflect.DeepEqual(all[0].Tags, []string{"tcp"}) {
+		t.Errorf("Expected the leech replaced by untagged cards in its deck, got %+v", all)
+	}
+
+	failing := func(ctx context.Context, p string) (string, error) { return "", errors.New("offline") }
+	if _, err := fixLeech(context.Background(), all[0], failing, false, bufio.NewReader(strings.NewReader("")), &out); err == nil {
+		t.Error("Expected a model failure to be reported")
+	}
+}
+
+func TestFixLeechInlineCard(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	original := Store
+	Store = s
+	defer func() { Store = original }()
+
+	note := filepath.Join(t.TempDir(), "net.md")
+	if err := os.WriteFile(note, []byte("---\ncatv.reverse: true\n---\nTCP handshake :: SYN, SYN-ACK, ACK\n"), 0600); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+	generate := func() []store.Flashcard {
+		t.Helper()
+		if _, err := generateForFile(context.Background(), &config.Config{}, "", note, generateOptions{}); err != nil {
+			t.Fatalf("generateForFile() error = %v", err)
+		}
+		cards, _ := s.GetAllFlashcards()
+		return cards
+	}
+
+	cards := generate()
+	if len(cards) != 2 {
+		t.Fatalf("Expected the inline card and its reverse, got %+v", cards)
+	}
+	rewrite := func(ctx context.Context, p string) (string, error) {
+		return "Q: First TCP handshake segment?\nA: SYN\n", nil
+	}
+	var out bytes.Buffer
+	if replaced, err := fixLeech(context.Background(), cards[0], rewrite, false, bufio.NewReader(strings.NewReader("y\n")), &out); err != nil || !replaced {
+		t.Fatalf("fixLeech() = %v, %v, want the leech replaced", replaced, err)
+	}
+
+	// Generating again keeps the replacement and does not bring back the leech or its reverse
+	cards = generate()
+	if len(cards) != 1 || cards[0].Question != "First TCP handshake segment?" {
+		t.Errorf("Expected only the replacement after generating again, got %+v", cards)
+	}
+}
+
+func TestPreviewText(t *testing.T) {
+	tests := []struct {
+		text  string
+		plain bool
+		want  string
+	}{
+		{"SYN", false, "SYN"},
+		{"- SYN\n- ACK", false, "• SYN\n   • ACK"},
+		{"- SYN\n\n- ACK", true, "- SYN\n\n   - ACK"},
+	}
+	for _, tt := range tests {
+		if got := previewText(tt.text, 3, tt.plain); got != tt.want {
+			t.Errorf("previewText(%q, %v) = %q, want %q", tt.text, tt.plain, got, tt.want)
+		}
+	}
+}
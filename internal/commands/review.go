@@ -1,81 +1,366 @@
 package commands
 
 import (
-	"catv/internal/tui"
+	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"catv/internal/config"
+	"catv/internal/queue"
+	"catv/internal/scheduler"
+	"catv/internal/security"
+	"catv/internal/stats"
+	"catv/internal/store"
+	"catv/internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
 
+// Review modes selected with review --mode
+const (
+	reviewModeClassic = "classic"
+	reviewModeChoice  = "mc"
+	reviewModeTyped   = "typed"
+)
+
 var ReviewCmd = &cobra.Command{
 	Use:   "review",
 	Short: "Review flashcards",
+	Long: `Review the flashcards that are due in the selected files.
+
+By default you reveal the answer and grade yourself. With --mode mc each card
+is asked as multiple choice: the answer is mixed with plausible wrong options
+generated once per card by the Ollama model (or, when it is unavailable, taken
+from other cards in the same deck) and graded automatically. With --mode typed
+you type the answer, which is compared with the stored one to suggest a grade
+you can accept or override; add --llm-grade to also have the Ollama model judge
+the meaning of the answer.
+
+Each card is timed, 30 seconds by default. Set review.timer in
+~/.catv/config.json to change it, 0 to turn the timer off, and
+review.deck_timers to override it per deck. Press p (ctrl+p when typing) to
+pause. Cards answered after the timer ran out can be revisited in at most 3
+days.
+
+Failed cards are asked again later in the session at each of the
+review.learning_steps (review.relearning_steps for cards that had been
+learned) until answered correctly, then are due again the next day.
+
+Cards are reviewed in the order they were created. Use --order random (with
+--seed to repeat a shuffle), overdue (longest overdue first), interleave (one
+card of each deck or file in turn) or weakest (most lapses first), and
+--new-mix to place new cards first, last, or N new cards every M reviews.
+Set review.order and review.new_mix in the config file to change the defaults.
+
+Each day at most review.new_per_day new cards (20 by default) and
+review.reviews_per_day reviews (200 by default) are served per deck, counting
+the cards already reviewed that day; -1 removes a limit. review.deck_limits
+overrides them per deck. Days start at review.rollover_hour (4 by default), so
+a late review still counts for the day before.
+
+Press u (ctrl+z when typing) to take back the last grade and grade the card
+again.
+
+Press s to suspend the current card until it is unsuspended in admin, z to
+bury it until the next day, and f to flag it for later attention. When typing,
+these keys work on the answer screen. Press e on the answer screen to fix the
+card's question or answer.
+
+Cards forgotten review.leech_threshold times (8 by default) become leeches:
+they are tagged leech, and suspended when review.suspend_leeches is set. Run
+catv leeches --fix to rewrite them.
+
+Grades are saved as they are given. When a review is interrupted, the next
+review offers to resume it with the cards left.
+
+Press x on the answer screen to ask the model to explain the card, with the
+note it came from as context, and keep asking follow-up questions.
+
+The review ends on a summary of the session: accuracy, time spent, the slowest
+and failed cards and when the cards are due next. Use --report to save it as
+a markdown file.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Step 1: Get all unique files from database
-		allFiles, err := Store.GetUniqueFiles()
+		mode, llmGrade, err := reviewMode(cmd)
 		if err != nil {
-			tui.PrintError("Failed to get files from database:", err)
+			tui.PrintError("Invalid review mode:", err)
 			return
 		}
 
-		if len(allFiles) == 0 {
-			tui.PrintInfo("No files found in the database. Generate flashcards first.")
-			return
+		cfg := config.LoadConfig()
+		order := queue.Options{Order: cfg.Review.Order, Mix: cfg.Review.NewMix}
+		// The root command runs review without its flags, keep the configured order
+		if o, _ := cmd.Flags().GetString("order"); o != "" {
+			order.Order = o
 		}
-
-		// Step 2: Show file selector UI
-		fileSelector := tui.NewFileSelectorModel(allFiles)
-		p := tea.NewProgram(fileSelector)
-		if _, err := p.Run(); err != nil {
-			fmt.Println("Error running file selector:", err)
+		if mix, _ := cmd.Flags().GetString("new-mix"); mix != "" {
+			order.Mix = mix
+		}
+		order.Seed, _ = cmd.Flags().GetUint64("seed")
+		if err = order.Validate(); err != nil {
+			tui.PrintError("Invalid review order:", err)
 			return
 		}
 
-		// Step 3: Get selected files
-		selectedFiles := fileSelector.GetSelectedFiles()
-		if len(selectedFiles) == 0 {
-			tui.PrintInfo("No files selected. See you next time!")
-			return
+		// Offer to pick up where an interrupted review stopped
+		session, flashcards := resumeSession(cmd)
+		var deferred int
+		if session != nil {
+			mode = session.Mode
+			llmGrade = llmGrade && mode == reviewModeTyped
+		} else {
+			flashcards = selectFlashcards(cfg)
+			if len(flashcards) == 0 {
+				return
+			}
+			if flashcards, err = queue.Build(flashcards, order, time.Now()); err != nil {
+				tui.PrintError("Invalid review order:", err)
+				return
+			}
+			if flashcards, deferred, err = limitFlashcards(flashcards, cfg.Review); err != nil {
+				tui.PrintError("Failed to count today's reviews:", err)
+				return
+			}
+			if len(flashcards) == 0 {
+				tui.PrintInfo(fmt.Sprintf("Daily limits reached, %d card(s) left for tomorrow.", deferred))
+				return
+			}
 		}
 
-		// Step 4: Get flashcards for selected files
-		flashcards, err := Store.GetFlashcardsForReviewByFiles(selectedFiles)
-		if err != nil {
-			tui.PrintError("DB query error:", err)
-			return
+		buryUntil := scheduler.NextDayStart(time.Now(), cfg.Review.RolloverHour)
+		var sessionID int
+		if session != nil {
+			sessionID = session.ID
+		} else {
+			ids := make([]int, len(flashcards))
+			for i, fc := range flashcards {
+				ids[i] = fc.ID
+			}
+			id, err := Store.StartSession(mode, ids)
+			if err != nil {
+				tui.PrintError("Failed to record the review session, it cannot be resumed:", err)
+			}
+			sessionID = id
 		}
 
-		if len(flashcards) == 0 {
-			tui.PrintInfo("No flashcards due for review in the selected file(s). Well done!")
-			return
+		recorder := newSessionRecorder(Store, sessionID, mode, buryUntil)
+		opts := append(reviewOptions(cfg, mode, llmGrade, flashcards),
+			// Grades are saved as they are given so an interrupted review loses nothing
+			tui.WithRecorder(recorder),
+			tui.WithMarker(recorder),
+			tui.WithEditor(recorder.Edit),
+			tui.WithLeeches(cfg.Review.LeechThreshold, cfg.Review.SuspendLeeches),
+			tui.WithDeferred(deferred),
+		)
+		model, _ := runReview(flashcards, opts)
+		if err := endSession(Store, sessionID, model.Pending()); err != nil {
+			tui.PrintError("DB update error:", err)
 		}
 
-		// Step 5: Run Bubble Tea TUI for review
-		model := tui.NewReviewModel(flashcards)
-		p = tea.NewProgram(model)
-		if _, err := p.Run(); err != nil {
-			fmt.Println("Error running review TUI:", err)
+		finishReview(cmd, model, "Reviewed")
+	},
+}
+
+// finishReview prints a line summing up a review and saves its report when
+// --report is set
+func finishReview(cmd *cobra.Command, model *tui.ReviewModel, verb string) {
+	summary := model.Summary()
+	if len(summary.Cards) == 0 {
+		return
+	}
+	tui.PrintSuccess(fmt.Sprintf("%s %d card(s), %d known on the first try", verb, len(summary.Cards), summary.FirstTry()))
+	path, _ := cmd.Flags().GetString("report")
+	if path == "" {
+		return
+	}
+	if err := os.WriteFile(path, []byte(summary.Report(time.Now())), 0600); err != nil {
+		tui.PrintError("Failed to save the session report:", err)
+		return
+	}
+	tui.PrintInfo("Session report saved to " + path)
+}
+
+// reviewMode returns the review mode selected by the --mode and --llm-grade
+// flags, and whether typed answers are graded by the model
+func reviewMode(cmd *cobra.Command) (mode string, llmGrade bool, err error) {
+	// The root command runs review without its flags, fall back to the default mode
+	mode, _ = cmd.Flags().GetString("mode")
+	if mode == "" {
+		mode = reviewModeClassic
+	}
+	if mode != reviewModeClassic && mode != reviewModeChoice && mode != reviewModeTyped {
+		return "", false, fmt.Errorf("unknown review mode %q, expected %s, %s or %s", mode, reviewModeClassic, reviewModeChoice, reviewModeTyped)
+	}
+	llmGrade, _ = cmd.Flags().GetBool("llm-grade")
+	if llmGrade {
+		if mode == reviewModeChoice {
+			return "", false, fmt.Errorf("--llm-grade grades typed answers and cannot be used with --mode mc")
 		}
+		mode = reviewModeTyped
+	}
+	return mode, llmGrade, nil
+}
 
-		// Step 6: After review, update DB only for flashcards that were actually answered
-		for i, fc := range flashcards {
-			if model.FlashcardWasCorrect(i) && model.FlashcardRevisitIn(i) > 0 {
-				fc.RevisitIn = model.FlashcardRevisitIn(i)
-				if err := Store.UpdateFlashcard(fc); err != nil {
-					tui.PrintError("DB update error:", err)
-				} else {
-					tui.PrintSuccess(fmt.Sprintf("Updated flashcard %d: revisitin=%d", fc.ID, fc.RevisitIn))
-				}
-			} else if !model.FlashcardWasCorrect(i) && model.FlashcardRevisitIn(i) > 0 {
-				// Incorrect -> revisit sooner (e.g. tomorrow => 1)
-				fc.RevisitIn = 1
-				if err := Store.UpdateFlashcard(fc); err != nil {
-					tui.PrintError("DB update error:", err)
-				} else {
-					tui.PrintSuccess(fmt.Sprintf("Marked flashcard %d incorrect: revisitin set to 1", fc.ID))
-				}
+// reviewOptions configures the review UI for mode: timer, learning steps,
+// model help and the way answers are given
+func reviewOptions(cfg *config.Config, mode string, llmGrade bool, flashcards []store.Flashcard) []tui.ReviewOption {
+	opts := []tui.ReviewOption{
+		tui.WithTimer(func(fc store.Flashcard) time.Duration {
+			return cfg.Review.TimerFor(fc.Deck)
+		}),
+	}
+	if learning, relearning, err := cfg.Review.Steps(); err != nil {
+		tui.PrintError("Ignoring learning steps, failed cards will be due tomorrow:", err)
+	} else {
+		opts = append(opts, tui.WithLearningSteps(learning, relearning))
+	}
+	if cfg.Display.PlainText {
+		opts = append(opts, tui.WithPlainText())
+	}
+	if status, err := dailyGoal(cfg); err != nil {
+		tui.PrintError("Failed to read today's progress, the daily goal won't be shown:", err)
+	} else {
+		opts = append(opts, tui.WithGoal(status))
+	}
+	if security.ValidateURL(cfg.OllamaURL) == nil {
+		opts = append(opts, tui.WithExplainer(ollamaChat(cfg, Model), cardExcerpt), tui.WithHinter(ollamaHinter(cfg, Model), hintTimeout))
+	}
+	switch mode {
+	case reviewModeTyped:
+		opts = append(opts, tui.WithTypedAnswers())
+		if llmGrade {
+			if err := security.ValidateURL(cfg.OllamaURL); err != nil {
+				tui.PrintError("Invalid Ollama URL, grading without the model:", err)
+			} else {
+				opts = append(opts, tui.WithGrader(ollamaGrader(cfg, Model), gradeTimeout))
 			}
 		}
-	},
+	case reviewModeChoice:
+		var generate distractorFunc
+		if err := security.ValidateURL(cfg.OllamaURL); err == nil {
+			generate = ollamaDistractors(cfg, Model)
+		}
+		tui.PrintInfo(fmt.Sprintf("Preparing multiple-choice options for %d flashcard(s)...", len(flashcards)))
+		opts = append(opts, tui.WithChoices(prepareChoices(context.Background(), flashcards, generate)))
+	}
+	return opts
+}
+
+// dailyGoal returns today's progress towards the configured daily goal
+func dailyGoal(cfg *config.Config) (stats.GoalStatus, error) {
+	return stats.CollectGoal(Store, stats.Goal(cfg.Goal), time.Now(), cfg.Review.RolloverHour)
+}
+
+// runReview runs the review UI until it is quit and waits for its grades to
+// be saved. The returned error is the UI's, save failures are printed.
+func runReview(flashcards []store.Flashcard, opts []tui.ReviewOption) (*tui.ReviewModel, error) {
+	model := tui.NewReviewModel(flashcards, opts...)
+	p := tea.NewProgram(model)
+	_, runErr := p.Run()
+	if runErr != nil {
+		fmt.Println("Error running review TUI:", runErr)
+	}
+	if err := model.Close(); err != nil {
+		tui.PrintError("Failed to save some grades:", err)
+	}
+	return model, runErr
+}
+
+// selectFlashcards asks which files to review, showing the daily goal, and
+// returns their due flashcards, or none after telling the user why
+func selectFlashcards(cfg *config.Config) []store.Flashcard {
+	// Step 1: Get all unique files from database
+	allFiles, err := Store.GetUniqueFiles()
+	if err != nil {
+		tui.PrintError("Failed to get files from database:", err)
+		return nil
+	}
+
+	if len(allFiles) == 0 {
+		tui.PrintInfo("No files found in the database. Generate flashcards first.")
+		return nil
+	}
+
+	// Step 2: Show file selector UI
+	var opts []tui.FileSelectorOption
+	if status, err := dailyGoal(cfg); err == nil {
+		opts = append(opts, tui.WithSelectorGoal(status))
+	}
+	fileSelector := tui.NewFileSelectorModel(allFiles, opts...)
+	p := tea.NewProgram(fileSelector)
+	if _, err := p.Run(); err != nil {
+		fmt.Println("Error running file selector:", err)
+		return nil
+	}
+
+	// Step 3: Get selected files
+	selectedFiles := fileSelector.GetSelectedFiles()
+	if len(selectedFiles) == 0 {
+		tui.PrintInfo("No files selected. See you next time!")
+		return nil
+	}
+
+	// Step 4: Get flashcards for selected files
+	flashcards, err := Store.GetFlashcardsForReviewByFiles(selectedFiles)
+	if err != nil {
+		tui.PrintError("DB query error:", err)
+		return nil
+	}
+
+	// Review only one card per sibling group (reverse cards, cloze deletions of the same text)
+	flashcards = store.DropSiblings(flashcards)
+
+	if len(flashcards) == 0 {
+		tui.PrintInfo("No flashcards due for review in the selected file(s). Well done!")
+	}
+	return flashcards
+}
+
+// limitFlashcards keeps the flashcards that fit in today's limits, returning
+// them with the number left for tomorrow
+func limitFlashcards(flashcards []store.Flashcard, cfg config.ReviewConfig) ([]store.Flashcard, int, error) {
+	reviewed, err := Store.GetDayCounts(scheduler.DayStart(time.Now(), cfg.RolloverHour))
+	if err != nil {
+		return nil, 0, err
+	}
+	kept, deferred := queue.Limit(flashcards, cfg.LimitsFor, reviewed)
+	return kept, deferred, nil
+}
+
+// resumeSession offers to resume the last interrupted review, returning it
+// with its remaining flashcards when accepted. A declined session is closed.
+func resumeSession(cmd *cobra.Command) (*store.ReviewSession, []store.Flashcard) {
+	session, err := Store.GetUnfinishedSession()
+	if err != nil {
+		tui.PrintError("Failed to look for an interrupted review:", err)
+		return nil, nil
+	}
+	if session == nil {
+		return nil, nil
+	}
+	remaining, err := Store.GetSessionRemaining(session)
+	if err != nil {
+		tui.PrintError("Failed to load the interrupted review:", err)
+		return nil, nil
+	}
+	question := fmt.Sprintf("Resume the review interrupted on %s with %d card(s) left?",
+		session.StartedAt.Local().Format("Jan 2 15:04"), len(remaining))
+	if len(remaining) > 0 && confirm(commandInput(cmd), cmd.OutOrStdout(), question) {
+		return session, remaining
+	}
+	if err := Store.FinishSession(session.ID); err != nil {
+		tui.PrintError("DB update error:", err)
+	}
+	return nil, nil
+}
+
+func init() {
+	ReviewCmd.Flags().String("mode", reviewModeClassic, "Review mode: classic (self-graded), mc (multiple choice) or typed (typed answers)")
+	ReviewCmd.Flags().Bool("llm-grade", false, "Have the Ollama model grade typed answers (implies --mode typed)")
+	ReviewCmd.Flags().String("order", "", "Order of the cards: "+strings.Join(queue.Orders, ", ")+" (default from config, created)")
+	ReviewCmd.Flags().Uint64("seed", 0, "Seed of the random order, to repeat a shuffle (default: a new order every time)")
+	ReviewCmd.Flags().String("report", "", "Save a markdown report of the session to this file")
+	ReviewCmd.Flags().String("new-mix", "", "Placement of new cards: new-first, reviews-first, or new:reviews such as 1:3 (default from config, kept in order)")
 }
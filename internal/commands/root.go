@@ -23,8 +23,11 @@ repetition. Simply point CATV at your folder of markdown notes, and it uses
 Ollama's local AI models to automatically generate flashcards and quiz you in 
 a colorful terminal interface.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Load configuration
-		cfg := config.LoadConfig()
+		// Load configuration, a broken config file falls back to the defaults
+		cfg, err := config.Load()
+		if err != nil {
+			tui.PrintError("Ignoring config file:", err)
+		}
 
 		// Ensure data directory exists
 		if err := cfg.EnsureDataDir(); err != nil {
@@ -33,7 +36,6 @@ a colorful terminal interface.`,
 		}
 
 		// Initialize database
-		var err error
 		Store, err = store.NewStore(cfg.DatabasePath)
 		if err != nil {
 			tui.PrintError("Database initialization failed:", err)
@@ -62,5 +64,8 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&Model, "model", "llama3.1", "Ollama model to use for flashcard generation")
 	RootCmd.AddCommand(GenerateCmd)
 	RootCmd.AddCommand(ReviewCmd)
+	RootCmd.AddCommand(CramCmd)
+	RootCmd.AddCommand(LeechesCmd)
 	RootCmd.AddCommand(AdminCmd)
+	RootCmd.AddCommand(StatsCmd)
 }
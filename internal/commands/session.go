@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"catv/internal/store"
+	"catv/internal/tui"
+
+	"github.com/spf13/cobra"
+)
+
+// sessionRecorder saves the grades of a review session as they are given.
+// Calls come one at a time from the review's saver.
+type sessionRecorder struct {
+	store     *store.Store
+	session   int
+	mode      string
+	buryUntil time.Time
+	cram      bool        // only log the reviews, schedules are left unchanged
+	logs      map[int]int // review log id of each recorded card, by position
+}
+
+func newSessionRecorder(s *store.Store, session int, mode string, buryUntil time.Time) *sessionRecorder {
+	return &sessionRecorder{store: s, session: session, mode: mode, buryUntil: buryUntil, logs: make(map[int]int)}
+}
+
+// newCramRecorder logs the reviews of a cram session, flagged as cram
+func newCramRecorder(s *store.Store, session int, mode string, buryUntil time.Time) *sessionRecorder {
+	return &sessionRecorder{store: s, session: session, mode: mode, buryUntil: buryUntil, cram: true, logs: make(map[int]int)}
+}
+
+// Record logs the review, counts it in the session's totals, saves the
+// card's new schedule, buries its siblings and marks new leeches
+func (r *sessionRecorder) Record(res tui.Result) error {
+	// Keep siblings out of today's reviews so both directions aren't shown on the same day
+	if !r.cram {
+		if err := r.store.BurySiblings(res.Flashcard, r.buryUntil); err != nil {
+			return err
+		}
+	}
+	id, err := r.store.LogReview(store.ReviewLog{
+		FlashcardID: res.Flashcard.ID,
+		Correct:     res.Correct,
+		RevisitIn:   res.RevisitIn,
+		Hints:       res.Hints,
+		Mode:        r.mode,
+		TimedOut:    res.TimedOut,
+		SessionID:   r.session,
+		WasNew:      res.Flashcard.IsNew(),
+		Cram:        r.cram,
+		Duration:    res.Duration,
+	})
+	if err != nil {
+		return err
+	}
+	r.logs[res.Index] = id
+	if r.session > 0 {
+		if err := r.store.AddSessionAnswers(r.session, 1, res.Duration); err != nil {
+			return err
+		}
+	}
+	if r.cram {
+		return nil
+	}
+
+	if err := r.store.UpdateFlashcard(res.Scheduled); err != nil {
+		return fmt.Errorf("failed to schedule flashcard %d: %w", res.Scheduled.ID, err)
+	}
+	if res.Leech {
+		return r.store.MarkLeech(res.Scheduled.ID, res.Suspend)
+	}
+	return nil
+}
+
+// Unrecord reverts Record when a grade is undone
+func (r *sessionRecorder) Unrecord(res tui.Result) error {
+	if id, ok := r.logs[res.Index]; ok {
+		if err := r.store.DeleteReviewLog(id); err != nil {
+			return err
+		}
+		delete(r.logs, res.Index)
+	}
+	if r.session > 0 {
+		if err := r.store.AddSessionAnswers(r.session, -1, -res.Duration); err != nil {
+			return err
+		}
+	}
+	if r.cram {
+		return nil
+	}
+	// The card as it was before the grade holds its previous schedule
+	if err := r.store.UpdateFlashcard(res.Flashcard); err != nil {
+		return fmt.Errorf("failed to restore flashcard %d: %w", res.Flashcard.ID, err)
+	}
+	if res.Leech {
+		if err := r.store.UnmarkLeech(res.Flashcard.ID, res.Suspend); err != nil {
+			return err
+		}
+	}
+	return r.store.UnburySiblings(res.Flashcard, r.buryUntil)
+}
+
+// Suspend takes a card out of review until it is unsuspended in admin
+func (r *sessionRecorder) Suspend(fc store.Flashcard) error {
+	return r.store.SetSuspended(fc.ID, true)
+}
+
+// Bury hides a card until the next day
+func (r *sessionRecorder) Bury(fc store.Flashcard) error {
+	return r.store.Bury(fc.ID, r.buryUntil)
+}
+
+// Flag marks a card for attention, or clears the mark
+func (r *sessionRecorder) Flag(fc store.Flashcard, flagged bool) error {
+	return r.store.SetFlagged(fc.ID, flagged)
+}
+
+// Edit saves the question and answer of a card edited during review, and the
+// text of its siblings with it. The rest of the cards is left as stored, it
+// may have changed since the review started.
+func (r *sessionRecorder) Edit(fc store.Flashcard) error {
+	current, err := r.store.GetFlashcard(fc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load flashcard %d: %w", fc.ID, err)
+	}
+	siblings, err := r.store.GetSiblings(current)
+	if err != nil {
+		return err
+	}
+	current.Question, current.Answer = fc.Question, fc.Answer
+	cards := []store.Flashcard{current}
+	for _, sibling := range siblings {
+		sibling = sibling.FollowEdit(current)
+		if sibling.Answer == "" {
+			return fmt.Errorf("the edited text has no {{c%d::...}} deletion for flashcard %d", sibling.Ordinal, sibling.ID)
+		}
+		cards = append(cards, sibling)
+	}
+	if err := r.store.UpdateTexts(cards); err != nil {
+		return fmt.Errorf("failed to save flashcard %d: %w", fc.ID, err)
+	}
+	return nil
+}
+
+// endSession finishes a review session once none of its cards is left to
+// grade. A session stopped early stays open with the cards left as its queue,
+// failed cards asked again included, so it can be resumed.
+func endSession(s *store.Store, id int, pending []store.Flashcard) error {
+	if id == 0 {
+		return nil
+	}
+	if len(pending) == 0 {
+		return s.FinishSession(id)
+	}
+	queue := make([]int, len(pending))
+	for i, fc := range pending {
+		queue[i] = fc.ID
+	}
+	return s.SetSessionQueue(id, queue)
+}
+
+// input reads the answers to questions, shared by all of them so a line read
+// ahead of one question is not lost to the next
+var (
+	input       *bufio.Reader
+	inputSource io.Reader
+)
+
+// commandInput returns the shared reader of the command's input
+func commandInput(cmd *cobra.Command) *bufio.Reader {
+	if in := cmd.InOrStdin(); input == nil || inputSource != in {
+		input, inputSource = bufio.NewReader(in), in
+	}
+	return input
+}
+
+// confirm asks a yes/no question, defaulting to yes on an empty answer
+func confirm(in *bufio.Reader, out io.Writer, question string) bool {
+	_, _ = fmt.Fprintf(out, "%s [Y/n] ", question)
+	answer, err := in.ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true
+	}
+	return false
+}
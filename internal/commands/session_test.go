@@ -0,0 +1,343 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"catv/internal/notes"
+	"catv/internal/store"
+	"catv/internal/tui"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSessionRecorder(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: "Q", Answer: "A"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := s.GetFlashcard(id)
+	reverse, err := s.CreateReverse(fc)
+	if err != nil {
+		t.Fatalf("CreateReverse() error = %v", err)
+	}
+
+	session, err := s.StartSession(reviewModeClassic, []int{fc.ID, reverse.ID})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	recorder := newSessionRecorder(s, session, reviewModeClassic, time.Now().Add(24*time.Hour))
+	scheduled := fc
+	scheduled.RevisitIn = 7
+	scheduled.DueAt = time.Now().Add(7 * 24 * time.Hour)
+	res := tui.Result{Index: 0, Flashcard: fc, Scheduled: scheduled, Correct: true, RevisitIn: 7, Duration: 3 * time.Second}
+	if err := recorder.Record(res); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if days, _ := s.GetStudyDays(time.Time{}, 0); len(days) != 1 || days[0].Answers != 1 || days[0].Time != 3*time.Second {
+		t.Errorf("Expected the answer counted in the session, got %+v", days)
+	}
+
+	got, _ := s.GetFlashcard(fc.ID)
+	logs, _ := s.GetReviewLogs(fc.ID)
+	due, _ := s.GetFlashcardsForReview()
+	if got.RevisitIn != 7 || len(logs) != 1 || logs[0].SessionID != session || len(due) != 0 {
+		t.Fatalf("Expected card scheduled, logged and its reverse buried, got revisitin %d, logs %+v, due %d", got.RevisitIn, logs, len(due))
+	}
+
+	if err := recorder.Unrecord(res); err != nil {
+		t.Fatalf("Unrecord() error = %v", err)
+	}
+	got, _ = s.GetFlashcard(fc.ID)
+	logs, _ = s.GetReviewLogs(fc.ID)
+	due, _ = s.GetFlashcardsForReview()
+	if got.RevisitIn != 0 || !got.IsNew() || len(logs) != 0 || len(due) != 2 {
+		t.Errorf("Expected the grade rolled back, got revisitin %d, logs %+v, due %d", got.RevisitIn, logs, len(due))
+	}
+	if days, _ := s.GetStudyDays(time.Time{}, 0); len(days) != 0 {
+		t.Errorf("Expected the answer taken out of the session, got %+v", days)
+	}
+}
+
+func TestCramRecorder(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: "Q", Answer: "A"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := s.GetFlashcard(id)
+	scheduled := fc
+	scheduled.RevisitIn = 1
+	scheduled.DueAt = time.Now().Add(24 * time.Hour)
+	res := tui.Result{Index: 0, Flashcard: fc, Scheduled: scheduled, RevisitIn: 1}
+
+	session, err := s.StartCramSession(reviewModeClassic)
+	if err != nil {
+		t.Fatalf("StartCramSession() error = %v", err)
+	}
+	recorder := newCramRecorder(s, session, reviewModeClassic, time.Now().Add(24*time.Hour))
+	if err := recorder.Record(res); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	got, _ := s.GetFlashcard(fc.ID)
+	logs, _ := s.GetReviewLogs(fc.ID)
+	if !got.IsNew() || got.RevisitIn != 0 || len(logs) != 1 || !logs[0].Cram {
+		t.Fatalf("Expected the review logged as cram without rescheduling, got %+v, logs %+v", got, logs)
+	}
+	if days, _ := s.GetStudyDays(time.Time{}, 0); len(days) != 1 || days[0].Answers != 1 {
+		t.Errorf("Expected the cram answer counted as study, got %+v", days)
+	}
+
+	if err := recorder.Unrecord(res); err != nil {
+		t.Fatalf("Unrecord() error = %v", err)
+	}
+	if logs, _ = s.GetReviewLogs(fc.ID); len(logs) != 0 {
+		t.Errorf("Expected the cram review removed, got %+v", logs)
+	}
+}
+
+func TestSessionRecorderMarker(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: "Q", Answer: "A"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := s.GetFlashcard(id)
+	buryUntil := time.Now().Add(24 * time.Hour)
+	recorder := newSessionRecorder(s, 0, reviewModeClassic, buryUntil)
+	for _, mark := range []func(store.Flashcard) error{recorder.Suspend, recorder.Bury, func(fc store.Flashcard) error { return recorder.Flag(fc, true) }} {
+		if err := mark(fc); err != nil {
+			t.Fatalf("Marking the card failed: %v", err)
+		}
+	}
+
+	got, _ := s.GetFlashcard(id)
+	if !got.Suspended || !got.Flagged || !got.IsBuried(time.Now()) || got.IsBuried(buryUntil) {
+		t.Errorf("Expected the card suspended, flagged and buried until the next day, got %+v", got)
+	}
+}
+
+func TestSessionRecorderLeech(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: "Q", Answer: "A"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := s.GetFlashcard(id)
+	scheduled := fc
+	scheduled.DueAt = time.Now()
+	scheduled.Lapses = 8
+	res := tui.Result{Index: 0, Flashcard: fc, Scheduled: scheduled, Lapse: true, Leech: true, Suspend: true}
+
+	recorder := newSessionRecorder(s, 0, reviewModeClassic, time.Now().Add(24*time.Hour))
+	if err := recorder.Record(res); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if leeches, _ := s.GetLeeches(); len(leeches) != 1 || !leeches[0].Suspended || leeches[0].Lapses != 8 {
+		t.Fatalf("Expected the card suspended as a leech, got %+v", leeches)
+	}
+	if err := recorder.Unrecord(res); err != nil {
+		t.Fatalf("Unrecord() error = %v", err)
+	}
+	if got, _ := s.GetFlashcard(id); got.Suspended || len(got.Tags) != 0 || got.Lapses != 0 {
+		t.Errorf("Expected the leech marking undone, got %+v", got)
+	}
+}
+
+func TestSessionRecorderEdit(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: "Q", Answer: "A", Deck: "go"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := s.GetFlashcard(id)
+	due := time.Now().Add(72 * time.Hour)
+	_ = s.UpdateFlashcard(store.Flashcard{ID: id, RevisitIn: 3, DueAt: due})
+	_ = s.MarkLeech(id, false)
+
+	// The review's copy of the card predates its schedule and tags
+	fc.Question, fc.Answer = "Fixed Q", "Fixed A"
+	recorder := newSessionRecorder(s, 0, reviewModeClassic, time.Now())
+	if err := recorder.Edit(fc); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	got, _ := s.GetFlashcard(id)
+	if got.Question != "Fixed Q" || got.Answer != "Fixed A" || got.RevisitIn != 3 || got.DueAt.Unix() != due.Unix() || len(got.Tags) != 1 {
+		t.Errorf("Expected only the text edited, got %+v", got)
+	}
+}
+
+func TestSessionRecorderEditSiblings(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	text := "{{c1::Go}} was created at {{c2::Google}}"
+	var clozes []int
+	for ordinal, answer := range map[int]string{1: "Go", 2: "Google"} {
+		id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: text, Answer: answer, Type: store.CardTypeCloze, Ordinal: ordinal})
+		if err != nil {
+			t.Fatalf("CreateFlashcard() error = %v", err)
+		}
+		clozes = append(clozes, id)
+	}
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: "bonjour", Answer: "hello"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := s.GetFlashcard(id)
+	reverse, err := s.CreateReverse(fc)
+	if err != nil {
+		t.Fatalf("CreateReverse() error = %v", err)
+	}
+	recorder := newSessionRecorder(s, 0, reviewModeClassic, time.Now())
+
+	// Every deletion of the text follows the edit with its own answer
+	cloze, _ := s.GetFlashcard(clozes[0])
+	cloze.Question, cloze.Answer = "{{c1::Go}} was designed at {{c2::Google Inc}}", "Go"
+	if err := recorder.Edit(cloze); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	for _, id := range clozes {
+		got, _ := s.GetFlashcard(id)
+		if got.Question != cloze.Question || got.Answer != notes.ClozeAnswer(cloze.Question, got.Ordinal) {
+			t.Errorf("Expected the cloze card to follow the edit, got %+v", got)
+		}
+	}
+	cloze.Question = "{{c1::Go}} was designed at Google"
+	if err := recorder.Edit(cloze); err == nil {
+		t.Error("Expected an error for a text without the sibling's deletion")
+	}
+
+	// A card and its reverse stay swapped, whichever one is edited
+	reverse.Question, reverse.Answer = "hi", "salut"
+	if err := recorder.Edit(reverse); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if got, _ := s.GetFlashcard(id); got.Question != "salut" || got.Answer != "hi" {
+		t.Errorf("Expected the original swapped from the edited reverse, got %+v", got)
+	}
+}
+
+func TestEndSession(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Question: "Q", Answer: "A"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := s.GetFlashcard(id)
+	session, err := s.StartSession(reviewModeClassic, []int{fc.ID})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+
+	// Quitting with a failed card queued again keeps it for the resumed session
+	if _, err := s.LogReview(store.ReviewLog{FlashcardID: fc.ID, SessionID: session}); err != nil {
+		t.Fatalf("LogReview() error = %v", err)
+	}
+	if err := endSession(s, session, []store.Flashcard{fc}); err != nil {
+		t.Fatalf("endSession() error = %v", err)
+	}
+	got, _ := s.GetUnfinishedSession()
+	if got == nil || got.ID != session {
+		t.Fatalf("Expected the stopped session left to resume, got %+v", got)
+	}
+	if remaining, err := s.GetSessionRemaining(got); err != nil || len(remaining) != 1 || remaining[0].ID != fc.ID {
+		t.Fatalf("Expected the requeued card left to review, got %+v (%v)", remaining, err)
+	}
+	if err := endSession(s, session, nil); err != nil {
+		t.Fatalf("endSession() error = %v", err)
+	}
+	if got, _ := s.GetUnfinishedSession(); got != nil {
+		t.Errorf("Expected the completed session finished, got %+v", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"0d", 0, true},
+		{"-3d", 0, true},
+		{"week", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSince(%q) = %v, %v, want %v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"\n", true},
+		{"y\n", true},
+		{"Yes\n", true},
+		{"n\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := confirm(bufio.NewReader(strings.NewReader(tt.input)), &out, "Resume?"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if out.String() != "Resume? [Y/n] " {
+			t.Errorf("Unexpected prompt %q", out.String())
+		}
+	}
+}
+
+func TestCommandInputShared(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("n\ny\n"))
+	var out bytes.Buffer
+	// Answers typed ahead are kept for the next question
+	if confirm(commandInput(cmd), &out, "First?") || !confirm(commandInput(cmd), &out, "Second?") {
+		t.Error("Expected each question to get its own answer from the shared input")
+	}
+}
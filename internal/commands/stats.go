@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"catv/internal/config"
+	"catv/internal/stats"
+	"catv/internal/tui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var StatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show your progress",
+	Long: `Show how your flashcards are doing: the cards in each state, retention over
+the last 7, 30 and 90 days, your reviews of the last 26 weeks as a calendar
+heatmap, the cards due in the next 30 days, a breakdown by deck and by file,
+today's progress towards the daily goal and your streak of days meeting it.
+
+Retention is the share of cards you had already learned that you knew on
+their first answer of the day; new cards and cram sessions are left out.
+
+With --json the report is printed as JSON instead, for scripts and
+dashboards.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.LoadConfig()
+		report, err := stats.Collect(Store, stats.Goal(cfg.Goal), time.Now(), cfg.Review.RolloverHour)
+		if err != nil {
+			tui.PrintError("Failed to compute stats:", err)
+			return
+		}
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				tui.PrintError("Failed to write stats:", err)
+			}
+			return
+		}
+		if _, err := tea.NewProgram(tui.NewStatsModel(report)).Run(); err != nil {
+			fmt.Println("Error running stats TUI:", err)
+		}
+	},
+}
+
+func init() {
+	StatsCmd.Flags().Bool("json", false, "Print the stats as JSON")
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"catv/internal/config"
+	"catv/internal/tui/keys"
+	"catv/internal/tui/theme"
+	"catv/internal/watch"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// watchStatusMsg updates the persistent status line of the watch UI
+type watchStatusMsg string
+
+// watchLogMsg is printed above the status line and kept in the scrollback
+type watchLogMsg struct {
+	text string
+	err  bool
+}
+
+// watchModel renders a spinner with a status line while notes are watched
+type watchModel struct {
+	spinner spinner.Model
+	root    string
+	status  string
+}
+
+func (m watchModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if keys.IsQuit(msg.String()) {
+			return m, tea.Quit
+		}
+	case watchStatusMsg:
+		m.status = string(msg)
+	case watchLogMsg:
+		if msg.err {
+			return m, tea.Println(theme.ErrorStyle.Render(msg.text))
+		}
+		return m, tea.Println(theme.SuccessStyle.Render(msg.text))
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m watchModel) View() string {
+	return fmt.Sprintf("%s Watching %s • %s\n%s\n",
+		m.spinner.View(), m.root, m.status, theme.HelpStyle.Render("q: Quit"))
+}
+
+// runWatch watches the markdown files under root and regenerates flashcards
+// for each file once its edits have settled, until the user quits
+func runWatch(cfg *config.Config, model, root string, opts discoverOptions, genOpts generateOptions, interval, debounce time.Duration) error {
+	// Set once the UI runs, the watcher only falls back once Run has started
+	var p *tea.Program
+	watchOpts := []watch.Option{watch.OnFallback(func(err error) {
+		p.Send(watchLogMsg{text: fmt.Sprintf("Filesystem notifications unavailable (%v), checking for changes every %s", err, interval), err: true})
+	})}
+	if info, err := os.Stat(root); err == nil && info.IsDir() {
+		// New folders skipped when listing notes, such as node_modules, are not watched either
+		watchOpts = append(watchOpts, watch.WithRoot(root), watch.SkipDirs(func(dir string) bool {
+			ignored, err := dirIgnored(root, dir, opts)
+			return ignored || err != nil
+		}))
+	}
+	w := watch.New(func() ([]string, error) {
+		return getMarkdownFiles(root, opts)
+	}, interval, debounce, watchOpts...)
+	if err := w.Prime(); err != nil {
+		return err
+	}
+
+	m := watchModel{
+		spinner: spinner.New(),
+		root:    root,
+		status:  fmt.Sprintf("%d file(s), waiting for changes", len(w.Files())),
+	}
+	p = tea.NewProgram(m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- w.Run(ctx, func(f string) {
+			absPath, _ := filepath.Abs(f)
+			p.Send(watchStatusMsg(fmt.Sprintf("generating %s", filepath.Base(absPath))))
+
+			genCtx, genCancel := context.WithTimeout(ctx, time.Duration(cfg.RequestTimeout)*time.Second)
+			count, err := generateForFile(genCtx, cfg, model, absPath, genOpts)
+			genCancel()
+
+			stamp := time.Now().Format("15:04:05")
+			if errors.Is(err, errSkipped) {
+				p.Send(watchStatusMsg(fmt.Sprintf("last: %s skipped at %s", filepath.Base(absPath), stamp)))
+				return
+			}
+			if err != nil {
+				p.Send(watchLogMsg{text: fmt.Sprintf("[%s] %s: %v", stamp, absPath, err), err: true})
+				p.Send(watchStatusMsg(fmt.Sprintf("last: %s failed at %s", filepath.Base(absPath), stamp)))
+				return
+			}
+			p.Send(watchLogMsg{text: fmt.Sprintf("[%s] %s (%d new flashcards)", stamp, absPath, count)})
+			p.Send(watchStatusMsg(fmt.Sprintf("last: %s (+%d) at %s", filepath.Base(absPath), count, stamp)))
+		})
+		// Stop the UI if watching fails so the error is reported to the user
+		p.Quit()
+	}()
+
+	if _, err := p.Run(); err != nil {
+		return err
+	}
+	cancel()
+	return <-errChan
+}
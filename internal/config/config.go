@@ -2,11 +2,22 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
+
+	"catv/internal/queue"
+	"catv/internal/scheduler"
 )
 
+// FileName is the optional JSON configuration file inside the data directory
+const FileName = "config.json"
+
 // Config holds all configuration for the CATV application
 type Config struct {
 	// Database settings
@@ -19,6 +30,111 @@ type Config struct {
 
 	// Application settings
 	DataDir string
+
+	// Review settings, read from the config file
+	Review ReviewConfig
+
+	// Display settings, read from the config file
+	Display DisplayConfig
+
+	// Daily goal, read from the config file
+	Goal GoalConfig
+}
+
+// GoalConfig holds the daily study goal. With both a number of cards and of
+// minutes, reaching either meets the goal. Without any, a day with a review
+// meets it.
+type GoalConfig struct {
+	// Cards is the number of answers to give each day, 0 for no goal on cards
+	Cards int `json:"cards"`
+	// Minutes is the time to study each day, 0 for no goal on time
+	Minutes int `json:"minutes"`
+	// FreezeDays is the number of days a week that can be missed without
+	// breaking the streak
+	FreezeDays int `json:"freeze_days"`
+}
+
+// DisplayConfig holds the settings of how cards are shown
+type DisplayConfig struct {
+	// PlainText shows questions and answers as written instead of rendering
+	// their markdown
+	PlainText bool `json:"plain_text"`
+}
+
+// ReviewConfig holds the settings of review sessions
+type ReviewConfig struct {
+	// TimerSeconds is the time to answer a card, 0 disables the timer
+	TimerSeconds int `json:"timer"`
+	// DeckTimers overrides TimerSeconds for cards of the given decks
+	DeckTimers map[string]int `json:"deck_timers"`
+	// LearningSteps are the delays before a failed new card is asked again
+	// in the same session, such as "1m" or "10m"
+	LearningSteps []string `json:"learning_steps"`
+	// RelearningSteps are the learning steps of a failed card that had been learned
+	RelearningSteps []string `json:"relearning_steps"`
+	// Order is the default order of review queues, see review --order
+	Order string `json:"order"`
+	// NewMix is the default placement of new cards among reviews, see review --new-mix
+	NewMix string `json:"new_mix"`
+	// NewPerDay is the number of new cards introduced each day, negative for no limit
+	NewPerDay int `json:"new_per_day"`
+	// ReviewsPerDay is the number of cards already learned reviewed each day,
+	// negative for no limit
+	ReviewsPerDay int `json:"reviews_per_day"`
+	// DeckLimits overrides the daily limits for the given decks
+	DeckLimits map[string]DeckLimits `json:"deck_limits"`
+	// RolloverHour is the hour the day starts at, so reviewing past midnight
+	// still counts for the previous day
+	RolloverHour int `json:"rollover_hour"`
+	// LeechThreshold is the number of lapses that makes a card a leech, 0 to
+	// not detect leeches
+	LeechThreshold int `json:"leech_threshold"`
+	// SuspendLeeches suspends cards when they become leeches
+	SuspendLeeches bool `json:"suspend_leeches"`
+}
+
+// DeckLimits are the daily limits of a deck, unset ones falling back to the
+// global limits
+type DeckLimits struct {
+	NewPerDay     *int `json:"new_per_day"`
+	ReviewsPerDay *int `json:"reviews_per_day"`
+}
+
+// LimitsFor returns the daily limits of a deck, negative for no limit
+func (r ReviewConfig) LimitsFor(deck string) (newCards, reviews int) {
+	newCards, reviews = r.NewPerDay, r.ReviewsPerDay
+	if limits, ok := r.DeckLimits[deck]; ok {
+		if limits.NewPerDay != nil {
+			newCards = *limits.NewPerDay
+		}
+		if limits.ReviewsPerDay != nil {
+			reviews = *limits.ReviewsPerDay
+		}
+	}
+	return newCards, reviews
+}
+
+// Steps returns the parsed learning and relearning steps
+func (r ReviewConfig) Steps() (learning, relearning scheduler.Steps, err error) {
+	if learning, err = scheduler.ParseSteps(r.LearningSteps); err != nil {
+		return nil, nil, err
+	}
+	if relearning, err = scheduler.ParseSteps(r.RelearningSteps); err != nil {
+		return nil, nil, err
+	}
+	return learning, relearning, nil
+}
+
+// TimerFor returns the answer timer of a deck, 0 when it is disabled
+func (r ReviewConfig) TimerFor(deck string) time.Duration {
+	seconds := r.TimerSeconds
+	if s, ok := r.DeckTimers[deck]; ok {
+		seconds = s
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 // DefaultConfig returns a configuration with sensible defaults
@@ -36,13 +152,41 @@ func DefaultConfig() *Config {
 		OllamaModel:    "llama3.1",
 		RequestTimeout: 300, // 5 minutes
 		DataDir:        dataDir,
+		Review: ReviewConfig{
+			TimerSeconds:    30,
+			LearningSteps:   []string{"1m", "10m"},
+			RelearningSteps: []string{"10m"},
+			Order:           queue.OrderCreated,
+			NewPerDay:       20,
+			ReviewsPerDay:   200,
+			RolloverHour:    4,
+			LeechThreshold:  8,
+		},
+		Goal: GoalConfig{FreezeDays: 1},
 	}
 }
 
-// LoadConfig loads configuration from environment variables with defaults
+// LoadConfig loads configuration from the config file and environment
+// variables with defaults. An unreadable config file is ignored, use Load to
+// report it.
 func LoadConfig() *Config {
+	cfg, _ := Load()
+	return cfg
+}
+
+// Load reads the configuration: defaults, then the config file in the data
+// directory, then environment variables. On error, such as a setting out of
+// range, the returned configuration is still usable, without the file's settings.
+func Load() (*Config, error) {
 	cfg := DefaultConfig()
 
+	if dataDir := os.Getenv("CATV_DATA_DIR"); dataDir != "" {
+		cfg.DataDir = dataDir
+		cfg.DatabasePath = filepath.Join(dataDir, "flashcards.db")
+	}
+
+	fileErr := cfg.loadFile(filepath.Join(cfg.DataDir, FileName))
+
 	// Override with environment variables if present
 	if model := os.Getenv("CATV_MODEL"); model != "" {
 		cfg.OllamaModel = model
@@ -52,12 +196,42 @@ func LoadConfig() *Config {
 		cfg.OllamaURL = url
 	}
 
-	if dataDir := os.Getenv("CATV_DATA_DIR"); dataDir != "" {
-		cfg.DataDir = dataDir
-		cfg.DatabasePath = filepath.Join(dataDir, "flashcards.db")
+	return cfg, fileErr
+}
+
+// loadFile applies the settings of a JSON config file, a missing file is not an error
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is the config file in the data directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	return cfg
+	var file struct {
+		Review  ReviewConfig  `json:"review"`
+		Display DisplayConfig `json:"display"`
+		Goal    GoalConfig    `json:"goal"`
+	}
+	file.Review, file.Display, file.Goal = c.Review, c.Display, c.Goal
+	// Decoding fills slices and maps in place, keep the current ones intact
+	// in case the file is rejected
+	file.Review.LearningSteps = slices.Clone(c.Review.LearningSteps)
+	file.Review.RelearningSteps = slices.Clone(c.Review.RelearningSteps)
+	file.Review.DeckTimers = maps.Clone(c.Review.DeckTimers)
+	file.Review.DeckLimits = maps.Clone(c.Review.DeckLimits)
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	// Settings out of range leave the whole file out, like a file that cannot be parsed
+	loaded := *c
+	loaded.Review, loaded.Display, loaded.Goal = file.Review, file.Display, file.Goal
+	if err := loaded.Validate(); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	*c = loaded
+	return nil
 }
 
 // EnsureDataDir creates the data directory if it doesn't exist
@@ -76,5 +250,26 @@ func (c *Config) Validate() error {
 	if c.RequestTimeout <= 0 {
 		return fmt.Errorf("request timeout must be positive")
 	}
+	if c.Review.TimerSeconds < 0 {
+		return fmt.Errorf("review timer cannot be negative")
+	}
+	if c.Review.LeechThreshold < 0 {
+		return fmt.Errorf("review leech threshold cannot be negative")
+	}
+	if c.Review.RolloverHour < 0 || c.Review.RolloverHour > 23 {
+		return fmt.Errorf("review rollover hour must be between 0 and 23")
+	}
+	if c.Goal.Cards < 0 || c.Goal.Minutes < 0 {
+		return fmt.Errorf("daily goal cannot be negative")
+	}
+	if c.Goal.FreezeDays < 0 || c.Goal.FreezeDays > 6 {
+		return fmt.Errorf("goal freeze days must be between 0 and 6")
+	}
+	if _, _, err := c.Review.Steps(); err != nil {
+		return err
+	}
+	if err := (queue.Options{Order: c.Review.Order, Mix: c.Review.NewMix}).Validate(); err != nil {
+		return err
+	}
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -150,3 +151,127 @@ func TestDefaultConfigErrorCase(t *testing.T) {
 		t.Error("DefaultConfig() should set DataDir even on error")
 	}
 }
+
+func TestLoadConfigFile(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("CATV_DATA_DIR", dataDir)
+
+	// Defaults without a config file
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Review.TimerFor("any") != 30*time.Second {
+		t.Errorf("Expected 30s default timer, got %v", cfg.Review.TimerFor("any"))
+	}
+
+	if learning, relearning, err := cfg.Review.Steps(); err != nil || len(learning) != 2 || len(relearning) != 1 {
+		t.Errorf("Expected default learning steps, got %v, %v (%v)", learning, relearning, err)
+	}
+
+	if cfg.Display.PlainText {
+		t.Error("Expected markdown rendering by default")
+	}
+
+	if cfg.Goal != (GoalConfig{FreezeDays: 1}) {
+		t.Errorf("Expected no daily goal and a freeze day by default, got %+v", cfg.Goal)
+	}
+
+	if newCards, reviews := cfg.Review.LimitsFor("any"); newCards != 20 || reviews != 200 {
+		t.Errorf("Expected default limits of 20 new cards and 200 reviews, got %d and %d", newCards, reviews)
+	}
+
+	content := `{"review": {"deck_timers": {"vocab": 10, "essays": 0}, "learning_steps": [],
+		"new_per_day": 5, "deck_limits": {"vocab": {"new_per_day": 0}, "exam": {"reviews_per_day": -1}}},
+		"display": {"plain_text": true}, "goal": {"minutes": 15}}`
+	if err := os.WriteFile(filepath.Join(dataDir, FileName), []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Review.TimerFor("vocab"); got != 10*time.Second {
+		t.Errorf("TimerFor(vocab) = %v, want 10s", got)
+	}
+	if got := cfg.Review.TimerFor("essays"); got != 0 {
+		t.Errorf("TimerFor(essays) = %v, want disabled", got)
+	}
+	if got := cfg.Review.TimerFor(""); got != 30*time.Second {
+		t.Errorf("Unlisted decks should keep the default timer, got %v", got)
+	}
+	if learning, relearning, _ := cfg.Review.Steps(); len(learning) != 0 || len(relearning) != 1 {
+		t.Errorf("Expected learning steps turned off and default relearning, got %v, %v", learning, relearning)
+	}
+	if !cfg.Display.PlainText {
+		t.Error("Expected plain text display from the config file")
+	}
+	if cfg.Goal != (GoalConfig{Minutes: 15, FreezeDays: 1}) {
+		t.Errorf("Expected a 15 minute goal keeping the default freeze day, got %+v", cfg.Goal)
+	}
+	for deck, want := range map[string][2]int{"any": {5, 200}, "vocab": {0, 200}, "exam": {5, -1}} {
+		if newCards, reviews := cfg.Review.LimitsFor(deck); newCards != want[0] || reviews != want[1] {
+			t.Errorf("LimitsFor(%s) = %d, %d, want %v", deck, newCards, reviews, want)
+		}
+	}
+	cfg.Goal.FreezeDays = 7
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a week of freeze days to fail validation")
+	}
+	cfg.Goal.FreezeDays = 1
+	cfg.Review.LeechThreshold = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a negative leech threshold to fail validation")
+	}
+	cfg.Review.LeechThreshold = 8
+	cfg.Review.RolloverHour = 24
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an invalid rollover hour to fail validation")
+	}
+	cfg.Review.RolloverHour = 4
+	cfg.Review.Order = "alphabetical"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an unknown review order to fail validation")
+	}
+	cfg.Review.Order = ""
+	cfg.Review.RelearningSteps = []string{"later"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected invalid relearning steps to fail validation")
+	}
+
+	if err := os.WriteFile(filepath.Join(dataDir, FileName), []byte(`{"review": `), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err = Load()
+	if err == nil {
+		t.Error("Expected an error for an invalid config file")
+	}
+	if cfg == nil || cfg.Review.TimerSeconds != 30 {
+		t.Error("Expected usable defaults alongside the error")
+	}
+}
+
+func TestLoadConfigFileOutOfRange(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("CATV_DATA_DIR", dataDir)
+
+	for _, content := range []string{
+		`{"review": {"rollover_hour": 30, "timer": 10}}`,
+		`{"goal": {"freeze_days": -1}, "review": {"timer": 10}}`,
+		`{"review": {"learning_steps": ["soon"], "timer": 10}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dataDir, FileName), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		cfg, err := Load()
+		if err == nil {
+			t.Errorf("Load() of %s should report the setting out of range", content)
+		}
+		if cfg.Review.RolloverHour != 4 || cfg.Goal.FreezeDays != 1 || cfg.Review.TimerSeconds != 30 {
+			t.Errorf("Load() of %s should fall back to the defaults, got %+v %+v", content, cfg.Review, cfg.Goal)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("The defaults used instead should be valid, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package grading compares a typed answer with the stored answer of a
+// flashcard and suggests a grade
+package grading
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Threshold is the similarity from which a typed answer is suggested as correct
+const Threshold = 0.8
+
+// maxDiffRunes bounds the size of the character diff, longer answers are shown
+// as a whole replacement
+const maxDiffRunes = 2000
+
+// Op is the kind of a diff segment
+type Op int
+
+const (
+	Equal   Op = iota // text present in both answers
+	Missing           // expected text the user did not type
+	Extra             // typed text that is not in the expected answer
+)
+
+// Segment is a run of characters sharing the same diff operation
+type Segment struct {
+	Op   Op
+	Text string
+}
+
+// Result is the outcome of comparing a typed answer with the expected one
+type Result struct {
+	Score   float64 // similarity between 0 and 1
+	Correct bool    // suggested grade
+	Diff    []Segment
+}
+
+// Grade compares the typed answer with the expected one
+func Grade(expected, given string) Result {
+	score := Similarity(expected, given)
+	return Result{
+		Score:   score,
+		Correct: score >= Threshold,
+		Diff:    Diff(strings.TrimSpace(expected), strings.TrimSpace(given)),
+	}
+}
+
+// Similarity scores two answers between 0 and 1 after normalization, as the
+// better of the normalized edit distance and the word overlap. The former
+// tolerates typos, the latter word order and small additions.
+func Similarity(expected, given string) float64 {
+	a, b := Normalize(expected), Normalize(given)
+	if a == b {
+		return 1
+	}
+	if a == "" || b == "" {
+		return 0
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	edit := 1 - float64(levenshtein(ra, rb))/float64(longest)
+	return max(edit, tokenOverlap(strings.Fields(a), strings.Fields(b)))
+}
+
+// Normalize lowercases an answer, drops punctuation and collapses whitespace
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// tokenOverlap is the F1 score of the words shared by both answers
+func tokenOverlap(a, b []string) float64 {
+	counts := make(map[string]int, len(a))
+	for _, w := range a {
+		counts[w]++
+	}
+	common := 0
+	for _, w := range b {
+		if counts[w] > 0 {
+			counts[w]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// Diff returns a case-insensitive character diff turning expected into given
+func Diff(expected, given string) []Segment {
+	a, b := []rune(expected), []rune(given)
+	if len(a) > maxDiffRunes || len(b) > maxDiffRunes {
+		return merge([]Segment{{Missing, expected}, {Extra, given}})
+	}
+
+	// Longest common subsequence table, lcs[i][j] covers a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if sameRune(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var segments []Segment
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case sameRune(a[i], b[j]):
+			segments = append(segments, Segment{Equal, string(b[j])})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			segments = append(segments, Segment{Missing, string(a[i])})
+			i++
+		default:
+			segments = append(segments, Segment{Extra, string(b[j])})
+			j++
+		}
+	}
+	segments = append(segments, Segment{Missing, string(a[i:])}, Segment{Extra, string(b[j:])})
+	return merge(segments)
+}
+
+func sameRune(a, b rune) bool {
+	return unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// merge joins adjacent segments with the same operation and drops empty ones
+func merge(segments []Segment) []Segment {
+	var result []Segment
+	for _, s := range segments {
+		if s.Text == "" {
+			continue
+		}
+		if n := len(result); n > 0 && result[n-1].Op == s.Op {
+			result[n-1].Text += s.Text
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package grading
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		given    string
+		correct  bool
+	}{
+		{"exact", "Paris", "Paris", true},
+		{"case and punctuation", "Transmission Control Protocol.", "transmission control protocol", true},
+		{"typo", "Mitochondria", "Mitochondira", true},
+		{"word order", "red green blue", "blue green red", true},
+		{"missing word", "the powerhouse of the cell", "powerhouse of the cell", true},
+		{"wrong", "Paris", "Lyon", false},
+		{"empty", "Paris", "", false},
+		{"partial", "Transmission Control Protocol", "Transmission", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Similarity(tt.expected, tt.given)
+			if score < 0 || score > 1 {
+				t.Fatalf("Similarity() = %f, out of range", score)
+			}
+			if got := Grade(tt.expected, tt.given).Correct; got != tt.correct {
+				t.Errorf("Grade(%q, %q).Correct = %v (score %.2f), want %v", tt.expected, tt.given, got, score, tt.correct)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if got := Normalize("  Hello,   World!\tÉté "); got != "hello world été" {
+		t.Errorf("Normalize() = %q", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		given    string
+		want     []Segment
+	}{
+		{"equal ignoring case", "Paris", "paris", []Segment{{Equal, "paris"}}},
+		{"missing", "colour", "color", []Segment{{Equal, "colo"}, {Missing, "u"}, {Equal, "r"}}},
+		{"extra", "color", "colour", []Segment{{Equal, "colo"}, {Extra, "u"}, {Equal, "r"}}},
+		{"replaced", "cat", "cut", []Segment{{Equal, "c"}, {Missing, "a"}, {Extra, "u"}, {Equal, "t"}}},
+		{"empty given", "cat", "", []Segment{{Missing, "cat"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.expected, tt.given); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
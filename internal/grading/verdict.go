@@ -0,0 +1,65 @@
+package grading
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Grades a model can give to a typed answer
+const (
+	GradeCorrect   = "correct"
+	GradePartial   = "partial"
+	GradeIncorrect = "incorrect"
+)
+
+// Verdict is the model's assessment of a typed answer
+type Verdict struct {
+	Grade    string `json:"verdict"`
+	Feedback string `json:"feedback"`
+}
+
+// Correct reports whether the answer should be graded correct. Partially
+// correct answers are not, so the card comes back soon.
+func (v Verdict) Correct() bool {
+	return v.Grade == GradeCorrect
+}
+
+// VerdictPrompt asks the model to grade a typed answer against the stored one
+func VerdictPrompt(question, expected, given string) string {
+	return fmt.Sprintf(`You are grading a flashcard answer typed by a student.
+Compare the student's answer with the reference answer, judging meaning rather than wording:
+- "correct": the answer contains the key idea of the reference answer, even if phrased differently
+- "partial": the answer is on the right track but misses or confuses an important part
+- "incorrect": the answer is wrong, unrelated or empty
+
+Reply with a JSON object only, in this exact shape:
+{"verdict": "correct" | "partial" | "incorrect", "feedback": "<one short sentence for the student>"}
+
+Question: %s
+Reference answer: %s
+Student answer: %s
+`, question, expected, given)
+}
+
+// ParseVerdict reads the model's JSON reply, tolerating text around the object
+func ParseVerdict(response string) (Verdict, error) {
+	var v Verdict
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return v, errors.New("no verdict in model response")
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &v); err != nil {
+		return v, fmt.Errorf("failed to parse verdict: %w", err)
+	}
+
+	v.Grade = strings.ToLower(strings.TrimSpace(v.Grade))
+	v.Feedback = strings.TrimSpace(v.Feedback)
+	switch v.Grade {
+	case GradeCorrect, GradePartial, GradeIncorrect:
+		return v, nil
+	default:
+		return v, fmt.Errorf("unknown verdict %q", v.Grade)
+	}
+}
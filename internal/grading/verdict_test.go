@@ -0,0 +1,65 @@
+package grading
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     Verdict
+		wantErr  bool
+	}{
+		{
+			name:     "plain json",
+			response: `{"verdict": "correct", "feedback": "Spot on."}`,
+			want:     Verdict{Grade: GradeCorrect, Feedback: "Spot on."},
+		},
+		{
+			name:     "surrounding text and casing",
+			response: "Sure! {\"verdict\": \" Partial \", \"feedback\": \" Missing the port. \"} Hope it helps",
+			want:     Verdict{Grade: GradePartial, Feedback: "Missing the port."},
+		},
+		{
+			name:     "no object",
+			response: "The answer is correct",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown verdict",
+			response: `{"verdict": "maybe"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid json",
+			response: `{"verdict": correct}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVerdict(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVerdict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseVerdict() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerdictPrompt(t *testing.T) {
+	prompt := VerdictPrompt("What does TCP stand for?", "Transmission Control Protocol", "transport control protocol")
+	for _, want := range []string{"Question: What does TCP stand for?", "Reference answer: Transmission Control Protocol", "Student answer: transport control protocol", `"verdict"`} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Prompt missing %q", want)
+		}
+	}
+	if (Verdict{Grade: GradePartial}).Correct() || !(Verdict{Grade: GradeCorrect}).Correct() {
+		t.Error("Only correct verdicts should grade the card correct")
+	}
+}
@@ -0,0 +1,90 @@
+// Package hint builds progressively stronger hints for a flashcard answer
+package hint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Hint levels, each one giving away more of the answer
+const (
+	LevelLetter = iota + 1 // first letter of the answer
+	LevelShape             // word count and word lengths
+	LevelNudge             // model-written nudge towards the answer
+)
+
+// MaxRevisitIn caps the days until the next review once hints were used, so
+// scheduling reflects that the card was not fully recalled. 0 means no cap.
+func MaxRevisitIn(hints int) int {
+	switch {
+	case hints <= 0:
+		return 0
+	case hints == 1:
+		return 7
+	case hints == 2:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// Letter reveals the first letter of the answer
+func Letter(answer string) string {
+	for _, r := range strings.TrimSpace(answer) {
+		return fmt.Sprintf("Starts with %q", string(r))
+	}
+	return "No letters to reveal"
+}
+
+// Shape reveals the number of words and their length, keeping first letters,
+// digits and punctuation: "Transmission Control" becomes "T___________ C______"
+func Shape(answer string) string {
+	words := strings.Fields(answer)
+	masked := make([]string, len(words))
+	for i, w := range words {
+		var b strings.Builder
+		for j, r := range []rune(w) {
+			switch {
+			case j == 0, !unicode.IsLetter(r):
+				b.WriteRune(r)
+			default:
+				b.WriteRune('_')
+			}
+		}
+		masked[i] = b.String()
+	}
+	noun := "words"
+	if len(words) == 1 {
+		noun = "word"
+	}
+	return fmt.Sprintf("%d %s: %s", len(words), noun, strings.Join(masked, " "))
+}
+
+// Prompt asks the model for a nudge that does not give the answer away
+func Prompt(question, answer string) string {
+	return fmt.Sprintf(`A student is trying to recall the answer to a flashcard and asked for a hint.
+Write a single short sentence that nudges them towards the answer, for example by pointing
+to a related concept, a mnemonic or the context it comes from. Never include the answer
+itself or any word of it. Output only the hint.
+
+Question: %s
+Answer (do not reveal): %s
+`, question, answer)
+}
+
+// Clean tidies the model's nudge and masks any word of the answer it leaked
+func Clean(response, answer string) string {
+	nudge, _, _ := strings.Cut(strings.TrimSpace(response), "\n")
+	nudge = strings.Trim(strings.TrimSpace(nudge), `"`)
+	for _, w := range strings.Fields(answer) {
+		w = strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if len([]rune(w)) < 3 {
+			continue
+		}
+		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(w) + `\w*`)
+		nudge = re.ReplaceAllString(nudge, "___")
+	}
+	return nudge
+}
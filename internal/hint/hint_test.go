@@ -0,0 +1,41 @@
+package hint
+
+import "testing"
+
+func TestLetterAndShape(t *testing.T) {
+	if got := Letter("  Transmission Control Protocol"); got != `Starts with "T"` {
+		t.Errorf("Letter() = %q", got)
+	}
+	if got := Letter(""); got != "No letters to reveal" {
+		t.Errorf("Letter() on empty answer = %q", got)
+	}
+
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"Transmission Control", "2 words: T___________ C______"},
+		{"HTTP/2", "1 word: H___/2"},
+		{"443", "1 word: 443"},
+	}
+	for _, tt := range tests {
+		if got := Shape(tt.answer); got != tt.want {
+			t.Errorf("Shape(%q) = %q, want %q", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestMaxRevisitIn(t *testing.T) {
+	for hints, want := range []int{0, 7, 3, 1, 1} {
+		if got := MaxRevisitIn(hints); got != want {
+			t.Errorf("MaxRevisitIn(%d) = %d, want %d", hints, got, want)
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	got := Clean("\"Think of how Transmission works, like TCP's cousin.\"\nExtra line", "Transmission Control Protocol")
+	if got != "Think of how ___ works, like TCP's cousin." {
+		t.Errorf("Clean() = %q", got)
+	}
+}
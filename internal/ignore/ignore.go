@@ -0,0 +1,192 @@
+// Package ignore implements gitignore-style pattern matching used to decide
+// which notes are picked up during flashcard generation
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a single compiled gitignore-style pattern
+type Pattern struct {
+	re      *regexp.Regexp
+	base    string // directory the pattern is relative to
+	negate  bool   // pattern started with "!" and re-includes matches
+	dirOnly bool   // pattern ended with "/" and only matches directories
+}
+
+// Compile parses a single gitignore-style pattern relative to base.
+// Blank lines and comments yield a nil pattern.
+func Compile(line, base string) (*Pattern, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	p := &Pattern{base: filepath.Clean(base)}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, nil
+	}
+
+	// Patterns containing a slash are anchored to base, others match at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	b.WriteString(globToRegexp(line))
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", line, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// globToRegexp converts the glob syntax used by gitignore into a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				// "**/" matches zero or more directories, a trailing "**" matches everything
+				if i+2 < len(glob) && glob[i+2] == '/' {
+					b.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					b.WriteString(".*")
+					i++
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Match reports whether the pattern matches path. The second return value is
+// false when the pattern does not apply to path at all.
+func (p *Pattern) Match(path string, isDir bool) (ignored, matched bool) {
+	if p.dirOnly && !isDir {
+		return false, false
+	}
+	rel, err := filepath.Rel(p.base, filepath.Clean(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	if !p.re.MatchString(filepath.ToSlash(rel)) {
+		return false, false
+	}
+	return !p.negate, true
+}
+
+// Matcher holds an ordered list of patterns where the last matching pattern wins
+type Matcher struct {
+	patterns []*Pattern
+}
+
+// NewMatcher creates an empty Matcher
+func NewMatcher() *Matcher {
+	return &Matcher{}
+}
+
+// Add compiles and appends patterns relative to base
+func (m *Matcher) Add(base string, lines ...string) error {
+	for _, line := range lines {
+		p, err := Compile(line, base)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return nil
+}
+
+// AddFile reads an ignore file and appends its patterns relative to the file's
+// directory. A missing file is not an error.
+func (m *Matcher) AddFile(path string) error {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return m.Add(filepath.Dir(path), lines...)
+}
+
+// Match reports whether path is ignored by the patterns added so far
+func (m *Matcher) Match(path string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if result, ok := p.Match(path, isDir); ok {
+			ignored = result
+		}
+	}
+	return ignored
+}
+
+// Len returns the number of patterns in the matcher
+func (m *Matcher) Len() int {
+	return len(m.patterns)
+}
@@ -0,0 +1,121 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	base := "/notes"
+	m := NewMatcher()
+	err := m.Add(base,
+		"# comment",
+		"",
+		"node_modules/",
+		"*.draft.md",
+		"/private",
+		"docs/**/vendor/*.md",
+		"drafts/",
+		"!drafts/keep.md",
+		"!important.draft.md",
+	)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"directory at any depth", "/notes/a/node_modules", true, true},
+		{"dir-only pattern ignores files", "/notes/node_modules", false, false},
+		{"basename glob", "/notes/sub/idea.draft.md", false, true},
+		{"negated basename", "/notes/sub/important.draft.md", false, false},
+		{"anchored pattern", "/notes/private", true, true},
+		{"anchored pattern does not match nested", "/notes/sub/private", true, false},
+		{"double star", "/notes/docs/a/b/vendor/x.md", false, true},
+		{"double star zero dirs", "/notes/docs/vendor/x.md", false, true},
+		{"plain note", "/notes/go.md", false, false},
+		{"outside base", "/other/idea.draft.md", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantNil bool
+	}{
+		{"blank", "   ", true},
+		{"comment", "# note", true},
+		{"escaped hash", `\#file.md`, false},
+		{"pattern", "*.md", false},
+		{"only slash", "/", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.line, "/")
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if (p == nil) != tt.wantNil {
+				t.Errorf("Compile(%q) nil = %v, want %v", tt.line, p == nil, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestMatcherCharacterClass(t *testing.T) {
+	m := NewMatcher()
+	if err := m.Add("/n", "note[0-9].md", "tmp[!a].md"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if !m.Match("/n/note1.md", false) {
+		t.Error("Expected note1.md to match character class")
+	}
+	if m.Match("/n/notex.md", false) {
+		t.Error("Expected notex.md not to match character class")
+	}
+	if !m.Match("/n/tmpb.md", false) || m.Match("/n/tmpa.md", false) {
+		t.Error("Expected negated character class to exclude 'a'")
+	}
+}
+
+func TestMatcherAddFile(t *testing.T) {
+	dir := t.TempDir()
+	ignoreFile := filepath.Join(dir, ".catvignore")
+	if err := os.WriteFile(ignoreFile, []byte("drafts/\n*.tmp.md\n"), 0600); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	m := NewMatcher()
+	if err := m.AddFile(ignoreFile); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", m.Len())
+	}
+	if !m.Match(filepath.Join(dir, "drafts"), true) {
+		t.Error("Expected drafts directory to be ignored")
+	}
+	if !m.Match(filepath.Join(dir, "a", "b.tmp.md"), false) {
+		t.Error("Expected tmp note to be ignored")
+	}
+
+	// Missing files are silently skipped
+	if err := m.AddFile(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("AddFile() on missing file error = %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package notes
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// clozePattern matches {{c1::text}} and {{c1::text::hint}} deletions
+var clozePattern = regexp.MustCompile(`\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+// IsCloze reports whether text contains at least one cloze deletion
+func IsCloze(text string) bool {
+	return clozePattern.MatchString(text)
+}
+
+// ClozeOrdinals returns the distinct deletion numbers in text in ascending order
+func ClozeOrdinals(text string) []int {
+	seen := make(map[int]bool)
+	var ordinals []int
+	for _, m := range clozePattern.FindAllStringSubmatch(text, -1) {
+		n := clozeOrdinal(m[1])
+		if n < 0 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		ordinals = append(ordinals, n)
+	}
+	sort.Ints(ordinals)
+	return ordinals
+}
+
+// clozeOrdinal parses the number of a deletion, so c01 and c1 are the same one
+func clozeOrdinal(digits string) int {
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// ClozeAnswer returns the hidden text of the deletions with the given number
+func ClozeAnswer(text string, ordinal int) string {
+	var answers []string
+	for _, m := range clozePattern.FindAllStringSubmatch(text, -1) {
+		if clozeOrdinal(m[1]) == ordinal {
+			answers = append(answers, m[2])
+		}
+	}
+	return strings.Join(answers, ", ")
+}
+
+// RenderCloze renders cloze text for the card with the given deletion number.
+// Deletions with that number are shown as a blank (or their hint), or as the
+// hidden text when reveal is set, and passed through highlight. Other
+// deletions are shown as plain text.
+func RenderCloze(text string, ordinal int, reveal bool, highlight func(string) string) string {
+	if highlight == nil {
+		highlight = func(s string) string { return s }
+	}
+	return clozePattern.ReplaceAllStringFunc(text, func(m string) string {
+		parts := clozePattern.FindStringSubmatch(m)
+		if clozeOrdinal(parts[1]) != ordinal {
+			return parts[2]
+		}
+		switch {
+		case reveal:
+			return highlight(parts[2])
+		case parts[3] != "":
+			return highlight("[" + parts[3] + "]")
+		default:
+			return highlight("[...]")
+		}
+	})
+}
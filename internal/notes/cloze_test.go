@@ -0,0 +1,58 @@
+package notes
+
+import "testing"
+
+func TestClozeOrdinalsAndAnswer(t *testing.T) {
+	text := "{{c2::Go}} was created at {{c1::Google}} in {{c2::2009::year}}."
+
+	ordinals := ClozeOrdinals(text)
+	if len(ordinals) != 2 || ordinals[0] != 1 || ordinals[1] != 2 {
+		t.Errorf("ClozeOrdinals() = %v, want [1 2]", ordinals)
+	}
+	if got := ClozeAnswer(text, 2); got != "Go, 2009" {
+		t.Errorf("ClozeAnswer(2) = %q, want %q", got, "Go, 2009")
+	}
+	// Zero-padded numbers are the same deletion
+	padded := "{{c01::Go}} was created at {{c1::Google}}"
+	if got := ClozeOrdinals(padded); len(got) != 1 || got[0] != 1 {
+		t.Errorf("ClozeOrdinals() = %v, want [1]", got)
+	}
+	if got := ClozeAnswer(padded, 1); got != "Go, Google" {
+		t.Errorf("ClozeAnswer(1) = %q, want %q", got, "Go, Google")
+	}
+	if got := RenderCloze(padded, 1, false, nil); got != "[...] was created at [...]" {
+		t.Errorf("RenderCloze() = %q, want both deletions hidden", got)
+	}
+	if !IsCloze(text) || IsCloze("plain text") {
+		t.Error("IsCloze() misdetected cloze text")
+	}
+}
+
+func TestRenderCloze(t *testing.T) {
+	text := "{{c2::Go}} was created at {{c1::Google}} in {{c2::2009::year}}."
+	mark := func(s string) string { return "<" + s + ">" }
+
+	tests := []struct {
+		name    string
+		ordinal int
+		reveal  bool
+		want    string
+	}{
+		{"hidden c1", 1, false, "Go was created at <[...]> in 2009."},
+		{"revealed c1", 1, true, "Go was created at <Google> in 2009."},
+		{"hidden c2 with hint", 2, false, "<[...]> was created at Google in <[year]>."},
+		{"revealed c2", 2, true, "<Go> was created at Google in <2009>."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderCloze(text, tt.ordinal, tt.reveal, mark); got != tt.want {
+				t.Errorf("RenderCloze() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := RenderCloze("a {{c1::b}}", 1, true, nil); got != "a b" {
+		t.Errorf("RenderCloze() with nil highlight = %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package notes
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Excerpt returns the part of a note that best matches query, used to give
+// the model context about a card. The body is split into blank-line separated
+// blocks and the block sharing the most words with query is returned, prefixed
+// with the heading it belongs to and cut to maxLen runes. It returns "" when
+// nothing matches.
+func Excerpt(content, query string, maxLen int) string {
+	_, body, err := ParseFrontMatter(content)
+	if err != nil {
+		body = content
+	}
+
+	words := make(map[string]bool)
+	for _, w := range excerptWords(query) {
+		words[w] = true
+	}
+
+	best, bestHeading, bestScore := "", "", 0
+	heading := ""
+	for _, block := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		if strings.HasPrefix(block, "#") && !strings.Contains(block, "\n") {
+			heading = block
+			continue
+		}
+
+		score := 0
+		seen := make(map[string]bool)
+		for _, w := range excerptWords(block) {
+			if words[w] && !seen[w] {
+				seen[w] = true
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestHeading, bestScore = block, heading, score
+		}
+	}
+	if bestScore == 0 {
+		return ""
+	}
+
+	if bestHeading != "" {
+		best = bestHeading + "\n\n" + best
+	}
+	if runes := []rune(best); maxLen > 0 && len(runes) > maxLen {
+		best = string(runes[:maxLen]) + "…"
+	}
+	return best
+}
+
+// excerptWords splits text into lowercase words, ignoring short ones that
+// carry little meaning
+func excerptWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) > 3 {
+			words = append(words, f)
+		}
+	}
+	return words
+}
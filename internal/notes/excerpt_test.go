@@ -0,0 +1,27 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	note := "---\ncatv.deck: net\n---\n# Networking\n\nIntro paragraph about networks.\n\n" +
+		"## Transport\n\nTCP provides reliable, ordered delivery of a stream of bytes.\nIt uses a three-way handshake.\n\n" +
+		"UDP is connectionless and has lower latency.\n"
+
+	got := Excerpt(note, "How does TCP establish a connection? Three-way handshake", 0)
+	want := "## Transport\n\nTCP provides reliable, ordered delivery of a stream of bytes.\nIt uses a three-way handshake."
+	if got != want {
+		t.Errorf("Excerpt() = %q, want %q", got, want)
+	}
+
+	if got := Excerpt(note, "unrelated quantum chromodynamics", 0); got != "" {
+		t.Errorf("Expected no excerpt for an unrelated query, got %q", got)
+	}
+
+	short := Excerpt(note, "handshake", 20)
+	if !strings.HasSuffix(short, "…") || len([]rune(short)) != 21 {
+		t.Errorf("Expected excerpt cut to 20 runes, got %q", short)
+	}
+}
@@ -0,0 +1,170 @@
+// Package notes parses markdown notes: front matter directives that control
+// flashcard generation and the note body that is sent to the model
+package notes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Directives are the per-note generation settings read from front matter
+type Directives struct {
+	Skip     bool     // catv: skip, the note is never turned into flashcards
+	Deck     string   // catv.deck, deck assigned to generated cards
+	Tags     []string // catv.tags, tags assigned to generated cards
+	MaxCards int      // catv.max_cards, upper bound of generated cards (0 = no limit)
+	Prompt   string   // catv.prompt, extra instructions appended to the prompt
+	Language string   // catv.language, language the cards should be written in
+	Reverse  bool     // catv.reverse, also create reversed cards (answer → question)
+}
+
+// ParseFrontMatter splits a YAML front matter block from the note body and
+// returns the catv directives found in it. Notes without front matter are
+// returned unchanged. Only the subset of YAML used by directives is supported:
+// scalars, flow lists ([a, b]) and block lists ("- a"), either as dotted keys
+// (catv.deck: x) or nested under a catv: mapping.
+func ParseFrontMatter(content string) (Directives, string, error) {
+	var d Directives
+
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return d, content, nil
+	}
+	rest := normalized[len("---\n"):]
+
+	var header []string
+	body := ""
+	closed := false
+	lines := strings.SplitAfter(rest, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\n")
+		if trimmed == "---" || trimmed == "..." {
+			body = strings.Join(lines[i+1:], "")
+			closed = true
+			break
+		}
+		header = append(header, trimmed)
+	}
+	if !closed {
+		// An unterminated block is not front matter, treat it as regular content
+		return d, content, nil
+	}
+
+	values := parseYAMLSubset(header)
+	if err := d.apply(values); err != nil {
+		return d, body, err
+	}
+	return d, body, nil
+}
+
+// parseYAMLSubset flattens the supported YAML subset into dotted keys
+func parseYAMLSubset(lines []string) map[string][]string {
+	values := make(map[string][]string)
+	parent := ""  // top-level key whose nested mapping is being read
+	lastKey := "" // key that block list items are appended to
+
+	for _, raw := range lines {
+		if strings.TrimSpace(raw) == "" || strings.HasPrefix(strings.TrimSpace(raw), "#") {
+			continue
+		}
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		line := strings.TrimSpace(raw)
+
+		if strings.HasPrefix(line, "- ") || line == "-" {
+			if lastKey != "" {
+				values[lastKey] = append(values[lastKey], unquote(strings.TrimSpace(strings.TrimPrefix(line, "-"))))
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if indented && parent != "" {
+			key = parent + "." + key
+		} else {
+			parent = ""
+			if value == "" {
+				parent = key
+			}
+		}
+		lastKey = key
+
+		if value == "" {
+			continue
+		}
+		values[key] = parseValue(value)
+	}
+	return values
+}
+
+// parseValue turns a scalar or flow list into its items
+func parseValue(value string) []string {
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		var items []string
+		for _, item := range strings.Split(value[1:len(value)-1], ",") {
+			if item = unquote(strings.TrimSpace(item)); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return []string{unquote(value)}
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// apply copies the recognised keys into the directives
+func (d *Directives) apply(values map[string][]string) error {
+	if v, ok := values["catv"]; ok && len(v) == 1 && strings.EqualFold(v[0], "skip") {
+		d.Skip = true
+	}
+	if v, ok := values["catv.skip"]; ok && len(v) == 1 {
+		skip, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return fmt.Errorf("invalid catv.skip value %q", v[0])
+		}
+		d.Skip = skip
+	}
+	if v, ok := values["catv.deck"]; ok && len(v) > 0 {
+		d.Deck = v[0]
+	}
+	if v, ok := values["catv.tags"]; ok {
+		// A single scalar may hold a comma separated list
+		if len(v) == 1 {
+			v = parseValue("[" + v[0] + "]")
+		}
+		d.Tags = v
+	}
+	if v, ok := values["catv.max_cards"]; ok && len(v) > 0 {
+		n, err := strconv.Atoi(v[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid catv.max_cards value %q", v[0])
+		}
+		d.MaxCards = n
+	}
+	if v, ok := values["catv.prompt"]; ok && len(v) > 0 {
+		d.Prompt = strings.Join(v, "\n")
+	}
+	if v, ok := values["catv.language"]; ok && len(v) > 0 {
+		d.Language = v[0]
+	}
+	if v, ok := values["catv.reverse"]; ok && len(v) == 1 {
+		reverse, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return fmt.Errorf("invalid catv.reverse value %q", v[0])
+		}
+		d.Reverse = reverse
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		want     Directives
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name:     "no front matter",
+			content:  "# Title\nBody",
+			wantBody: "# Title\nBody",
+		},
+		{
+			name:     "skip",
+			content:  "---\ncatv: skip\n---\n# Title\n",
+			want:     Directives{Skip: true},
+			wantBody: "# Title\n",
+		},
+		{
+			name: "dotted keys",
+			content: "---\ntitle: Go\ncatv.deck: golang\ncatv.tags: [lang, \"backend\"]\n" +
+				"catv.max_cards: 5\ncatv.prompt: Focus on syntax\ncatv.language: Portuguese\n---\nBody",
+			want: Directives{
+				Deck:     "golang",
+				Tags:     []string{"lang", "backend"},
+				MaxCards: 5,
+				Prompt:   "Focus on syntax",
+				Language: "Portuguese",
+			},
+			wantBody: "Body",
+		},
+		{
+			name:     "nested mapping with block list",
+			content:  "---\ncatv:\n  deck: 'k8s'\n  tags:\n    - ops\n    - cloud\n  max_cards: 3\nauthor: me\n---\nBody",
+			want:     Directives{Deck: "k8s", Tags: []string{"ops", "cloud"}, MaxCards: 3},
+			wantBody: "Body",
+		},
+		{
+			name:     "comma separated tags",
+			content:  "---\ncatv.tags: a, b\n---\n",
+			want:     Directives{Tags: []string{"a", "b"}},
+			wantBody: "",
+		},
+		{
+			name:     "unterminated block is content",
+			content:  "---\ncatv: skip\n# Title",
+			wantBody: "---\ncatv: skip\n# Title",
+		},
+		{
+			name:     "reverse",
+			content:  "---\ncatv.reverse: true\n---\nBody",
+			want:     Directives{Reverse: true},
+			wantBody: "Body",
+		},
+		{
+			name:    "invalid reverse",
+			content: "---\ncatv.reverse: sometimes\n---\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid max cards",
+			content: "---\ncatv.max_cards: many\n---\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, body, err := ParseFrontMatter(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFrontMatter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if d.Skip != tt.want.Skip || d.Deck != tt.want.Deck || d.MaxCards != tt.want.MaxCards ||
+				d.Prompt != tt.want.Prompt || d.Language != tt.want.Language || d.Reverse != tt.want.Reverse {
+				t.Errorf("directives = %+v, want %+v", d, tt.want)
+			}
+			if strings.Join(d.Tags, ",") != strings.Join(tt.want.Tags, ",") {
+				t.Errorf("tags = %v, want %v", d.Tags, tt.want.Tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package notes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// InlineCard is a flashcard written by the author directly in a note
+type InlineCard struct {
+	Question string
+	Answer   string
+	Hash     string // Stable identity derived from the card text
+	Ordinal  int    // Cloze deletion number, 0 for question/answer cards
+}
+
+// listMarker matches leading list bullets so "- Term :: Definition" works in lists
+var listMarker = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+
+// ExtractInlineCards finds hand-written cards in a note body. Supported syntaxes:
+//
+//	Question :: Answer        (single line)
+//	Q: question / A: answer   (blocks, answers run until a blank line)
+//	text with {{c1::cloze}}   (one cloze card per deletion number)
+//
+// Content inside fenced code blocks is ignored.
+func ExtractInlineCards(body string) []InlineCard {
+	var cards []InlineCard
+	var q, a []string
+	inAnswer := false
+	inFence := false
+
+	flush := func() {
+		if len(q) > 0 && len(a) > 0 {
+			cards = append(cards, newInlineCard(strings.Join(q, "\n"), strings.Join(a, "\n")))
+		}
+		q, a = nil, nil
+		inAnswer = false
+	}
+
+	for _, raw := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			flush()
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "Q:"):
+			flush()
+			q = []string{strings.TrimSpace(line[2:])}
+		case strings.HasPrefix(line, "A:") && len(q) > 0:
+			inAnswer = true
+			a = []string{strings.TrimSpace(line[2:])}
+		case len(q) > 0:
+			// Continuation lines belong to whichever side of the block is open
+			if inAnswer {
+				a = append(a, line)
+			} else {
+				q = append(q, line)
+			}
+		case IsCloze(line):
+			cards = append(cards, newClozeCards(listMarker.ReplaceAllString(line, ""))...)
+		default:
+			text := listMarker.ReplaceAllString(line, "")
+			// Require spaces around the separator so code like std::vector is not a card
+			if question, answer, ok := strings.Cut(text, " :: "); ok {
+				question, answer = strings.TrimSpace(question), strings.TrimSpace(answer)
+				if question != "" && answer != "" {
+					cards = append(cards, newInlineCard(question, answer))
+				}
+			}
+		}
+	}
+	flush()
+	return cards
+}
+
+func newInlineCard(question, answer string) InlineCard {
+	return InlineCard{Question: question, Answer: answer, Hash: TextHash(question, answer)}
+}
+
+// newClozeCards creates one card per deletion number found in text
+func newClozeCards(text string) []InlineCard {
+	ordinals := ClozeOrdinals(text)
+	cards := make([]InlineCard, 0, len(ordinals))
+	for _, ord := range ordinals {
+		cards = append(cards, InlineCard{
+			Question: text,
+			Answer:   ClozeAnswer(text, ord),
+			Hash:     TextHash(text, strconv.Itoa(ord)),
+			Ordinal:  ord,
+		})
+	}
+	return cards
+}
+
+// TextHash returns a short stable hash identifying a piece of card text
+func TextHash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(strings.TrimSpace(p)))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,59 @@
+package notes
+
+import "testing"
+
+func TestExtractInlineCards(t *testing.T) {
+	body := "# Networking\n\n" +
+		"- TCP :: Transmission Control Protocol\n" +
+		"Some prose with a colon: not a card, nor is std::vector.\n\n" +
+		"Q: What port does HTTPS use\n" +
+		"by default?\n" +
+		"A: 443\n" +
+		"unless configured otherwise\n\n" +
+		"The {{c1::OSI model}} has {{c2::seven::number}} layers.\n\n" +
+		"```\n" +
+		"key :: value inside code\n" +
+		"Q: ignored\n" +
+		"A: ignored\n" +
+		"```\n" +
+		"Q: Dangling question without answer\n"
+
+	cards := ExtractInlineCards(body)
+	if len(cards) != 4 {
+		t.Fatalf("Expected 4 inline cards, got %d: %+v", len(cards), cards)
+	}
+
+	if cards[0].Question != "TCP" || cards[0].Answer != "Transmission Control Protocol" {
+		t.Errorf("Unexpected :: card: %+v", cards[0])
+	}
+	if cards[1].Question != "What port does HTTPS use\nby default?" || cards[1].Answer != "443\nunless configured otherwise" {
+		t.Errorf("Unexpected Q/A block card: %+v", cards[1])
+	}
+	// One cloze card per deletion number, keeping the raw text as the question
+	cloze := "The {{c1::OSI model}} has {{c2::seven::number}} layers."
+	if cards[2].Question != cloze || cards[2].Answer != "OSI model" || cards[2].Ordinal != 1 {
+		t.Errorf("Unexpected first cloze card: %+v", cards[2])
+	}
+	if cards[3].Question != cloze || cards[3].Answer != "seven" || cards[3].Ordinal != 2 {
+		t.Errorf("Unexpected second cloze card: %+v", cards[3])
+	}
+	if cards[2].Hash == cards[3].Hash {
+		t.Error("Cloze siblings should have distinct hashes")
+	}
+}
+
+func TestInlineCardHashStable(t *testing.T) {
+	a := ExtractInlineCards("TCP :: Transmission Control Protocol")
+	b := ExtractInlineCards("\n  - TCP ::   Transmission Control Protocol  \n")
+	if len(a) != 1 || len(b) != 1 {
+		t.Fatalf("Expected one card each, got %d and %d", len(a), len(b))
+	}
+	if a[0].Hash != b[0].Hash {
+		t.Errorf("Hash should ignore surrounding whitespace: %s != %s", a[0].Hash, b[0].Hash)
+	}
+
+	c := ExtractInlineCards("TCP :: Transport Control Protocol")
+	if a[0].Hash == c[0].Hash {
+		t.Error("Hash should change when the card text changes")
+	}
+}
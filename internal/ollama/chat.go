@@ -0,0 +1,100 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ChatMessage is one turn of a conversation with the model
+type ChatMessage struct {
+	Role    string `json:"role"` // "system", "user" or "assistant"
+	Content string `json:"content"`
+}
+
+// ChatRequest represents a request to the Ollama chat API
+type ChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+// ChatURL derives the chat endpoint from the base of the configured Ollama
+// URL, which may be the server root or any of its API endpoints
+func ChatURL(ollamaURL string) string {
+	u, err := url.Parse(ollamaURL)
+	if err != nil {
+		return ollamaURL
+	}
+	base := strings.TrimSuffix(u.Path, "/")
+	if i := strings.LastIndex(base, "/api/"); i >= 0 {
+		base = base[:i]
+	}
+	base = strings.TrimSuffix(base, "/api")
+	u.Path, u.RawPath = base+"/api/chat", ""
+	return u.String()
+}
+
+// Chat sends a conversation to the Ollama chat API and streams the reply,
+// calling onToken for every chunk as it arrives. It returns the full reply, or
+// the part received and an error when the stream breaks off.
+// The request is bounded by ctx only since replies can take a while to stream.
+func Chat(ctx context.Context, model, url string, messages []ChatMessage, onToken func(string)) (string, error) {
+	body, err := json.Marshal(ChatRequest{Model: model, Messages: messages, Stream: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req) // #nosec G107 - URL is from config, validated by caller
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var reply strings.Builder
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var chunk struct {
+			Message ChatMessage `json:"message"`
+			Done    bool        `json:"done"`
+		}
+		if err := dec.Decode(&chunk); err != nil {
+			if ctx.Err() != nil {
+				return reply.String(), ctx.Err()
+			}
+			// The stream ends with a done chunk, without it the reply is cut short
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return reply.String(), fmt.Errorf("failed to read reply: %w", err)
+		}
+		if chunk.Message.Content != "" {
+			reply.WriteString(chunk.Message.Content)
+			if onToken != nil {
+				onToken(chunk.Message.Content)
+			}
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	return reply.String(), nil
+}
@@ -14,11 +14,22 @@ import (
 type OllamaRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
+	Format string `json:"format,omitempty"` // "json" constrains the reply to a JSON object
 }
 
 // GenerateQA sends a prompt to the Ollama API and returns the response
 func GenerateQA(ctx context.Context, model, url, prompt string) (string, error) {
-	body, err := json.Marshal(OllamaRequest{Model: model, Prompt: prompt})
+	return generate(ctx, url, OllamaRequest{Model: model, Prompt: prompt})
+}
+
+// GenerateJSON sends a prompt to the Ollama API asking for a JSON reply and
+// returns the raw response
+func GenerateJSON(ctx context.Context, model, url, prompt string) (string, error) {
+	return generate(ctx, url, OllamaRequest{Model: model, Prompt: prompt, Format: "json"})
+}
+
+func generate(ctx context.Context, url string, request OllamaRequest) (string, error) {
+	body, err := json.Marshal(request)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -63,7 +74,9 @@ func GenerateQA(ctx context.Context, model, url, prompt string) (string, error)
 	return response.String(), nil
 }
 
-// ParseFlashcards parses the Ollama response and returns a list of questions and answers
+// ParseFlashcards parses the Ollama response and returns a list of questions and answers.
+// Cloze lines ("C: text with {{c1::deletion}}") are returned with type "cloze" and
+// the full text as the question.
 func ParseFlashcards(response string) ([]map[string]string, error) {
 	var qas []map[string]string
 	lines := strings.Split(response, "\n")
@@ -83,6 +96,12 @@ func ParseFlashcards(response string) ([]map[string]string, error) {
 				qas = append(qas, map[string]string{"question": q, "answer": a})
 				q = "" // Reset for next Q/A pair
 			}
+		case "C:":
+			// Cloze text only counts if it contains at least one {{cN::...}} deletion
+			text := strings.TrimSpace(strings.Trim(l[2:], "*: "))
+			if strings.Contains(text, "{{c") && strings.Contains(text, "::") {
+				qas = append(qas, map[string]string{"question": text, "answer": "", "type": "cloze"})
+			}
 		}
 	}
 	return qas, nil
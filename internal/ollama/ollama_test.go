@@ -3,6 +3,8 @@ package ollama
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -61,6 +63,14 @@ func TestParseFlashcards(t *testing.T) {
 				{"question": "What is Go?", "answer": "A language"},
 			},
 		},
+		{
+			name:  "cloze line",
+			input: "Q: What is Go?\nA: A language\nC: A {{c1::goroutine}} is a lightweight thread\nC: not a cloze",
+			expected: []map[string]string{
+				{"question": "What is Go?", "answer": "A language"},
+				{"question": "A {{c1::goroutine}} is a lightweight thread", "answer": "", "type": "cloze"},
+			},
+		},
 		{
 			name:  "lines too short",
 			input: "Q:\nA:\nQ: Q\nA: A",
@@ -82,7 +92,7 @@ func TestParseFlashcards(t *testing.T) {
 				return
 			}
 			for i, qa := range result {
-				if qa["question"] != tt.expected[i]["question"] || qa["answer"] != tt.expected[i]["answer"] {
+				if qa["question"] != tt.expected[i]["question"] || qa["answer"] != tt.expected[i]["answer"] || qa["type"] != tt.expected[i]["type"] {
 					t.Errorf("ParseFlashcards() = %v, expected %v", qa, tt.expected[i])
 				}
 			}
@@ -229,3 +239,97 @@ func TestGenerateQAInvalidURL(t *testing.T) {
 		t.Error("GenerateQA() should return error for invalid URL")
 	}
 }
+
+func TestGenerateJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.Format != "json" {
+			t.Errorf("Expected json format, got %q", req.Format)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"response": `{"verdict": "correct"}`, "done": true})
+	}))
+	defer server.Close()
+
+	result, err := GenerateJSON(context.Background(), "test-model", server.URL, "Grade this")
+	if err != nil {
+		t.Fatalf("GenerateJSON() error = %v", err)
+	}
+	if result != `{"verdict": "correct"}` {
+		t.Errorf("GenerateJSON() = %q", result)
+	}
+}
+
+func TestChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if !req.Stream || len(req.Messages) != 2 || req.Messages[1].Content != "Why?" {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+		for i, token := range []string{"Because ", "TCP ", "retransmits."} {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": map[string]string{"role": "assistant", "content": token},
+				"done":    i == 2,
+			})
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	var tokens []string
+	messages := []ChatMessage{{Role: "system", Content: "Explain"}, {Role: "user", Content: "Why?"}}
+	reply, err := Chat(context.Background(), "test-model", server.URL, messages, func(s string) { tokens = append(tokens, s) })
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if reply != "Because TCP retransmits." || len(tokens) != 3 {
+		t.Errorf("Chat() = %q with tokens %q", reply, tokens)
+	}
+}
+
+func TestChatBrokenStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+	}{
+		{"cut short", `{"message":{"role":"assistant","content":"Because "},"done":false}` + "\n"},
+		{"malformed", `{"message":{"role":"assistant","content":"Because "},"done":false}` + "\n" + `{"message":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.stream))
+			}))
+			defer server.Close()
+
+			reply, err := Chat(context.Background(), "test-model", server.URL, nil, nil)
+			if err == nil || !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Chat() error = %v, want the broken stream reported", err)
+			}
+			if reply != "Because " {
+				t.Errorf("Chat() = %q, want the part received", reply)
+			}
+		})
+	}
+}
+
+func TestChatURL(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:11434/api/generate": "http://localhost:11434/api/chat",
+		"http://localhost:11434/api/chat":     "http://localhost:11434/api/chat",
+		"http://localhost:11434":              "http://localhost:11434/api/chat",
+		"http://localhost:11434/":             "http://localhost:11434/api/chat",
+		"http://localhost:11434/api":          "http://localhost:11434/api/chat",
+		"https://proxy/ollama/api/generate":   "https://proxy/ollama/api/chat",
+	}
+	for in, want := range tests {
+		if got := ChatURL(in); got != want {
+			t.Errorf("ChatURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package queue orders the flashcards of a review session
+package queue
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"catv/internal/store"
+)
+
+// Orders accepted by Build
+const (
+	OrderCreated    = "created"    // oldest cards first, the order they were generated in
+	OrderRandom     = "random"     // shuffled, reproducible with a seed
+	OrderOverdue    = "overdue"    // longest overdue first, new cards last
+	OrderInterleave = "interleave" // one card of each deck (or file) in turn
+	OrderWeakest    = "weakest"    // most lapses first
+)
+
+// Orders lists the accepted orders, for help and error messages
+var Orders = []string{OrderCreated, OrderRandom, OrderOverdue, OrderInterleave, OrderWeakest}
+
+// Mixes of new and review cards accepted by Build besides an "N:M" ratio
+const (
+	MixNewFirst     = "new-first"
+	MixReviewsFirst = "reviews-first"
+)
+
+// Options selects how a review queue is built
+type Options struct {
+	Order string
+	Seed  uint64 // seed of the random order, 0 for a different order every time
+	// Mix places new cards among reviews: new-first, reviews-first, or "N:M"
+	// for N new cards every M reviews. Empty keeps the order's placement.
+	Mix string
+}
+
+// Validate checks the order and mix
+func (o Options) Validate() error {
+	if o.Order != "" && !slices.Contains(Orders, o.Order) {
+		return fmt.Errorf("unknown order %q, expected one of %s", o.Order, strings.Join(Orders, ", "))
+	}
+	_, _, err := parseMix(o.Mix)
+	return err
+}
+
+// Build orders cards for review. Cards are not modified.
+func Build(cards []store.Flashcard, opts Options, now time.Time) ([]store.Flashcard, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Mix == "" {
+		return order(slices.Clone(cards), opts, now), nil
+	}
+
+	var newCards, reviews []store.Flashcard
+	for _, fc := range cards {
+		if fc.IsNew() {
+			newCards = append(newCards, fc)
+		} else {
+			reviews = append(reviews, fc)
+		}
+	}
+	newCards, reviews = order(newCards, opts, now), order(reviews, opts, now)
+	switch opts.Mix {
+	case MixNewFirst:
+		return append(newCards, reviews...), nil
+	case MixReviewsFirst:
+		return append(reviews, newCards...), nil
+	}
+	perNew, perReviews, _ := parseMix(opts.Mix)
+	return mix(newCards, reviews, perNew, perReviews), nil
+}
+
+// order sorts cards in place according to opts.Order
+func order(cards []store.Flashcard, opts Options, now time.Time) []store.Flashcard {
+	slices.SortStableFunc(cards, func(a, b store.Flashcard) int { return a.ID - b.ID })
+	switch opts.Order {
+	case OrderRandom:
+		seed := opts.Seed
+		if seed == 0 {
+			seed = uint64(now.UnixNano()) // #nosec G115 -- any value makes a usable seed
+		}
+		// #nosec G404 -- shuffling review order, not security sensitive
+		rng := rand.New(rand.NewPCG(seed, seed))
+		rng.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	case OrderOverdue:
+		// The earliest due date is the longest overdue
+		slices.SortStableFunc(cards, func(a, b store.Flashcard) int {
+			switch {
+			case a.IsNew() && !b.IsNew():
+				return 1
+			case !a.IsNew() && b.IsNew():
+				return -1
+			}
+			return a.DueAt.Compare(b.DueAt)
+		})
+	case OrderInterleave:
+		cards = interleave(cards)
+	case OrderWeakest:
+		slices.SortStableFunc(cards, func(a, b store.Flashcard) int { return b.Lapses - a.Lapses })
+	}
+	return cards
+}
+
+// interleave takes one card of each deck in turn, grouping cards without a
+// deck by file, so a session does not go through one topic at a time
+func interleave(cards []store.Flashcard) []store.Flashcard {
+	var keys []string
+	groups := make(map[string][]store.Flashcard)
+	for _, fc := range cards {
+		key := "deck:" + fc.Deck
+		if fc.Deck == "" {
+			key = "file:" + fc.File
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], fc)
+	}
+
+	interleaved := make([]store.Flashcard, 0, len(cards))
+	for len(interleaved) < len(cards) {
+		for _, key := range keys {
+			if group := groups[key]; len(group) > 0 {
+				interleaved = append(interleaved, group[0])
+				groups[key] = group[1:]
+			}
+		}
+	}
+	return interleaved
+}
+
+// mix alternates perNew new cards with perReviews reviews, appending the
+// rest of either once the other runs out
+func mix(newCards, reviews []store.Flashcard, perNew, perReviews int) []store.Flashcard {
+	mixed := make([]store.Flashcard, 0, len(newCards)+len(reviews))
+	for len(newCards) > 0 || len(reviews) > 0 {
+		n := min(perNew, len(newCards))
+		mixed, newCards = append(mixed, newCards[:n]...), newCards[n:]
+		r := min(perReviews, len(reviews))
+		mixed, reviews = append(mixed, reviews[:r]...), reviews[r:]
+	}
+	return mixed
+}
+
+// parseMix reads an "N:M" ratio of new cards to reviews
+func parseMix(mix string) (perNew, perReviews int, err error) {
+	switch mix {
+	case "", MixNewFirst, MixReviewsFirst:
+		return 0, 0, nil
+	}
+	n, m, ok := strings.Cut(mix, ":")
+	if ok {
+		perNew, err = strconv.Atoi(n)
+		if err == nil {
+			perReviews, err = strconv.Atoi(m)
+		}
+	}
+	if !ok || err != nil || perNew < 0 || perReviews < 0 || perNew+perReviews == 0 {
+		return 0, 0, fmt.Errorf("invalid mix %q, expected %s, %s or new:reviews such as 1:3", mix, MixNewFirst, MixReviewsFirst)
+	}
+	return perNew, perReviews, nil
+}
+
+// Limits returns the daily limits of a deck, negative for no limit
+type Limits func(deck string) (newCards, reviews int)
+
+// Limit keeps the cards of the queue that fit in their deck's daily limits,
+// counting the cards already reviewed today. It returns the cards kept, in
+// queue order, and the number of cards left for another day.
+func Limit(cards []store.Flashcard, limits Limits, reviewed map[string]store.DayCounts) (kept []store.Flashcard, deferred int) {
+	left := make(map[string]*store.DayCounts)
+	for _, fc := range cards {
+		l, ok := left[fc.Deck]
+		if !ok {
+			newCards, reviews := limits(fc.Deck)
+			done := reviewed[fc.Deck]
+			l = &store.DayCounts{New: remaining(newCards, done.New), Reviews: remaining(reviews, done.Reviews)}
+			left[fc.Deck] = l
+		}
+		count := &l.Reviews
+		if fc.IsNew() {
+			count = &l.New
+		}
+		if *count == 0 {
+			deferred++
+			continue
+		}
+		if *count > 0 {
+			*count--
+		}
+		kept = append(kept, fc)
+	}
+	return kept, deferred
+}
+
+// remaining is what is left of a daily limit, -1 for no limit
+func remaining(limit, done int) int {
+	if limit < 0 {
+		return -1
+	}
+	return max(limit-done, 0)
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"catv/internal/store"
+)
+
+func ids(cards []store.Flashcard) string {
+	out := make([]int, len(cards))
+	for i, fc := range cards {
+		out[i] = fc.ID
+	}
+	return fmt.Sprint(out)
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	cards := []store.Flashcard{
+		{ID: 4, File: "/b.md", DueAt: now.Add(-48 * time.Hour), Lapses: 1},
+		{ID: 1, File: "/a.md"},
+		{ID: 2, File: "/a.md", DueAt: now.Add(-time.Hour), Lapses: 3},
+		{ID: 3, File: "/a.md", Deck: "go"},
+		{ID: 5, File: "/b.md"},
+		{ID: 6, File: "/c.md", Deck: "go", DueAt: now.Add(-72 * time.Hour)},
+	}
+
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, "[1 2 3 4 5 6]"},
+		{Options{Order: OrderCreated}, "[1 2 3 4 5 6]"},
+		{Options{Order: OrderOverdue}, "[6 4 2 1 3 5]"},
+		{Options{Order: OrderInterleave}, "[1 3 4 2 6 5]"},
+		{Options{Order: OrderWeakest}, "[2 4 1 3 5 6]"},
+		{Options{Mix: MixNewFirst}, "[1 3 5 2 4 6]"},
+		{Options{Order: OrderOverdue, Mix: MixReviewsFirst}, "[6 4 2 1 3 5]"},
+		{Options{Mix: "1:2"}, "[1 2 4 3 6 5]"},
+	}
+	for _, tt := range tests {
+		got, err := Build(cards, tt.opts, now)
+		if err != nil {
+			t.Fatalf("Build(%+v) error = %v", tt.opts, err)
+		}
+		if ids(got) != tt.want {
+			t.Errorf("Build(%+v) = %s, want %s", tt.opts, ids(got), tt.want)
+		}
+	}
+	if ids(cards) != "[4 1 2 3 5 6]" {
+		t.Errorf("Build() should not reorder its input, got %s", ids(cards))
+	}
+}
+
+func TestBuildRandom(t *testing.T) {
+	var cards []store.Flashcard
+	for id := 1; id <= 20; id++ {
+		cards = append(cards, store.Flashcard{ID: id})
+	}
+	opts := Options{Order: OrderRandom, Seed: 42}
+	a, _ := Build(cards, opts, time.Now())
+	b, _ := Build(cards, opts, time.Now())
+	if ids(a) != ids(b) {
+		t.Errorf("Expected the same seed to give the same order, got %s and %s", ids(a), ids(b))
+	}
+	if ids(a) == ids(cards) || len(a) != len(cards) {
+		t.Errorf("Expected a shuffled order, got %s", ids(a))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, opts := range []Options{{Order: "alphabetical"}, {Mix: "lots"}, {Mix: "0:0"}, {Mix: "1:-2"}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", opts)
+		}
+	}
+	if err := (Options{Order: OrderRandom, Mix: "2:5"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	cards := []store.Flashcard{
+		{ID: 1, Deck: "vocab"},
+		{ID: 2, Deck: "vocab", DueAt: now},
+		{ID: 3, Deck: "vocab"},
+		{ID: 4, Deck: "vocab", DueAt: now},
+		{ID: 5, Deck: "exam"},
+		{ID: 6, Deck: "exam", DueAt: now},
+		{ID: 7, Deck: "exam"},
+	}
+	limits := func(deck string) (int, int) {
+		if deck == "exam" {
+			return -1, 0
+		}
+		return 2, 5
+	}
+
+	tests := []struct {
+		name         string
+		reviewed     map[string]store.DayCounts
+		want         string
+		wantDeferred int
+	}{
+		{"nothing reviewed", nil, "[1 2 3 4 5 7]", 1},
+		{"new limit partly used", map[string]store.DayCounts{"vocab": {New: 1, Reviews: 4}}, "[1 2 5 7]", 3},
+		{"limits exceeded", map[string]store.DayCounts{"vocab": {New: 3, Reviews: 9}, "exam": {New: 50}}, "[5 7]", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, deferred := Limit(cards, limits, tt.reviewed)
+			if ids(kept) != tt.want || deferred != tt.wantDeferred {
+				t.Errorf("Limit() = %s, %d, want %s, %d", ids(kept), deferred, tt.want, tt.wantDeferred)
+			}
+		})
+	}
+}
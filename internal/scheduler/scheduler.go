@@ -0,0 +1,64 @@
+// Package scheduler decides when flashcards are reviewed again
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// GraduatingInterval is the number of days until the next review of a card
+// that went through all its learning steps
+const GraduatingInterval = 1
+
+// Steps are the delays before a failed card is asked again in the same
+// session, one per correct answer needed before it graduates
+type Steps []time.Duration
+
+// ParseSteps reads steps written as durations, such as "1m" or "10m"
+func ParseSteps(steps []string) (Steps, error) {
+	parsed := make(Steps, 0, len(steps))
+	for _, s := range steps {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid learning step %q: %w", s, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid learning step %q: must be positive", s)
+		}
+		parsed = append(parsed, d)
+	}
+	return parsed, nil
+}
+
+// Delay returns the delay before the card is asked at step, false once the
+// card has passed every step and graduates
+func (s Steps) Delay(step int) (time.Duration, bool) {
+	if step < 0 || step >= len(s) {
+		return 0, false
+	}
+	return s[step], true
+}
+
+// DueAt returns when a card scheduled days from now is due: the start of
+// that day, so it is due whatever time the user reviews
+func DueAt(now time.Time, days int) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+days, 0, 0, 0, 0, now.Location())
+}
+
+// DayStart returns when the review day containing now started, days rolling
+// over at the given hour rather than midnight
+func DayStart(now time.Time, rolloverHour int) time.Time {
+	y, m, d := now.Date()
+	start := time.Date(y, m, d, rolloverHour, 0, 0, 0, now.Location())
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// NextDayStart returns when the review day after the one containing now
+// starts, when buried cards come back
+func NextDayStart(now time.Time, rolloverHour int) time.Time {
+	return DayStart(now, rolloverHour).AddDate(0, 0, 1)
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSteps(t *testing.T) {
+	steps, err := ParseSteps([]string{"1m", "10m"})
+	if err != nil || len(steps) != 2 || steps[1] != 10*time.Minute {
+		t.Fatalf("ParseSteps() = %v, %v", steps, err)
+	}
+	if d, ok := steps.Delay(0); !ok || d != time.Minute {
+		t.Errorf("Delay(0) = %v, %v", d, ok)
+	}
+	if _, ok := steps.Delay(2); ok {
+		t.Error("Expected the card to graduate after the last step")
+	}
+
+	for _, invalid := range [][]string{{"soon"}, {"0s"}, {"-1m"}} {
+		if _, err := ParseSteps(invalid); err == nil {
+			t.Errorf("Expected an error for %v", invalid)
+		}
+	}
+}
+
+func TestDueAt(t *testing.T) {
+	now := time.Date(2024, 3, 31, 22, 15, 0, 0, time.UTC)
+	if got, want := DueAt(now, 1), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("DueAt() = %v, want %v", got, want)
+	}
+}
+
+func TestDayStart(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 3, 10, 2, 30, 0, 0, time.UTC), time.Date(2024, 3, 9, 4, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 10, 4, 0, 0, 0, time.UTC), time.Date(2024, 3, 10, 4, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), time.Date(2024, 3, 10, 4, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := DayStart(tt.now, 4); !got.Equal(tt.want) {
+			t.Errorf("DayStart(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+		if got := NextDayStart(tt.now, 4); !got.Equal(tt.want.AddDate(0, 0, 1)) {
+			t.Errorf("NextDayStart(%v) = %v, want the day after %v", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"time"
+
+	"catv/internal/scheduler"
+	"catv/internal/store"
+)
+
+// Goal is a daily study goal. With both a number of cards and of minutes,
+// reaching either meets it. Without any, a day with a review meets it.
+type Goal struct {
+	Cards      int `json:"cards"`       // answers to give each day, 0 for none
+	Minutes    int `json:"minutes"`     // time to study each day, 0 for none
+	FreezeDays int `json:"freeze_days"` // days a week that can be missed without breaking the streak
+}
+
+// Met reports whether a day of study meets the goal
+func (g Goal) Met(answers int, spent time.Duration) bool {
+	return g.Progress(answers, spent) >= 1
+}
+
+// Progress is the share of the goal done, from 0 to 1, the furthest of cards
+// and minutes
+func (g Goal) Progress(answers int, spent time.Duration) float64 {
+	if g.Cards <= 0 && g.Minutes <= 0 {
+		if answers > 0 {
+			return 1
+		}
+		return 0
+	}
+	progress := 0.0
+	if g.Cards > 0 {
+		progress = float64(answers) / float64(g.Cards)
+	}
+	if g.Minutes > 0 {
+		progress = max(progress, spent.Minutes()/float64(g.Minutes))
+	}
+	return min(progress, 1)
+}
+
+// GoalStatus is the progress towards today's goal and the streak of days it
+// was met
+type GoalStatus struct {
+	Goal    Goal          `json:"goal"`
+	Answers int           `json:"answers"` // given today
+	Time    time.Duration `json:"-"`       // studied today
+	Minutes float64       `json:"minutes"` // Time in minutes
+	Streak  Streak        `json:"streak"`
+}
+
+// Streak is the number of consecutive days the goal was met. Missed days
+// within the freeze days of their week are frozen: they don't count but don't
+// break the streak.
+type Streak struct {
+	Current int  `json:"current"` // up to today, or yesterday while today's goal is not met
+	Frozen  int  `json:"frozen"`  // days frozen within the current streak
+	Longest int  `json:"longest"`
+	Today   bool `json:"today"` // whether today's goal is met
+}
+
+// Met reports whether today's goal is met
+func (s GoalStatus) Met() bool {
+	return s.Goal.Met(s.Answers, s.Time)
+}
+
+// Add returns the status once a session's answers are added to today's,
+// extending the streak when they meet the goal
+func (s GoalStatus) Add(answers int, spent time.Duration) GoalStatus {
+	met := s.Met()
+	s.Answers += answers
+	s.Time += spent
+	s.Minutes = s.Time.Minutes()
+	if !met && s.Met() {
+		s.Streak.Today = true
+		s.Streak.Current++
+		s.Streak.Longest = max(s.Streak.Longest, s.Streak.Current)
+	}
+	return s
+}
+
+// CollectGoal returns the progress towards the goal of the review day
+// containing now and the streak, from the answers given, cram included
+func CollectGoal(s *store.Store, goal Goal, now time.Time, rolloverHour int) (GoalStatus, error) {
+	days, err := s.GetStudyDays(time.Time{}, store.DayOffset(now, rolloverHour))
+	if err != nil {
+		return GoalStatus{}, err
+	}
+	return goalStatus(days, goal, scheduler.DayStart(now, rolloverHour)), nil
+}
+
+func goalStatus(days []store.StudyDay, goal Goal, today time.Time) GoalStatus {
+	status := GoalStatus{Goal: goal, Streak: goalStreak(days, goal, today)}
+	for _, d := range days {
+		if d.Day == date(today, 0) {
+			status.Answers, status.Time, status.Minutes = d.Answers, d.Time, d.Time.Minutes()
+		}
+	}
+	return status
+}
+
+// goalStreak walks the days from the first one studied to today. Today only
+// counts once its goal is met, it can still be.
+func goalStreak(days []store.StudyDay, goal Goal, today time.Time) Streak {
+	var s Streak
+	if len(days) == 0 {
+		return s
+	}
+	met := make(map[string]bool, len(days))
+	for _, d := range days {
+		met[d.Day] = goal.Met(d.Answers, d.Time)
+	}
+	first, err := time.Parse(dateLayout, days[0].Day)
+	if err != nil {
+		return s
+	}
+	s.Today = met[date(today, 0)]
+
+	frozen := make(map[int]int) // freeze days used by week
+	run := 0
+	for day := first; date(day, 0) <= date(today, 0); day = day.AddDate(0, 0, 1) {
+		year, week := day.ISOWeek()
+		key := year*100 + week
+		switch {
+		case met[date(day, 0)]:
+			run++
+		case date(day, 0) == date(today, 0):
+			// Today is not over
+		case run > 0 && frozen[key] < goal.FreezeDays:
+			frozen[key]++
+			s.Frozen++
+		default:
+			run, s.Frozen = 0, 0
+		}
+		s.Longest = max(s.Longest, run)
+	}
+	s.Current = run
+	return s
+}
@@ -0,0 +1,216 @@
+// Package stats sums up the flashcards and their review history: card states,
+// retention, daily activity, the due forecast and the daily goal with its streak
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"catv/internal/scheduler"
+	"catv/internal/store"
+)
+
+// Periods covered by a report
+const (
+	ActivityDays = 26 * 7 // days of the activity heatmap, today included
+	ForecastDays = 30     // days of the due forecast, today included
+	GroupDays    = 30     // days of reviews counted for each deck and file
+)
+
+// RetentionDays are the periods retention is measured over
+var RetentionDays = []int{7, 30, 90}
+
+// dateLayout is the layout of the dates in a report
+const dateLayout = "2006-01-02"
+
+// Report sums up the flashcards and their reviews as of a day
+type Report struct {
+	Date      string      `json:"date"` // review day the report was made on
+	Cards     Cards       `json:"cards"`
+	Retention []Retention `json:"retention"`
+	Goal      GoalStatus  `json:"goal"`
+	Activity  []Day       `json:"activity"` // every day of the heatmap, oldest first
+	Forecast  []Due       `json:"forecast"` // every day of the forecast, overdue cards due today
+	Decks     []Group     `json:"decks"`
+	Files     []Group     `json:"files"`
+}
+
+// Cards is the number of flashcards in each state
+type Cards struct {
+	Total     int `json:"total"`
+	New       int `json:"new"`
+	Learning  int `json:"learning"`
+	Young     int `json:"young"`
+	Mature    int `json:"mature"`
+	Suspended int `json:"suspended"`
+	Due       int `json:"due"`
+	Leeches   int `json:"leeches"`
+	Flagged   int `json:"flagged"`
+}
+
+// Retention is the share of learned cards recalled on their first answer of
+// the day, new cards and cram excluded
+type Retention struct {
+	Days     int     `json:"days"`
+	Recalls  int     `json:"recalls"`
+	Recalled int     `json:"recalled"`
+	Rate     float64 `json:"rate"` // 0 to 1, 0 without recalls
+}
+
+// Day is the activity of a day
+type Day struct {
+	Date    string  `json:"date"`
+	Answers int     `json:"answers"`
+	Minutes float64 `json:"minutes"`
+}
+
+// Due is the number of cards due on a day
+type Due struct {
+	Date  string `json:"date"`
+	Cards int    `json:"cards"`
+}
+
+// Group sums up the cards of a deck or a file and their reviews over the
+// last GroupDays days
+type Group struct {
+	Name      string  `json:"name"`
+	Cards     int     `json:"cards"`
+	New       int     `json:"new"`
+	Mature    int     `json:"mature"`
+	Due       int     `json:"due"`
+	Answers   int     `json:"answers"`
+	Recalls   int     `json:"recalls"`
+	Retention float64 `json:"retention"` // 0 to 1, 0 without recalls
+}
+
+// Collect builds the report of the review day containing now, days starting
+// at rolloverHour, with the progress towards goal
+func Collect(s *store.Store, goal Goal, now time.Time, rolloverHour int) (*Report, error) {
+	today := scheduler.DayStart(now, rolloverHour)
+	offset := store.DayOffset(now, rolloverHour)
+
+	states, err := s.GetStateCounts()
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.GetDailyStats(today.AddDate(0, 0, 1-max(ActivityDays, slices.Max(RetentionDays))), offset)
+	if err != nil {
+		return nil, err
+	}
+	status, err := CollectGoal(s, goal, now, rolloverHour)
+	if err != nil {
+		return nil, err
+	}
+	// Cards are due at midnight, the forecast goes by calendar days
+	due, err := s.GetDueForecast(today.AddDate(0, 0, ForecastDays), store.DayOffset(now, 0))
+	if err != nil {
+		return nil, err
+	}
+	since := today.AddDate(0, 0, 1-GroupDays)
+	decks, err := s.GetGroupStats(store.GroupByDeck, since, offset)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.GetGroupStats(store.GroupByFile, since, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{
+		Date: today.Format(dateLayout),
+		Cards: Cards{
+			Total: states.Total(), New: states.New, Learning: states.Learning, Young: states.Young, Mature: states.Mature,
+			Suspended: states.Suspended, Due: states.Due, Leeches: states.Leeches, Flagged: states.Flagged,
+		},
+		Goal:     status,
+		Activity: activity(days, today, ActivityDays),
+		Forecast: forecast(due, now, ForecastDays),
+		Decks:    groups(decks),
+		Files:    groups(files),
+	}
+	for _, n := range RetentionDays {
+		r.Retention = append(r.Retention, retention(days, today, n))
+	}
+	return r, nil
+}
+
+// date returns the date n days after day
+func date(day time.Time, n int) string {
+	y, m, d := day.Date()
+	return time.Date(y, m, d+n, 12, 0, 0, 0, time.UTC).Format(dateLayout)
+}
+
+// retention measures retention over the n days ending today
+func retention(days []store.DayStats, today time.Time, n int) Retention {
+	r := Retention{Days: n}
+	first := date(today, 1-n)
+	for _, d := range days {
+		if d.Day >= first {
+			r.Recalls += d.Recalls
+			r.Recalled += d.Recalled
+		}
+	}
+	r.Rate = rate(r.Recalled, r.Recalls)
+	return r
+}
+
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// activity lists the n days ending today with their reviews
+func activity(days []store.DayStats, today time.Time, n int) []Day {
+	byDate := make(map[string]store.DayStats, len(days))
+	for _, d := range days {
+		byDate[d.Day] = d
+	}
+	out := make([]Day, n)
+	for i := range out {
+		day := date(today, i+1-n)
+		d := byDate[day]
+		out[i] = Day{Date: day, Answers: d.Answers, Minutes: d.Time.Minutes()}
+	}
+	return out
+}
+
+// forecast lists the n days starting today with the cards due, overdue cards
+// counting as due today
+func forecast(due []store.DueCount, today time.Time, n int) []Due {
+	out := make([]Due, n)
+	index := make(map[string]int, n)
+	for i := range out {
+		out[i].Date = date(today, i)
+		index[out[i].Date] = i
+	}
+	for _, d := range due {
+		if d.Day < out[0].Date {
+			out[0].Cards += d.Cards
+		} else if i, ok := index[d.Day]; ok {
+			out[i].Cards += d.Cards
+		}
+	}
+	return out
+}
+
+func groups(stats []store.GroupStats) []Group {
+	out := make([]Group, len(stats))
+	for i, g := range stats {
+		out[i] = Group{
+			Name: g.Name, Cards: g.Cards, New: g.New, Mature: g.Mature, Due: g.Due,
+			Answers: g.Answers, Recalls: g.Recalls, Retention: rate(g.Recalled, g.Recalls),
+		}
+	}
+	return out
+}
+
+// Percent formats a rate as a percentage, a dash without data
+func Percent(rate float64, total int) string {
+	if total == 0 {
+		return "–"
+	}
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
@@ -0,0 +1,168 @@
+package stats
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"catv/internal/store"
+)
+
+func TestGoal(t *testing.T) {
+	tests := []struct {
+		name     string
+		goal     Goal
+		answers  int
+		spent    time.Duration
+		progress float64
+	}{
+		{"no target, no review", Goal{}, 0, 0, 0},
+		{"no target, a review", Goal{}, 1, time.Second, 1},
+		{"cards", Goal{Cards: 20}, 5, time.Hour, 0.25},
+		{"minutes", Goal{Minutes: 10}, 100, 5 * time.Minute, 0.5},
+		{"furthest of both", Goal{Cards: 20, Minutes: 10}, 15, 5 * time.Minute, 0.75},
+		{"capped", Goal{Cards: 20}, 30, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.goal.Progress(tt.answers, tt.spent); got != tt.progress {
+				t.Errorf("Progress() = %v, want %v", got, tt.progress)
+			}
+			if got := tt.goal.Met(tt.answers, tt.spent); got != (tt.progress == 1) {
+				t.Errorf("Met() = %v", got)
+			}
+		})
+	}
+}
+
+func TestGoalStreak(t *testing.T) {
+	// A Sunday, weeks start on Monday 2024-03-04
+	today := time.Date(2024, 3, 10, 4, 0, 0, 0, time.Local)
+	day := func(date string, answers int) store.StudyDay { return store.StudyDay{Day: date, Answers: answers} }
+	goal := Goal{Cards: 10}
+	tests := []struct {
+		name string
+		goal Goal
+		days []store.StudyDay
+		want Streak
+	}{
+		{"no study", goal, nil, Streak{}},
+		{"met today", goal, []store.StudyDay{day("2024-03-08", 10), day("2024-03-09", 12), day("2024-03-10", 10)}, Streak{Current: 3, Longest: 3, Today: true}},
+		{"today still to come", goal, []store.StudyDay{day("2024-03-08", 10), day("2024-03-09", 10), day("2024-03-10", 3)}, Streak{Current: 2, Longest: 2}},
+		{"missed without freeze", goal, []store.StudyDay{day("2024-03-06", 10), day("2024-03-07", 10), day("2024-03-08", 9), day("2024-03-09", 10)}, Streak{Current: 1, Longest: 2}},
+		{"frozen", Goal{Cards: 10, FreezeDays: 1}, []store.StudyDay{day("2024-03-06", 10), day("2024-03-07", 10), day("2024-03-09", 10)}, Streak{Current: 3, Frozen: 1, Longest: 3}},
+		{"out of freezes", Goal{Cards: 10, FreezeDays: 1}, []store.StudyDay{day("2024-03-05", 10), day("2024-03-07", 10), day("2024-03-09", 10)}, Streak{Current: 1, Longest: 2}},
+		{"freezes renew each week", Goal{Cards: 10, FreezeDays: 1}, []store.StudyDay{day("2024-03-01", 10), day("2024-03-03", 10), day("2024-03-05", 10), day("2024-03-06", 10),
+			day("2024-03-07", 10), day("2024-03-08", 10), day("2024-03-09", 10)}, Streak{Current: 7, Frozen: 2, Longest: 7}},
+		{"any review without target", Goal{}, []store.StudyDay{day("2024-03-09", 1), day("2024-03-10", 1)}, Streak{Current: 2, Longest: 2, Today: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goalStreak(tt.days, tt.goal, today); got != tt.want {
+				t.Errorf("goalStreak() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoalStatusAdd(t *testing.T) {
+	today := time.Date(2024, 3, 10, 4, 0, 0, 0, time.Local)
+	days := []store.StudyDay{{Day: "2024-03-09", Answers: 10}, {Day: "2024-03-10", Answers: 4, Time: time.Minute}}
+	status := goalStatus(days, Goal{Cards: 10}, today)
+	if status.Answers != 4 || status.Time != time.Minute || status.Met() || status.Streak != (Streak{Current: 1, Longest: 1}) {
+		t.Fatalf("goalStatus() = %+v", status)
+	}
+	if got := status.Add(3, time.Minute); got.Answers != 7 || got.Minutes != 2 || got.Streak.Today {
+		t.Errorf("Add(3) = %+v, the goal is not met yet", got)
+	}
+	got := status.Add(6, time.Minute)
+	if !got.Met() || got.Streak != (Streak{Current: 2, Longest: 2, Today: true}) {
+		t.Errorf("Add(6) = %+v, want the streak extended", got)
+	}
+	if again := got.Add(5, 0); again.Streak != got.Streak {
+		t.Errorf("Add() after the goal is met changed the streak to %+v", again.Streak)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	today := time.Date(2024, 3, 10, 4, 0, 0, 0, time.Local)
+	days := []store.DayStats{
+		{Day: "2024-02-01", Recalls: 10, Recalled: 2},
+		{Day: "2024-03-04", Recalls: 4, Recalled: 2},
+		{Day: "2024-03-10", Recalls: 4, Recalled: 4},
+	}
+	if got := retention(days, today, 7); got != (Retention{Days: 7, Recalls: 8, Recalled: 6, Rate: 0.75}) {
+		t.Errorf("retention(7) = %+v", got)
+	}
+	if got := retention(days, today, 90); got.Recalls != 18 || got.Recalled != 8 {
+		t.Errorf("retention(90) = %+v", got)
+	}
+	if got := retention(nil, today, 30); got.Rate != 0 || Percent(got.Rate, got.Recalls) != "–" {
+		t.Errorf("retention without recalls = %+v", got)
+	}
+}
+
+func TestActivityAndForecast(t *testing.T) {
+	today := time.Date(2024, 3, 1, 4, 0, 0, 0, time.Local)
+	days := []store.DayStats{{Day: "2024-02-28", Answers: 3, Time: 90 * time.Second}, {Day: "2024-03-01", Answers: 1}}
+	want := []Day{{Date: "2024-02-28", Answers: 3, Minutes: 1.5}, {Date: "2024-02-29"}, {Date: "2024-03-01", Answers: 1}}
+	if got := activity(days, today, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("activity() = %+v, want %+v", got, want)
+	}
+
+	due := []store.DueCount{{Day: "2024-02-20", Cards: 2}, {Day: "2024-03-01", Cards: 1}, {Day: "2024-03-03", Cards: 4}, {Day: "2024-04-01", Cards: 9}}
+	wantDue := []Due{{Date: "2024-03-01", Cards: 3}, {Date: "2024-03-02"}, {Date: "2024-03-03", Cards: 4}}
+	if got := forecast(due, today, 3); !reflect.DeepEqual(got, wantDue) {
+		t.Errorf("forecast() = %+v, want %+v", got, wantDue)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	s, err := store.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now()
+	id, err := s.CreateFlashcard(store.Flashcard{File: "/a.md", Deck: "vocab", Question: "Q", Answer: "A"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	session, err := s.StartSession("classic", []int{id})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	for _, e := range []store.ReviewLog{
+		{FlashcardID: id, ReviewedAt: now.AddDate(0, 0, -1), Correct: true},
+		{FlashcardID: id, ReviewedAt: now, Correct: false},
+	} {
+		if _, err := s.LogReview(e); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+	if err := s.AddSessionAnswers(session, 2, time.Minute); err != nil {
+		t.Fatalf("AddSessionAnswers() error = %v", err)
+	}
+
+	r, err := Collect(s, Goal{Cards: 5}, now, 0)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if r.Date != now.Format(dateLayout) || r.Cards.Total != 1 || r.Cards.New != 1 {
+		t.Errorf("Unexpected report of %s: %+v", r.Date, r.Cards)
+	}
+	if r.Goal.Goal.Cards != 5 || r.Goal.Answers != 1 || r.Goal.Met() || r.Goal.Streak != (Streak{}) {
+		t.Errorf("Goal = %+v", r.Goal)
+	}
+	if len(r.Activity) != ActivityDays || r.Activity[ActivityDays-1].Answers != 1 || len(r.Forecast) != ForecastDays {
+		t.Errorf("Expected %d days of activity and %d of forecast", ActivityDays, ForecastDays)
+	}
+	if len(r.Retention) != len(RetentionDays) || r.Retention[0].Recalls != 2 || r.Retention[0].Recalled != 1 {
+		t.Errorf("Retention = %+v", r.Retention)
+	}
+	if len(r.Decks) != 1 || r.Decks[0].Name != "vocab" || r.Decks[0].Answers != 2 || len(r.Files) != 1 {
+		t.Errorf("Decks = %+v, Files = %+v", r.Decks, r.Files)
+	}
+}
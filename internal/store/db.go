@@ -4,6 +4,10 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"slices"
+	"time"
+
+	"catv/internal/scheduler"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -39,12 +43,46 @@ func NewStore(dbName string) (*Store, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Tables added after the original schema
+	for _, table := range []string{createDistractorsTable, createReviewLogTable, createReviewSessionsTable, createNoteGenerationsTable, createRetiredHashesTable} {
+		if _, err := db.Exec(table); err != nil {
+			return nil, fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+
+	// Add columns introduced after the initial schema to existing databases
+	if _, err := migrateColumns(db, "flashcards", columnMigrations); err != nil {
+		return nil, err
+	}
+	if _, err := migrateColumns(db, "review_log", reviewLogColumnMigrations); err != nil {
+		return nil, err
+	}
+	added, err := migrateColumns(db, "review_sessions", reviewSessionColumnMigrations)
+	if err != nil {
+		return nil, err
+	}
+	// Sessions recorded before their totals were kept get them from their reviews
+	if slices.Contains(added, "answers") {
+		if err := backfillSessionTotals(db); err != nil {
+			return nil, err
+		}
+	}
+	// Cards scheduled before due dates existed are due revisitin days after
+	// their last review, or their creation when no review was logged
+	_, err = db.Exec(`UPDATE flashcards
+			  SET due_at = datetime(COALESCE((SELECT MAX(reviewed_at) FROM review_log WHERE flashcard_id = flashcards.id),
+			      created_at, datetime('now')), '+' || revisitin || ' days')
+			  WHERE due_at IS NULL AND revisitin > 0`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set due dates: %w", err)
+	}
 
 	// Create indexes for frequently queried columns to improve performance
 	indexes := []string{
 		`CREATE INDEX IF NOT EXISTS idx_flashcards_revisitin ON flashcards(revisitin)`,
 		`CREATE INDEX IF NOT EXISTS idx_flashcards_file ON flashcards(file)`,
 		`CREATE INDEX IF NOT EXISTS idx_flashcards_file_revisitin ON flashcards(file, revisitin)`,
+		`CREATE INDEX IF NOT EXISTS idx_flashcards_file_source_hash ON flashcards(file, source_hash)`,
 	}
 	for _, idx := range indexes {
 		if _, err := db.Exec(idx); err != nil {
@@ -55,12 +93,141 @@ func NewStore(dbName string) (*Store, error) {
 	return &Store{DB: db}, nil
 }
 
+// columnMigrations lists flashcards columns added after the original schema,
+// in the order they were introduced
+var columnMigrations = []columnMigration{
+	{"deck", "TEXT NOT NULL DEFAULT ''"},
+	{"tags", "TEXT NOT NULL DEFAULT ''"},
+	{"source_hash", "TEXT NOT NULL DEFAULT ''"},
+	{"card_type", "TEXT NOT NULL DEFAULT 'basic'"},
+	{"cloze_ordinal", "INTEGER NOT NULL DEFAULT 0"},
+	{"sibling_of", "INTEGER NOT NULL DEFAULT 0"},
+	{"buried_until", "DATETIME"},
+	{"due_at", "DATETIME"},
+	{"lapses", "INTEGER NOT NULL DEFAULT 0"},
+	{"suspended", "INTEGER NOT NULL DEFAULT 0"},
+	{"flagged", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// reviewLogColumnMigrations lists review_log columns added after the table was introduced
+var reviewLogColumnMigrations = []columnMigration{
+	{"timed_out", "INTEGER NOT NULL DEFAULT 0"},
+	{"session_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"was_new", "INTEGER NOT NULL DEFAULT 0"},
+	{"cram", "INTEGER NOT NULL DEFAULT 0"},
+	{"duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// columnMigration is a column added to an existing table
+type columnMigration struct {
+	name       string
+	definition string
+}
+
+// migrateColumns adds any missing columns to table and returns the names of
+// those it added
+func migrateColumns(db *sql.DB, table string, columns []columnMigration) ([]string, error) {
+	// #nosec G202 -- table names are constants passed by NewStore
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s schema: %w", table, err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name, typ  string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultVal, &pk); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan %s schema: %w", table, err)
+		}
+		existing[name] = true
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s schema: %w", table, err)
+	}
+
+	var added []string
+	for _, col := range columns {
+		if existing[col.name] {
+			continue
+		}
+		// #nosec G202 -- table and column names and definitions are constants defined above
+		if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + col.name + " " + col.definition); err != nil {
+			return nil, fmt.Errorf("failed to add column %s: %w", col.name, err)
+		}
+		added = append(added, col.name)
+	}
+	return added, nil
+}
+
+// flashcardColumns is the column list matching scanFlashcard
+const flashcardColumns = "id, file, question, answer, revisitin, deck, tags, source_hash, card_type, cloze_ordinal, sibling_of, buried_until, due_at, lapses, suspended, flagged"
+
+// timeLayout matches SQLite's datetime() output so stored times compare
+// correctly against datetime('now') in queries
+const timeLayout = "2006-01-02 15:04:05"
+
+// formatTime converts a time to the UTC text representation stored in the database
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// nullTime stores a zero time as NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return formatTime(t)
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFlashcard reads a row selected with flashcardColumns
+func scanFlashcard(r rowScanner) (Flashcard, error) {
+	var fc Flashcard
+	var tags, cardType string
+	var buriedUntil, dueAt sql.NullTime
+	if err := r.Scan(&fc.ID, &fc.File, &fc.Question, &fc.Answer, &fc.RevisitIn, &fc.Deck, &tags, &fc.SourceHash, &cardType, &fc.Ordinal,
+		&fc.SiblingOf, &buriedUntil, &dueAt, &fc.Lapses, &fc.Suspended, &fc.Flagged); err != nil {
+		return fc, err
+	}
+	fc.Type = CardType(cardType)
+	if buriedUntil.Valid {
+		fc.BuriedUntil = buriedUntil.Time
+	}
+	if dueAt.Valid {
+		fc.DueAt = dueAt.Time
+	}
+	fc.Tags = SplitTags(tags)
+	return fc, nil
+}
+
+// notBuried filters out flashcards temporarily hidden from review
+const notBuried = "(buried_until IS NULL OR buried_until <= datetime('now'))"
+
+// notSuspended filters out flashcards taken out of review
+const notSuspended = "suspended = 0"
+
+// isDue selects flashcards never scheduled, reset to 0, or whose due date has passed
+const isDue = "(revisitin <= 0 OR due_at <= datetime('now'))"
+
 // GetFlashcardsForReview returns all flashcards that are due for review
 // A flashcard is due for review when RevisitIn <= 0 or when the revisit date has passed
+// Buried flashcards are excluded until their burial expires, suspended ones
+// until they are unsuspended
 func (s *Store) GetFlashcardsForReview() ([]Flashcard, error) {
-	query := `SELECT id, file, question, answer, revisitin 
+	query := `SELECT ` + flashcardColumns + `
 			  FROM flashcards 
-			  WHERE revisitin <= 0 
+			  WHERE ` + isDue + ` AND ` + notBuried + ` AND ` + notSuspended + `
 			  ORDER BY id ASC`
 	rows, err := s.DB.Query(query)
 	if err != nil {
@@ -73,8 +240,7 @@ func (s *Store) GetFlashcardsForReview() ([]Flashcard, error) {
 	// Pre-allocate slice with reasonable initial capacity to reduce allocations
 	flashcards := make([]Flashcard, 0, 100)
 	for rows.Next() {
-		var fc Flashcard
-		err := rows.Scan(&fc.ID, &fc.File, &fc.Question, &fc.Answer, &fc.RevisitIn)
+		fc, err := scanFlashcard(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan flashcard: %w", err)
 		}
@@ -105,10 +271,10 @@ func (s *Store) GetFlashcardsForReviewByFiles(files []string) ([]Flashcard, erro
 
 	// nosemgrep: go.lang.security.audit.database.string-formatted-query.string-formatted-query
 	// #nosec G201 -- This is safe: we're only using fmt.Sprintf to build placeholders (?), not user data
-	query := fmt.Sprintf(`SELECT id, file, question, answer, revisitin 
+	query := fmt.Sprintf(`SELECT %s
 			  FROM flashcards 
-			  WHERE revisitin <= 0 AND file IN (%s)
-			  ORDER BY id ASC`, placeholders)
+			  WHERE %s AND %s AND %s AND file IN (%s)
+			  ORDER BY id ASC`, flashcardColumns, isDue, notBuried, notSuspended, placeholders)
 
 	// Convert files to []interface{} for Query
 	args := make([]interface{}, len(files))
@@ -127,8 +293,7 @@ func (s *Store) GetFlashcardsForReviewByFiles(files []string) ([]Flashcard, erro
 	// Pre-allocate slice with reasonable initial capacity to reduce allocations
 	flashcards := make([]Flashcard, 0, 50)
 	for rows.Next() {
-		var fc Flashcard
-		err := rows.Scan(&fc.ID, &fc.File, &fc.Question, &fc.Answer, &fc.RevisitIn)
+		fc, err := scanFlashcard(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan flashcard: %w", err)
 		}
@@ -173,7 +338,7 @@ func (s *Store) GetUniqueFiles() ([]string, error) {
 
 // GetAllFlashcards returns all flashcards ordered by revisitin ascending
 func (s *Store) GetAllFlashcards() ([]Flashcard, error) {
-	rows, err := s.DB.Query("SELECT id, file, question, answer, revisitin FROM flashcards ORDER BY revisitin ASC, id ASC")
+	rows, err := s.DB.Query("SELECT " + flashcardColumns + " FROM flashcards ORDER BY revisitin ASC, id ASC")
 	if err != nil {
 		return nil, err
 	}
@@ -183,8 +348,8 @@ func (s *Store) GetAllFlashcards() ([]Flashcard, error) {
 	// Pre-allocate slice with reasonable initial capacity to reduce allocations
 	flashcards := make([]Flashcard, 0, 100)
 	for rows.Next() {
-		var fc Flashcard
-		if err := rows.Scan(&fc.ID, &fc.File, &fc.Question, &fc.Answer, &fc.RevisitIn); err != nil {
+		fc, err := scanFlashcard(rows)
+		if err != nil {
 			return nil, err
 		}
 		flashcards = append(flashcards, fc)
@@ -192,28 +357,55 @@ func (s *Store) GetAllFlashcards() ([]Flashcard, error) {
 	return flashcards, nil
 }
 
-// DeleteFlashcard deletes a flashcard by id
+// DeleteFlashcard deletes a flashcard by id along with its reverse siblings,
+// all at once
 func (s *Store) DeleteFlashcard(id int) error {
-	_, err := s.DB.Exec("DELETE FROM flashcards WHERE id=?", id)
-	return err
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec("DELETE FROM distractors WHERE flashcard_id IN (SELECT id FROM flashcards WHERE id = ? OR sibling_of = ?)", id, id); err != nil {
+		return fmt.Errorf("failed to delete distractors of flashcard %d: %w", id, err)
+	}
+	if _, err := tx.Exec("DELETE FROM flashcards WHERE id = ? OR sibling_of = ?", id, id); err != nil {
+		return fmt.Errorf("failed to delete flashcard %d: %w", id, err)
+	}
+	return tx.Commit()
 }
 
-// UpdateFlashcardFull updates all editable fields of a flashcard
+// UpdateFlashcardFull updates all editable fields of a flashcard. Cached
+// distractors are dropped since they may no longer fit the edited card.
 func (s *Store) UpdateFlashcardFull(fc Flashcard) error {
-	_, err := s.DB.Exec("UPDATE flashcards SET file=?, question=?, answer=?, revisitin=? WHERE id=?", fc.File, fc.Question, fc.Answer, fc.RevisitIn, fc.ID)
+	// A changed revisit interval reschedules the card from today
+	var dueAt time.Time
+	if fc.RevisitIn > 0 {
+		dueAt = scheduler.DueAt(time.Now(), fc.RevisitIn)
+	}
+	_, err := s.DB.Exec(`UPDATE flashcards SET file=?, question=?, answer=?, deck=?, tags=?,
+			  due_at = CASE WHEN revisitin = ? THEN due_at ELSE ? END, revisitin=? WHERE id=?`,
+		fc.File, fc.Question, fc.Answer, fc.Deck, JoinTags(fc.Tags), fc.RevisitIn, nullTime(dueAt), fc.RevisitIn, fc.ID)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec("DELETE FROM distractors WHERE flashcard_id=?", fc.ID)
 	return err
 }
 
-// UpdateFlashcard updates a flashcard's revisitin date
+// UpdateFlashcard updates a flashcard's schedule: its revisit interval, due
+// date and lapses
 func (s *Store) UpdateFlashcard(fc Flashcard) error {
-	_, err := s.DB.Exec("UPDATE flashcards SET revisitin=? WHERE id=?", fc.RevisitIn, fc.ID)
+	_, err := s.DB.Exec("UPDATE flashcards SET revisitin=?, due_at=?, lapses=? WHERE id=?", fc.RevisitIn, nullTime(fc.DueAt), fc.Lapses, fc.ID)
 	return err
 }
 
-// IsFileProcessed checks if a file has already been processed
+// IsFileProcessed checks if a file has already been processed by the model.
+// Inline cards written by the author do not count, they are synced on every run.
 func (s *Store) IsFileProcessed(filePath string) (bool, error) {
 	var count int
-	row := s.DB.QueryRow("SELECT COUNT(*) FROM flashcards WHERE file = ?", filePath)
+	row := s.DB.QueryRow("SELECT COUNT(*) FROM flashcards WHERE file = ? AND source_hash = ''", filePath)
 	err := row.Scan(&count)
 	if err != nil {
 		return false, err
@@ -221,12 +413,87 @@ func (s *Store) IsFileProcessed(filePath string) (bool, error) {
 	return count > 0, nil
 }
 
+// GetFileQuestions returns the distinct questions of a file's flashcards,
+// oldest first. Reverse cards are left out, they repeat their original.
+func (s *Store) GetFileQuestions(filePath string) ([]string, error) {
+	rows, err := s.DB.Query("SELECT question FROM flashcards WHERE file = ? AND sibling_of = 0 GROUP BY question ORDER BY MIN(id)", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query questions of %s: %w", filePath, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var questions []string
+	for rows.Next() {
+		var q string
+		if err := rows.Scan(&q); err != nil {
+			return nil, fmt.Errorf("failed to scan question: %w", err)
+		}
+		questions = append(questions, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating questions: %w", err)
+	}
+	return questions, nil
+}
+
+// FlashcardExists checks if a flashcard with the same question (and cloze
+// deletion number) already exists for the flashcard's file
+func (s *Store) FlashcardExists(fc Flashcard) (bool, error) {
+	var count int
+	row := s.DB.QueryRow("SELECT COUNT(*) FROM flashcards WHERE file = ? AND question = ? AND cloze_ordinal = ?", fc.File, fc.Question, fc.Ordinal)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetSourceHashes returns the ids of a file's inline flashcards keyed by their source hash
+func (s *Store) GetSourceHashes(filePath string) (map[string]int, error) {
+	rows, err := s.DB.Query("SELECT id, source_hash FROM flashcards WHERE file = ? AND source_hash != ''", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query source hashes: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	hashes := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, fmt.Errorf("failed to scan source hash: %w", err)
+		}
+		hashes[hash] = id
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating source hashes: %w", err)
+	}
+	return hashes, nil
+}
+
 // InsertFlashcard inserts a new flashcard into the database
 func (s *Store) InsertFlashcard(fc Flashcard) error {
-	_, err := s.DB.Exec("INSERT INTO flashcards (file, question, answer, revisitin) VALUES (?, ?, ?, ?)", fc.File, fc.Question, fc.Answer, fc.RevisitIn)
+	_, err := s.CreateFlashcard(fc)
 	return err
 }
 
+// CreateFlashcard inserts a new flashcard like InsertFlashcard and returns its id
+func (s *Store) CreateFlashcard(fc Flashcard) (int, error) {
+	res, err := s.DB.Exec("INSERT INTO flashcards (file, question, answer, revisitin, deck, tags, source_hash, card_type, cloze_ordinal, sibling_of) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		fc.File, fc.Question, fc.Answer, fc.RevisitIn, fc.Deck, JoinTags(fc.Tags), fc.SourceHash, fc.Type.orDefault(), fc.Ordinal, fc.SiblingOf)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
 // Close closes the database connection
 func (s *Store) Close() {
 	_ = s.DB.Close()
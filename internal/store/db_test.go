@@ -1,9 +1,15 @@
 package store
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
 )
 
 func TestNewStore(t *testing.T) {
@@ -444,3 +450,1021 @@ func TestGetAllFlashcards_EmptyDatabase(t *testing.T) {
 		t.Errorf("Expected 0 flashcards in empty database, got %d", len(cards))
 	}
 }
+
+func TestFlashcardExists(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	if err := store.InsertFlashcard(Flashcard{File: "/test/1.md", Question: "Q1", Answer: "A1"}); err != nil {
+		t.Fatalf("InsertFlashcard() error = %v", err)
+	}
+
+	exists, err := store.FlashcardExists(Flashcard{File: "/test/1.md", Question: "Q1"})
+	if err != nil {
+		t.Fatalf("FlashcardExists() error = %v", err)
+	}
+	if !exists {
+		t.Error("Expected flashcard to exist")
+	}
+
+	exists, _ = store.FlashcardExists(Flashcard{File: "/test/2.md", Question: "Q1"})
+	if exists {
+		t.Error("Expected flashcard from another file not to match")
+	}
+
+	exists, _ = store.FlashcardExists(Flashcard{File: "/test/1.md", Question: "Q1", Ordinal: 2})
+	if exists {
+		t.Error("Expected another cloze deletion of the same text not to match")
+	}
+}
+
+func TestDeckAndTags(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	fc := Flashcard{File: "/test/1.md", Question: "Q1", Answer: "A1", Deck: "golang", Tags: []string{"lang", " backend "}}
+	if err := store.InsertFlashcard(fc); err != nil {
+		t.Fatalf("InsertFlashcard() error = %v", err)
+	}
+
+	cards, err := store.GetAllFlashcards()
+	if err != nil {
+		t.Fatalf("GetAllFlashcards() error = %v", err)
+	}
+	if cards[0].Deck != "golang" {
+		t.Errorf("Expected deck 'golang', got %q", cards[0].Deck)
+	}
+	if len(cards[0].Tags) != 2 || cards[0].Tags[1] != "backend" {
+		t.Errorf("Expected tags [lang backend], got %v", cards[0].Tags)
+	}
+}
+
+func TestNewStoreMigratesOldSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE flashcards (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file TEXT NOT NULL,
+		question TEXT NOT NULL,
+		answer TEXT NOT NULL,
+		revisitin INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO flashcards (file, question, answer) VALUES ('/a.md', 'Q', 'A')"); err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	// Created 3 days ago to be revisited in 2 days, and never reviewed
+	if _, err := db.Exec("INSERT INTO flashcards (file, question, answer, revisitin, created_at) VALUES ('/a.md', 'Q2', 'A', 2, datetime('now', '-3 days'))"); err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	// Created long ago but last reviewed yesterday to be revisited in 2 days
+	if _, err := db.Exec("INSERT INTO flashcards (file, question, answer, revisitin, created_at) VALUES ('/a.md', 'Q3', 'A', 2, datetime('now', '-30 days'))"); err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE review_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		flashcard_id INTEGER NOT NULL,
+		reviewed_at DATETIME NOT NULL,
+		correct INTEGER NOT NULL DEFAULT 0,
+		revisit_in INTEGER NOT NULL DEFAULT 0,
+		hints INTEGER NOT NULL DEFAULT 0,
+		mode TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create old review log: %v", err)
+	}
+	for _, ago := range []string{"-20 days", "-1 days"} {
+		if _, err := db.Exec("INSERT INTO review_log (flashcard_id, reviewed_at, correct, revisit_in) VALUES (3, datetime('now', ?), 1, 2)", ago); err != nil {
+			t.Fatalf("Failed to insert legacy review: %v", err)
+		}
+	}
+	_ = db.Close()
+
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	defer store.Close()
+
+	cards, err := store.GetFlashcardsForReview()
+	if err != nil {
+		t.Fatalf("GetFlashcardsForReview() error = %v", err)
+	}
+	if len(cards) != 2 || cards[0].Deck != "" || cards[0].Tags != nil {
+		t.Errorf("Expected legacy cards due with empty deck and tags, got %+v", cards)
+	}
+	if len(cards) == 2 && (!cards[0].IsNew() || cards[1].IsNew() || cards[1].Question != "Q2") {
+		t.Errorf("Expected the new card and the card due since its creation, got %+v", cards)
+	}
+	if _, err := store.LogReview(ReviewLog{FlashcardID: cards[0].ID, TimedOut: true}); err != nil {
+		t.Errorf("Expected review log to gain the timed_out column, got %v", err)
+	}
+}
+
+func TestClozeCardType(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	if err := store.InsertFlashcard(Flashcard{File: "/a.md", Question: "Q", Answer: "A"}); err != nil {
+		t.Fatalf("InsertFlashcard() error = %v", err)
+	}
+	cloze := Flashcard{File: "/a.md", Question: "{{c1::Go}} is fun", Answer: "Go", Type: CardTypeCloze, Ordinal: 1}
+	if err := store.InsertFlashcard(cloze); err != nil {
+		t.Fatalf("InsertFlashcard() error = %v", err)
+	}
+
+	cards, err := store.GetAllFlashcards()
+	if err != nil {
+		t.Fatalf("GetAllFlashcards() error = %v", err)
+	}
+	if cards[0].Type != CardTypeBasic || cards[0].IsCloze() {
+		t.Errorf("Expected untyped card to default to basic, got %q", cards[0].Type)
+	}
+	if !cards[1].IsCloze() || cards[1].Ordinal != 1 {
+		t.Errorf("Expected cloze card with ordinal 1, got %+v", cards[1])
+	}
+}
+
+func TestCreateReverse(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: "Capital of France?", Answer: "Paris", Deck: "geo"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, err := store.GetFlashcard(id)
+	if err != nil {
+		t.Fatalf("GetFlashcard() error = %v", err)
+	}
+
+	reverse, err := store.CreateReverse(fc)
+	if err != nil {
+		t.Fatalf("CreateReverse() error = %v", err)
+	}
+	if reverse.Question != "Paris" || reverse.Answer != "Capital of France?" || reverse.SiblingOf != id || reverse.Deck != "geo" {
+		t.Errorf("Unexpected reverse card: %+v", reverse)
+	}
+	if has, err := store.HasReverse(id); err != nil || !has {
+		t.Errorf("HasReverse() = %v, %v, want true", has, err)
+	}
+
+	if _, err := store.CreateReverse(fc); !errors.Is(err, ErrReverseExists) {
+		t.Errorf("Expected ErrReverseExists, got %v", err)
+	}
+	if _, err := store.CreateReverse(reverse); !errors.Is(err, ErrReverseNotAllowed) {
+		t.Errorf("Expected ErrReverseNotAllowed for a reverse card, got %v", err)
+	}
+	cloze := Flashcard{ID: 99, Question: "{{c1::Go}}", Type: CardTypeCloze, Ordinal: 1}
+	if _, err := store.CreateReverse(cloze); !errors.Is(err, ErrReverseNotAllowed) {
+		t.Errorf("Expected ErrReverseNotAllowed for a cloze card, got %v", err)
+	}
+}
+
+func TestBurySiblings(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: "Q", Answer: "A"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := store.GetFlashcard(id)
+	if _, err := store.CreateReverse(fc); err != nil {
+		t.Fatalf("CreateReverse() error = %v", err)
+	}
+	text := "{{c1::Go}} was made at {{c2::Google}}"
+	for ord := 1; ord <= 2; ord++ {
+		cloze := Flashcard{File: "/a.md", Question: text, Answer: "x", Type: CardTypeCloze, Ordinal: ord}
+		if err := store.InsertFlashcard(cloze); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+
+	due, err := store.GetFlashcardsForReview()
+	if err != nil || len(due) != 4 {
+		t.Fatalf("Expected 4 due cards before burying, got %d (%v)", len(due), err)
+	}
+
+	until := time.Now().Add(24 * time.Hour)
+	if err := store.BurySiblings(due[0], until); err != nil {
+		t.Fatalf("BurySiblings() error = %v", err)
+	}
+	if err := store.BurySiblings(due[2], until); err != nil {
+		t.Fatalf("BurySiblings() error = %v", err)
+	}
+
+	due, err = store.GetFlashcardsForReview()
+	if err != nil {
+		t.Fatalf("GetFlashcardsForReview() error = %v", err)
+	}
+	if len(due) != 2 || due[0].ID != id || due[1].Ordinal != 1 {
+		t.Errorf("Expected only the reviewed cards to stay due, got %+v", due)
+	}
+
+	// Burying in the past has no effect
+	if err := store.BurySiblings(due[0], time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("BurySiblings() error = %v", err)
+	}
+	if due, _ = store.GetFlashcardsForReview(); len(due) != 3 {
+		t.Errorf("Expected reverse card to be due again, got %d cards", len(due))
+	}
+}
+
+func TestDropSiblings(t *testing.T) {
+	text := "{{c1::a}} {{c2::b}}"
+	cards := []Flashcard{
+		{ID: 1, File: "/a.md", Question: "Q", Answer: "A"},
+		{ID: 2, File: "/a.md", Question: "A", Answer: "Q", SiblingOf: 1},
+		{ID: 3, File: "/a.md", Question: text, Type: CardTypeCloze, Ordinal: 1},
+		{ID: 4, File: "/a.md", Question: text, Type: CardTypeCloze, Ordinal: 2},
+		{ID: 5, File: "/b.md", Question: text, Type: CardTypeCloze, Ordinal: 1},
+		{ID: 6, File: "/a.md", Question: "Other", Answer: "A"},
+	}
+
+	got := DropSiblings(cards)
+	var ids []int
+	for _, fc := range got {
+		ids = append(ids, fc.ID)
+	}
+	if fmt.Sprint(ids) != "[1 3 5 6]" {
+		t.Errorf("DropSiblings() ids = %v, want [1 3 5 6]", ids)
+	}
+}
+
+func TestDistractors(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: "Capital of France?", Answer: "Paris", Deck: "geo"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	if got, err := store.GetDistractors(id); err != nil || len(got) != 0 {
+		t.Fatalf("Expected no cached distractors, got %v (%v)", got, err)
+	}
+
+	if err := store.SaveDistractors(id, []string{"Lyon", "Nice", "Lille"}); err != nil {
+		t.Fatalf("SaveDistractors() error = %v", err)
+	}
+	if err := store.SaveDistractors(id, []string{"Lyon", "Nice"}); err != nil {
+		t.Fatalf("SaveDistractors() error = %v", err)
+	}
+	got, err := store.GetDistractors(id)
+	if err != nil || fmt.Sprint(got) != "[Lyon Nice]" {
+		t.Errorf("GetDistractors() = %v, %v, want [Lyon Nice]", got, err)
+	}
+
+	// Editing the card invalidates the cache
+	fc, _ := store.GetFlashcard(id)
+	fc.Answer = "Paris, France"
+	if err := store.UpdateFlashcardFull(fc); err != nil {
+		t.Fatalf("UpdateFlashcardFull() error = %v", err)
+	}
+	if got, _ := store.GetDistractors(id); len(got) != 0 {
+		t.Errorf("Expected cache to be cleared after edit, got %v", got)
+	}
+}
+
+func TestGetDeckAnswers(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	cards := []Flashcard{
+		{File: "/a.md", Question: "Capital of France?", Answer: "Paris", Deck: "geo"},
+		{File: "/a.md", Question: "Capital of Italy?", Answer: "Rome", Deck: "geo"},
+		{File: "/b.md", Question: "Largest city of Italy?", Answer: "Rome", Deck: "geo"},
+		{File: "/b.md", Question: "Capital of Spain?", Answer: "Madrid", Deck: "geo"},
+		{File: "/c.md", Question: "What is Go?", Answer: "A language", Deck: "code"},
+		{File: "/c.md", Question: "{{c1::Paris}} is big", Answer: "Paris2", Deck: "geo", Type: CardTypeCloze, Ordinal: 1},
+	}
+	for _, fc := range cards {
+		if err := store.InsertFlashcard(fc); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+	all, _ := store.GetAllFlashcards()
+
+	answers, err := store.GetDeckAnswers(all[0])
+	if err != nil {
+		t.Fatalf("GetDeckAnswers() error = %v", err)
+	}
+	sort.Strings(answers)
+	if fmt.Sprint(answers) != "[Madrid Rome]" {
+		t.Errorf("GetDeckAnswers() = %v, want [Madrid Rome]", answers)
+	}
+}
+
+func TestReviewLog(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	reviewed := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	entries := []ReviewLog{
+		{FlashcardID: 1, ReviewedAt: reviewed.Add(time.Hour), Correct: true, RevisitIn: 3, Hints: 1, Mode: "typed", Duration: 4500 * time.Millisecond},
+		{FlashcardID: 1, ReviewedAt: reviewed, Correct: false, Mode: "classic"},
+		{FlashcardID: 2, Correct: true, RevisitIn: 7, TimedOut: true},
+	}
+	for _, e := range entries {
+		if _, err := store.LogReview(e); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+
+	logs, err := store.GetReviewLogs(1)
+	if err != nil {
+		t.Fatalf("GetReviewLogs() error = %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(logs))
+	}
+	if !logs[0].ReviewedAt.Equal(reviewed) || logs[0].Correct || logs[0].Mode != "classic" {
+		t.Errorf("Unexpected first entry: %+v", logs[0])
+	}
+	if !logs[1].Correct || logs[1].RevisitIn != 3 || logs[1].Hints != 1 || logs[1].Mode != "typed" || logs[1].Duration != 4500*time.Millisecond {
+		t.Errorf("Unexpected second entry: %+v", logs[1])
+	}
+
+	logs, _ = store.GetReviewLogs(2)
+	if len(logs) != 1 || logs[0].ReviewedAt.IsZero() || !logs[0].TimedOut {
+		t.Errorf("Expected a timed out entry reviewed now, got %+v", logs)
+	}
+}
+
+func TestReviewSessions(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	var ids []int
+	for _, q := range []string{"Q1", "Q2", "Q3"} {
+		id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: q, Answer: "A"})
+		if err != nil {
+			t.Fatalf("CreateFlashcard() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if session, err := store.GetUnfinishedSession(); err != nil || session != nil {
+		t.Fatalf("Expected no unfinished session, got %+v (%v)", session, err)
+	}
+	id, err := store.StartSession("typed", []int{ids[2], ids[0], ids[1]})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	if _, err := store.LogReview(ReviewLog{FlashcardID: ids[2], SessionID: id}); err != nil {
+		t.Fatalf("LogReview() error = %v", err)
+	}
+	if err := store.DeleteFlashcard(ids[1]); err != nil {
+		t.Fatalf("DeleteFlashcard() error = %v", err)
+	}
+
+	session, err := store.GetUnfinishedSession()
+	if err != nil || session == nil || session.ID != id || session.Mode != "typed" || len(session.Queue) != 3 {
+		t.Fatalf("Expected the interrupted session, got %+v (%v)", session, err)
+	}
+	remaining, err := store.GetSessionRemaining(session)
+	if err != nil {
+		t.Fatalf("GetSessionRemaining() error = %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != ids[0] {
+		t.Errorf("Expected only the ungraded, existing card to remain, got %+v", remaining)
+	}
+
+	// A card graded then queued again remains once the queue is saved
+	if err := store.SetSessionQueue(id, []int{ids[0], ids[2]}); err != nil {
+		t.Fatalf("SetSessionQueue() error = %v", err)
+	}
+	session, _ = store.GetUnfinishedSession()
+	remaining, err = store.GetSessionRemaining(session)
+	if err != nil || len(remaining) != 2 || remaining[0].ID != ids[0] || remaining[1].ID != ids[2] {
+		t.Fatalf("Expected the saved queue to remain, got %+v (%v)", remaining, err)
+	}
+	if _, err := store.LogReview(ReviewLog{FlashcardID: ids[0], SessionID: id}); err != nil {
+		t.Fatalf("LogReview() error = %v", err)
+	}
+	remaining, _ = store.GetSessionRemaining(session)
+	if len(remaining) != 1 || remaining[0].ID != ids[2] {
+		t.Errorf("Expected the cards graded since the queue was saved left out, got %+v", remaining)
+	}
+
+	if err := store.FinishSession(id); err != nil {
+		t.Fatalf("FinishSession() error = %v", err)
+	}
+	if session, _ := store.GetUnfinishedSession(); session != nil {
+		t.Errorf("Expected finished session not to be offered, got %+v", session)
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	for _, q := range []string{"past", "future", "reset"} {
+		if err := store.InsertFlashcard(Flashcard{File: "/a.md", Question: q, Answer: "A"}); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+	cards, _ := store.GetAllFlashcards()
+	schedule := map[string]Flashcard{
+		"past":   {RevisitIn: 3, DueAt: time.Now().Add(-time.Hour), Lapses: 2},
+		"future": {RevisitIn: 3, DueAt: time.Now().Add(72 * time.Hour)},
+		"reset":  {RevisitIn: 0, DueAt: time.Now().Add(72 * time.Hour)},
+	}
+	for _, fc := range cards {
+		s := schedule[fc.Question]
+		fc.RevisitIn, fc.DueAt, fc.Lapses = s.RevisitIn, s.DueAt, s.Lapses
+		if err := store.UpdateFlashcard(fc); err != nil {
+			t.Fatalf("UpdateFlashcard() error = %v", err)
+		}
+	}
+
+	due, err := store.GetFlashcardsForReview()
+	if err != nil {
+		t.Fatalf("GetFlashcardsForReview() error = %v", err)
+	}
+	if len(due) != 2 || due[0].Question != "past" || due[1].Question != "reset" || due[0].Lapses != 2 {
+		t.Errorf("Expected the past due and reset cards, got %+v", due)
+	}
+
+	// Editing the interval in admin reschedules the card from today
+	future, _ := store.GetFlashcard(cards[1].ID)
+	future.RevisitIn = 1
+	if err := store.UpdateFlashcardFull(future); err != nil {
+		t.Fatalf("UpdateFlashcardFull() error = %v", err)
+	}
+	future, _ = store.GetFlashcard(cards[1].ID)
+	if future.DueAt.After(time.Now().Add(24 * time.Hour)) {
+		t.Errorf("Expected the card due by tomorrow, got %v", future.DueAt)
+	}
+}
+
+func TestGetDayCounts(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	for i, deck := range []string{"vocab", "vocab", "vocab", "grammar"} {
+		fc := Flashcard{File: "/a.md", Question: fmt.Sprintf("Q%d", i), Answer: "A", Deck: deck}
+		if err := store.InsertFlashcard(fc); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+	cards, _ := store.GetAllFlashcards()
+
+	dayStart := time.Date(2024, 3, 1, 4, 0, 0, 0, time.UTC)
+	entries := []ReviewLog{
+		// A new card failed then repeated counts once as new
+		{FlashcardID: cards[0].ID, ReviewedAt: dayStart.Add(time.Hour), WasNew: true},
+		{FlashcardID: cards[0].ID, ReviewedAt: dayStart.Add(2 * time.Hour), Correct: true},
+		{FlashcardID: cards[1].ID, ReviewedAt: dayStart.Add(time.Hour), Correct: true},
+		{FlashcardID: cards[3].ID, ReviewedAt: dayStart.Add(time.Hour), WasNew: true},
+		// Cram reviews leave the day's limits alone
+		{FlashcardID: cards[2].ID, ReviewedAt: dayStart.Add(time.Hour), Cram: true},
+		// Reviewed the day before
+		{FlashcardID: cards[2].ID, ReviewedAt: dayStart.Add(-time.Hour), Correct: true},
+	}
+	for _, e := range entries {
+		if _, err := store.LogReview(e); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+
+	counts, err := store.GetDayCounts(dayStart)
+	if err != nil {
+		t.Fatalf("GetDayCounts() error = %v", err)
+	}
+	want := map[string]DayCounts{"vocab": {New: 1, Reviews: 1}, "grammar": {New: 1}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("GetDayCounts() = %v, want %v", counts, want)
+	}
+}
+
+func TestGetFlashcardsMatching(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	cards := []Flashcard{
+		{File: "/a.md", Question: "Q1", Answer: "A", Deck: "go", Tags: []string{"exam", "basics"}, RevisitIn: 30},
+		{File: "/a.md", Question: "Q2", Answer: "A", Deck: "go", Tags: []string{"examples"}},
+		{File: "/b.md", Question: "Q3", Answer: "A", Deck: "rust", Tags: []string{"exam"}},
+	}
+	for _, fc := range cards {
+		if err := store.InsertFlashcard(fc); err != nil {
+			t.Fatalf("InsertFlashcard() error = %v", err)
+		}
+	}
+	all, _ := store.GetAllFlashcards()
+	byQuestion := make(map[string]int)
+	for _, fc := range all {
+		byQuestion[fc.Question] = fc.ID
+	}
+	now := time.Now()
+	for _, e := range []ReviewLog{
+		{FlashcardID: byQuestion["Q1"], ReviewedAt: now.Add(-48 * time.Hour)},
+		{FlashcardID: byQuestion["Q3"], ReviewedAt: now.Add(-30 * 24 * time.Hour)},
+		{FlashcardID: byQuestion["Q2"], ReviewedAt: now.Add(-time.Hour), Correct: true},
+	} {
+		if _, err := store.LogReview(e); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"no filter", Filter{}, []string{"Q1", "Q2", "Q3"}},
+		{"deck", Filter{Deck: "go"}, []string{"Q1", "Q2"}},
+		{"file", Filter{File: "/b.md"}, []string{"Q3"}},
+		{"whole tag", Filter{Tag: "exam"}, []string{"Q1", "Q3"}},
+		{"failed since", Filter{FailedSince: now.Add(-7 * 24 * time.Hour)}, []string{"Q1"}},
+		{"combined", Filter{Deck: "rust", Tag: "basics"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.GetFlashcardsMatching(tt.filter)
+			if err != nil {
+				t.Fatalf("GetFlashcardsMatching() error = %v", err)
+			}
+			var questions []string
+			for _, fc := range got {
+				questions = append(questions, fc.Question)
+			}
+			if !reflect.DeepEqual(questions, tt.want) {
+				t.Errorf("GetFlashcardsMatching(%+v) = %v, want %v", tt.filter, questions, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardStates(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	var ids []int
+	for _, q := range []string{"suspended", "buried", "flagged"} {
+		id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: q, Answer: "A"})
+		if err != nil {
+			t.Fatalf("CreateFlashcard() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := store.SetSuspended(ids[0], true); err != nil {
+		t.Fatalf("SetSuspended() error = %v", err)
+	}
+	if err := store.Bury(ids[1], time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Bury() error = %v", err)
+	}
+	if err := store.SetFlagged(ids[2], true); err != nil {
+		t.Fatalf("SetFlagged() error = %v", err)
+	}
+
+	due, _ := store.GetFlashcardsForReview()
+	if len(due) != 1 || due[0].ID != ids[2] || !due[0].Flagged {
+		t.Fatalf("Expected only the flagged card due, got %+v", due)
+	}
+	byFile, _ := store.GetFlashcardsForReviewByFiles([]string{"/a.md"})
+	matching, _ := store.GetFlashcardsMatching(Filter{})
+	if len(byFile) != 1 || len(matching) != 2 {
+		t.Errorf("Expected suspended cards left out of review and cram, got %d and %d cards", len(byFile), len(matching))
+	}
+	session, _ := store.StartSession("classic", ids)
+	remaining, _ := store.GetSessionRemaining(&ReviewSession{ID: session, Queue: ids})
+	if len(remaining) != 1 {
+		t.Errorf("Expected suspended and buried cards left out of a resumed session, got %+v", remaining)
+	}
+
+	suspended, _ := store.GetFlashcard(ids[0])
+	buried, _ := store.GetFlashcard(ids[1])
+	if !suspended.Suspended || !buried.IsBuried(time.Now()) {
+		t.Errorf("Expected card states to be loaded, got %+v and %+v", suspended, buried)
+	}
+
+	// Burials expire on their own, unsuspending and unburying bring cards back
+	_ = store.SetSuspended(ids[0], false)
+	_ = store.Bury(ids[1], time.Time{})
+	if due, _ = store.GetFlashcardsForReview(); len(due) != 3 {
+		t.Errorf("Expected all cards due again, got %d", len(due))
+	}
+}
+
+func TestLeeches(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	var ids []int
+	for i, lapses := range []int{3, 9, 0} {
+		id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: fmt.Sprintf("Q%d", i), Answer: "A", Deck: "go", Tags: []string{"net"}})
+		if err != nil {
+			t.Fatalf("CreateFlashcard() error = %v", err)
+		}
+		_ = store.UpdateFlashcard(Flashcard{ID: id, Lapses: lapses})
+		ids = append(ids, id)
+	}
+	if err := store.MarkLeech(ids[0], false); err != nil {
+		t.Fatalf("MarkLeech() error = %v", err)
+	}
+	if err := store.MarkLeech(ids[1], true); err != nil {
+		t.Fatalf("MarkLeech() error = %v", err)
+	}
+
+	leeches, err := store.GetLeeches()
+	if err != nil {
+		t.Fatalf("GetLeeches() error = %v", err)
+	}
+	if len(leeches) != 2 || leeches[0].ID != ids[1] || !leeches[0].Suspended || leeches[1].Suspended {
+		t.Fatalf("Expected both leeches, most forgotten first and only it suspended, got %+v", leeches)
+	}
+	if !reflect.DeepEqual(leeches[1].Tags, []string{"net", LeechTag}) {
+		t.Errorf("Expected the leech tag added, got %v", leeches[1].Tags)
+	}
+
+	if err := store.UnmarkLeech(ids[1], true); err != nil {
+		t.Fatalf("UnmarkLeech() error = %v", err)
+	}
+	fc, _ := store.GetFlashcard(ids[1])
+	if fc.Suspended || !reflect.DeepEqual(fc.Tags, []string{"net"}) {
+		t.Errorf("Expected the leech unmarked and unsuspended, got %+v", fc)
+	}
+
+	// A leech replaced by smaller cards keeps its review history
+	if _, err := store.LogReview(ReviewLog{FlashcardID: ids[0]}); err != nil {
+		t.Fatalf("LogReview() error = %v", err)
+	}
+	replacements := []Flashcard{
+		{File: "/a.md", Question: "Q0a", Answer: "A", Deck: "go"},
+		{File: "/a.md", Question: "Q0b", Answer: "A", Deck: "go"},
+	}
+	if err := store.ReplaceFlashcard(ids[0], replacements); err != nil {
+		t.Fatalf("ReplaceFlashcard() error = %v", err)
+	}
+	all, _ := store.GetAllFlashcards()
+	logs, _ := store.GetReviewLogs(ids[0])
+	if _, err := store.GetFlashcard(ids[0]); !errors.Is(err, sql.ErrNoRows) || len(all) != 4 || len(logs) != 1 {
+		t.Errorf("Expected the leech replaced by 2 cards with its history kept, got %d cards, %d logs, err %v", len(all), len(logs), err)
+	}
+}
+
+func TestGetFileQuestions(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: "bonjour", Answer: "hello"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := store.GetFlashcard(id)
+	if _, err := store.CreateReverse(fc); err != nil {
+		t.Fatalf("CreateReverse() error = %v", err)
+	}
+	createCards(t, store, []Flashcard{
+		{File: "/a.md", Question: "The {{c1::Seine}} flows through {{c2::Paris}}.", Answer: "Seine", Type: CardTypeCloze, Ordinal: 1},
+		{File: "/a.md", Question: "The {{c1::Seine}} flows through {{c2::Paris}}.", Answer: "Paris", Type: CardTypeCloze, Ordinal: 2},
+		{File: "/b.md", Question: "Other note?", Answer: "A"},
+	})
+
+	questions, err := store.GetFileQuestions("/a.md")
+	want := []string{"bonjour", "The {{c1::Seine}} flows through {{c2::Paris}}."}
+	if err != nil || !reflect.DeepEqual(questions, want) {
+		t.Errorf("GetFileQuestions() = %v, %v, want %v", questions, err, want)
+	}
+}
+
+func TestDeleteFlashcardSiblings(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: "bonjour", Answer: "hello"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := store.GetFlashcard(id)
+	if _, err := store.CreateReverse(fc); err != nil {
+		t.Fatalf("CreateReverse() error = %v", err)
+	}
+	other, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: "merci", Answer: "thanks"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+
+	// The reverse goes with the card instead of pointing to a deleted one
+	if err := store.DeleteFlashcard(id); err != nil {
+		t.Fatalf("DeleteFlashcard() error = %v", err)
+	}
+	all, _ := store.GetAllFlashcards()
+	if len(all) != 1 || all[0].ID != other {
+		t.Errorf("Expected only the unrelated card left, got %+v", all)
+	}
+}
+
+func TestReplaceFlashcardSiblings(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	id, err := store.CreateFlashcard(Flashcard{File: "/a.md", Question: "bonjour", Answer: "hello", SourceHash: "h1"})
+	if err != nil {
+		t.Fatalf("CreateFlashcard() error = %v", err)
+	}
+	fc, _ := store.GetFlashcard(id)
+	reverse := fc.Reverse()
+	reverse.SourceHash = "h1r"
+	if err := store.InsertFlashcard(reverse); err != nil {
+		t.Fatalf("InsertFlashcard() error = %v", err)
+	}
+
+	// The reverse goes with the leech and both sources are retired
+	if err := store.ReplaceFlashcard(id, []Flashcard{{File: "/a.md", Question: "Greeting in French?", Answer: "bonjour"}}); err != nil {
+		t.Fatalf("ReplaceFlashcard() error = %v", err)
+	}
+	all, _ := store.GetAllFlashcards()
+	if len(all) != 1 || all[0].Question != "Greeting in French?" {
+		t.Errorf("Expected only the replacement left, got %+v", all)
+	}
+	retired, err := store.GetRetiredHashes("/a.md")
+	if err != nil || !reflect.DeepEqual(retired, map[string]bool{"h1": true, "h1r": true}) {
+		t.Fatalf("GetRetiredHashes() = %v, %v, want both hashes", retired, err)
+	}
+
+	if err := store.UnretireHash("/a.md", "h1r"); err != nil {
+		t.Fatalf("UnretireHash() error = %v", err)
+	}
+	if retired, _ = store.GetRetiredHashes("/a.md"); !reflect.DeepEqual(retired, map[string]bool{"h1": true}) {
+		t.Errorf("Expected the hash unretired, got %v", retired)
+	}
+}
+
+func TestGetStateCounts(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(48 * time.Hour)
+	cards := []Flashcard{
+		{Question: "new"},
+		{Question: "learning", DueAt: past},
+		{Question: "young", RevisitIn: 3, DueAt: future},
+		{Question: "young and due", RevisitIn: 3, DueAt: past, Flagged: true},
+		{Question: "mature", RevisitIn: 30, DueAt: future, Tags: []string{"exam", LeechTag}},
+		{Question: "suspended", RevisitIn: 30, DueAt: past, Suspended: true},
+	}
+	for i := range cards {
+		cards[i].File, cards[i].Answer = "/a.md", "A"
+	}
+	createCards(t, store, cards)
+
+	got, err := store.GetStateCounts()
+	if err != nil {
+		t.Fatalf("GetStateCounts() error = %v", err)
+	}
+	want := StateCounts{New: 1, Learning: 1, Young: 2, Mature: 1, Suspended: 1, Due: 3, Leeches: 1, Flagged: 1}
+	if got != want || got.Total() != len(cards) {
+		t.Errorf("GetStateCounts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetDailyStats(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	entries := []ReviewLog{
+		// Only the first answer of the day to a learned card is a recall
+		{FlashcardID: 1, ReviewedAt: day, Duration: 2 * time.Second},
+		{FlashcardID: 1, ReviewedAt: day.Add(time.Minute), Correct: true, Duration: time.Second},
+		{FlashcardID: 2, ReviewedAt: day, Correct: true, Duration: time.Second},
+		{FlashcardID: 3, ReviewedAt: day, Correct: true, WasNew: true},
+		{FlashcardID: 4, ReviewedAt: day, Correct: true, Cram: true},
+		// Before 4am, still the day before
+		{FlashcardID: 1, ReviewedAt: day.Add(17 * time.Hour), Correct: true},
+		{FlashcardID: 1, ReviewedAt: day.Add(19 * time.Hour), Correct: true},
+	}
+	for _, e := range entries {
+		if _, err := store.LogReview(e); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+
+	got, err := store.GetDailyStats(day.Add(-time.Hour), -4*time.Hour)
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	want := []DayStats{
+		{Day: "2024-03-01", Answers: 6, Time: 4 * time.Second, Recalls: 2, Recalled: 1},
+		{Day: "2024-03-02", Answers: 1, Recalls: 1, Recalled: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDailyStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetDueForecastAndGroupStats(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	now := time.Now().UTC()
+	cards := []Flashcard{
+		{File: "/a.md", Deck: "vocab", Question: "new"},
+		{File: "/a.md", Deck: "vocab", Question: "overdue", RevisitIn: 1, DueAt: now.AddDate(0, 0, -3)},
+		{File: "/b.md", Deck: "vocab", Question: "mature", RevisitIn: 30, DueAt: now.AddDate(0, 0, 2)},
+		{File: "/b.md", Deck: "grammar", Question: "later", RevisitIn: 60, DueAt: now.AddDate(0, 0, 60)},
+		{File: "/b.md", Deck: "grammar", Question: "suspended", RevisitIn: 1, DueAt: now, Suspended: true},
+	}
+	for i := range cards {
+		cards[i].Answer = "A"
+	}
+	createCards(t, store, cards)
+
+	forecast, err := store.GetDueForecast(now.AddDate(0, 0, 30), 0)
+	if err != nil {
+		t.Fatalf("GetDueForecast() error = %v", err)
+	}
+	want := []DueCount{{Day: now.AddDate(0, 0, -3).Format("2006-01-02"), Cards: 1}, {Day: now.AddDate(0, 0, 2).Format("2006-01-02"), Cards: 1}}
+	if !reflect.DeepEqual(forecast, want) {
+		t.Errorf("GetDueForecast() = %+v, want %+v", forecast, want)
+	}
+
+	for _, e := range []ReviewLog{
+		{FlashcardID: cards[1].ID, ReviewedAt: now.Add(-time.Hour)},
+		{FlashcardID: cards[1].ID, ReviewedAt: now.Add(-time.Minute), Correct: true},
+		{FlashcardID: cards[2].ID, ReviewedAt: now.Add(-time.Hour), Correct: true},
+		{FlashcardID: cards[2].ID, ReviewedAt: now.AddDate(0, 0, -40), Correct: true},
+	} {
+		if _, err := store.LogReview(e); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+	decks, err := store.GetGroupStats(GroupByDeck, now.AddDate(0, 0, -30), 0)
+	if err != nil {
+		t.Fatalf("GetGroupStats() error = %v", err)
+	}
+	wantDecks := []GroupStats{
+		{Name: "grammar", Cards: 2, Mature: 1},
+		{Name: "vocab", Cards: 3, New: 1, Mature: 1, Due: 2, Answers: 3, Recalls: 2, Recalled: 1},
+	}
+	if !reflect.DeepEqual(decks, wantDecks) {
+		t.Errorf("GetGroupStats(deck) = %+v, want %+v", decks, wantDecks)
+	}
+	files, err := store.GetGroupStats(GroupByFile, now.AddDate(0, 0, -30), 0)
+	if err != nil || len(files) != 2 || files[1].Name != "/b.md" || files[1].Answers != 1 {
+		t.Errorf("GetGroupStats(file) = %+v, %v", files, err)
+	}
+	if _, err := store.GetGroupStats("question", now, 0); err == nil {
+		t.Error("Expected an error grouping by an unknown column")
+	}
+}
+
+// createCards saves cards with their schedule and states, setting their ids
+func createCards(t *testing.T, store *Store, cards []Flashcard) {
+	t.Helper()
+	for i := range cards {
+		id, err := store.CreateFlashcard(cards[i])
+		if err != nil {
+			t.Fatalf("CreateFlashcard() error = %v", err)
+		}
+		cards[i].ID = id
+		if err := store.UpdateFlashcard(cards[i]); err != nil {
+			t.Fatalf("UpdateFlashcard() error = %v", err)
+		}
+		if err := store.SetSuspended(id, cards[i].Suspended); err != nil {
+			t.Fatalf("SetSuspended() error = %v", err)
+		}
+		if err := store.SetFlagged(id, cards[i].Flagged); err != nil {
+			t.Fatalf("SetFlagged() error = %v", err)
+		}
+	}
+}
+
+func TestSessionTotals(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	id, err := store.StartSession("classic", []int{1, 2})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	cram, err := store.StartCramSession("typed")
+	if err != nil {
+		t.Fatalf("StartCramSession() error = %v", err)
+	}
+	if session, _ := store.GetUnfinishedSession(); session == nil || session.ID != id {
+		t.Errorf("Expected the review, not the cram session, to be resumable, got %+v", session)
+	}
+	for _, add := range []struct {
+		id, answers int
+		spent       time.Duration
+	}{{id, 1, 3 * time.Second}, {id, 1, 2 * time.Second}, {id, -1, -2 * time.Second}, {cram, 2, time.Second}} {
+		if err := store.AddSessionAnswers(add.id, add.answers, add.spent); err != nil {
+			t.Fatalf("AddSessionAnswers() error = %v", err)
+		}
+	}
+	var answers int
+	var ms int64
+	if err := store.DB.QueryRow("SELECT answers, duration_ms FROM review_sessions WHERE id = ?", id).Scan(&answers, &ms); err != nil {
+		t.Fatalf("Failed to read session totals: %v", err)
+	}
+	if answers != 1 || ms != 3000 {
+		t.Errorf("Expected the undone answer taken back, got %d answers in %dms", answers, ms)
+	}
+}
+
+func TestGetStudyDays(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	// A session started yesterday and resumed today counts today's answers today
+	session, err := store.StartSession("classic", []int{1, 2})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	if _, err := store.DB.Exec("UPDATE review_sessions SET started_at = ? WHERE id = ?", formatTime(yesterday), session); err != nil {
+		t.Fatalf("Failed to move the session back: %v", err)
+	}
+	for _, e := range []ReviewLog{
+		{FlashcardID: 1, SessionID: session, ReviewedAt: yesterday, Duration: 2 * time.Second},
+		{FlashcardID: 2, SessionID: session, ReviewedAt: now, Duration: 3 * time.Second},
+		{FlashcardID: 1, Cram: true, ReviewedAt: now, Duration: time.Second},
+	} {
+		if _, err := store.LogReview(e); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+
+	days, err := store.GetStudyDays(yesterday.Add(-time.Hour), DayOffset(now, 0))
+	if err != nil {
+		t.Fatalf("GetStudyDays() error = %v", err)
+	}
+	want := []StudyDay{
+		{Day: yesterday.Format("2006-01-02"), Answers: 1, Time: 2 * time.Second},
+		{Day: now.Format("2006-01-02"), Answers: 2, Time: 4 * time.Second},
+	}
+	if !reflect.DeepEqual(days, want) {
+		t.Errorf("GetStudyDays() = %+v, want %+v", days, want)
+	}
+}
+
+func TestNewStoreBackfillsSessionTotals(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	id, _ := store.StartSession("classic", []int{1})
+	for _, ms := range []time.Duration{time.Second, 2 * time.Second} {
+		if _, err := store.LogReview(ReviewLog{FlashcardID: 1, SessionID: id, Duration: ms}); err != nil {
+			t.Fatalf("LogReview() error = %v", err)
+		}
+	}
+	// Drop the totals as kept before they were recorded
+	for _, stmt := range []string{
+		"CREATE TABLE old_sessions AS SELECT id, started_at, finished_at, mode, queue FROM review_sessions",
+		"DROP TABLE review_sessions",
+		"ALTER TABLE old_sessions RENAME TO review_sessions",
+	} {
+		if _, err := store.DB.Exec(stmt); err != nil {
+			t.Fatalf("Failed to restore the old schema: %v", err)
+		}
+	}
+	store.Close()
+
+	store, err = NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	defer store.Close()
+	var answers, ms int
+	if err := store.DB.QueryRow("SELECT answers, duration_ms FROM review_sessions WHERE id = ?", id).Scan(&answers, &ms); err != nil {
+		t.Fatalf("Failed to read session totals: %v", err)
+	}
+	if answers != 2 || ms != 3000 {
+		t.Errorf("Expected totals backfilled from the review log, got %d answers in %dms", answers, ms)
+	}
+}
+
+func TestGenerationHash(t *testing.T) {
+	store := setupTestDB(t)
+	defer store.Close()
+
+	if hash, err := store.GetGenerationHash("/notes/a.md"); err != nil || hash != "" {
+		t.Fatalf("GetGenerationHash() = %q, %v, want none for a new note", hash, err)
+	}
+	for _, hash := range []string{"abc", "def"} {
+		if err := store.SetGenerationHash("/notes/a.md", hash); err != nil {
+			t.Fatalf("SetGenerationHash() error = %v", err)
+		}
+	}
+	if hash, _ := store.GetGenerationHash("/notes/a.md"); hash != "def" {
+		t.Errorf("GetGenerationHash() = %q, want the latest hash", hash)
+	}
+	if hash, _ := store.GetGenerationHash("/notes/b.md"); hash != "" {
+		t.Errorf("GetGenerationHash() = %q for another note", hash)
+	}
+}
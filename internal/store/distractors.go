@@ -0,0 +1,85 @@
+package store
+
+import (
+	"fmt"
+)
+
+// createDistractorsTable caches the wrong options used by multiple-choice
+// review, so they are only generated once per card
+const createDistractorsTable = `CREATE TABLE IF NOT EXISTS distractors (
+			  id INTEGER PRIMARY KEY AUTOINCREMENT,
+			  flashcard_id INTEGER NOT NULL,
+			  text TEXT NOT NULL
+		  );
+		  CREATE INDEX IF NOT EXISTS idx_distractors_flashcard ON distractors(flashcard_id);`
+
+// GetDistractors returns the cached distractors of a flashcard
+func (s *Store) GetDistractors(flashcardID int) ([]string, error) {
+	rows, err := s.DB.Query("SELECT text FROM distractors WHERE flashcard_id = ? ORDER BY id ASC", flashcardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query distractors: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []string
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, fmt.Errorf("failed to scan distractor: %w", err)
+		}
+		result = append(result, text)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating distractors: %w", err)
+	}
+	return result, nil
+}
+
+// SaveDistractors replaces the cached distractors of a flashcard
+func (s *Store) SaveDistractors(flashcardID int, distractors []string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.Exec("DELETE FROM distractors WHERE flashcard_id = ?", flashcardID); err != nil {
+		return fmt.Errorf("failed to clear distractors: %w", err)
+	}
+	for _, d := range distractors {
+		if _, err := tx.Exec("INSERT INTO distractors (flashcard_id, text) VALUES (?, ?)", flashcardID, d); err != nil {
+			return fmt.Errorf("failed to insert distractor: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDeckAnswers returns the distinct answers of the other cards in fc's deck,
+// used as offline distractors for multiple-choice review
+func (s *Store) GetDeckAnswers(fc Flashcard) ([]string, error) {
+	rows, err := s.DB.Query(`SELECT DISTINCT answer FROM flashcards
+			  WHERE deck = ? AND card_type = ? AND id != ? AND answer != ?`, fc.Deck, fc.Type.orDefault(), fc.ID, fc.Answer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deck answers: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []string
+	for rows.Next() {
+		var answer string
+		if err := rows.Scan(&answer); err != nil {
+			return nil, fmt.Errorf("failed to scan answer: %w", err)
+		}
+		result = append(result, answer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating answers: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Filter selects flashcards regardless of their schedule, for cram sessions.
+// Empty fields match every card.
+type Filter struct {
+	Deck        string
+	File        string
+	Tag         string
+	FailedSince time.Time // answered incorrectly at least once since then
+}
+
+// GetFlashcardsMatching returns the flashcards matching every field of the
+// filter, due or not, oldest first. Suspended cards are left out.
+func (s *Store) GetFlashcardsMatching(f Filter) ([]Flashcard, error) {
+	conditions := []string{notSuspended}
+	var args []any
+	if f.Deck != "" {
+		conditions = append(conditions, "deck = ?")
+		args = append(args, f.Deck)
+	}
+	if f.File != "" {
+		conditions = append(conditions, "file = ?")
+		args = append(args, f.File)
+	}
+	if f.Tag != "" {
+		// Tags are stored comma separated, pad them so only whole tags match
+		conditions = append(conditions, "instr(',' || tags || ',', ?) > 0")
+		args = append(args, ","+f.Tag+",")
+	}
+	if !f.FailedSince.IsZero() {
+		conditions = append(conditions, "id IN (SELECT flashcard_id FROM review_log WHERE correct = 0 AND reviewed_at >= ?)")
+		args = append(args, formatTime(f.FailedSince))
+	}
+	query := "SELECT " + flashcardColumns + " FROM flashcards WHERE " + strings.Join(conditions, " AND ") + " ORDER BY id ASC"
+
+	// #nosec G202 -- conditions are constants, values are passed as arguments
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flashcards: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var flashcards []Flashcard
+	for rows.Next() {
+		fc, err := scanFlashcard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan flashcard: %w", err)
+		}
+		flashcards = append(flashcards, fc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating flashcards: %w", err)
+	}
+	return flashcards, nil
+}
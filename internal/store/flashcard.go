@@ -1,11 +1,145 @@
 // Package store provides data persistence for flashcards using SQLite
 package store
 
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"catv/internal/notes"
+)
+
+// CardType identifies how a flashcard is presented during review
+type CardType string
+
+const (
+	// CardTypeBasic shows the question and reveals the answer
+	CardTypeBasic CardType = "basic"
+	// CardTypeCloze shows a text with one deletion blanked out and reveals it
+	CardTypeCloze CardType = "cloze"
+)
+
+// orDefault returns the basic type for flashcards created without a type
+func (t CardType) orDefault() CardType {
+	if t == "" {
+		return CardTypeBasic
+	}
+	return t
+}
+
 // Flashcard represents a single flashcard with spaced repetition metadata
 type Flashcard struct {
-	ID        int    // Unique identifier for the flashcard
-	File      string // Source file path where the flashcard was generated from
-	Question  string // The question/text to be reviewed
-	Answer    string // The answer/explanation for the question
-	RevisitIn int    // Number of days until next review (<=0 means due for review)
+	ID          int       // Unique identifier for the flashcard
+	File        string    // Source file path where the flashcard was generated from
+	Question    string    // The question/text to be reviewed
+	Answer      string    // The answer/explanation for the question
+	RevisitIn   int       // Number of days until next review (<=0 means due for review)
+	Deck        string    // Deck the flashcard belongs to (empty for the default deck)
+	Tags        []string  // Free-form labels used for filtering
+	SourceHash  string    // Hash of the note text for inline cards (empty for generated cards)
+	Type        CardType  // Presentation type, basic or cloze
+	Ordinal     int       // Cloze deletion number shown as a blank, 0 for basic cards
+	SiblingOf   int       // Id of the card this one reverses, 0 for original cards
+	BuriedUntil time.Time // Hidden from review until this time (zero when not buried)
+	DueAt       time.Time // Next review time (zero for cards never scheduled)
+	Lapses      int       // Times the card was forgotten after being learned
+	Suspended   bool      // Left out of review until unsuspended
+	Flagged     bool      // Marked for attention, such as a wrong or unclear card
+}
+
+// IsNew reports whether the flashcard was never scheduled, so failing it is
+// part of learning it rather than a lapse
+func (fc Flashcard) IsNew() bool {
+	return fc.DueAt.IsZero()
+}
+
+// IsBuried reports whether the flashcard is hidden from review at now
+func (fc Flashcard) IsBuried(now time.Time) bool {
+	return fc.BuriedUntil.After(now)
+}
+
+// IsCloze reports whether the flashcard is a cloze deletion card
+func (fc Flashcard) IsCloze() bool {
+	return fc.Type == CardTypeCloze
+}
+
+// JoinTags serializes tags for storage as a comma separated list
+func JoinTags(tags []string) string {
+	cleaned := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			cleaned = append(cleaned, t)
+		}
+	}
+	return strings.Join(cleaned, ",")
+}
+
+// SplitTags parses a comma separated tag list as stored in the database
+func SplitTags(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// SiblingKey identifies the group of cards generated from the same note text.
+// A card and its reverse share a key, as do the cloze deletions of one text.
+func (fc Flashcard) SiblingKey() string {
+	switch {
+	case fc.IsCloze():
+		return "cloze:" + fc.File + "\x00" + fc.Question
+	case fc.SiblingOf > 0:
+		return "card:" + strconv.Itoa(fc.SiblingOf)
+	default:
+		return "card:" + strconv.Itoa(fc.ID)
+	}
+}
+
+// FollowEdit returns fc, a sibling of edited, with its text following the
+// edit: a cloze deletion gets the edited text and its answer from it, while a
+// card and its reverse keep their question and answer swapped
+func (fc Flashcard) FollowEdit(edited Flashcard) Flashcard {
+	if fc.IsCloze() {
+		fc.Question, fc.Answer = edited.Question, notes.ClozeAnswer(edited.Question, fc.Ordinal)
+	} else {
+		fc.Question, fc.Answer = edited.Answer, edited.Question
+	}
+	return fc
+}
+
+// Reverse returns a new basic card with question and answer swapped that is
+// linked to fc as its sibling
+func (fc Flashcard) Reverse() Flashcard {
+	return Flashcard{
+		File:      fc.File,
+		Question:  fc.Answer,
+		Answer:    fc.Question,
+		RevisitIn: 0, // Due immediately, scheduled independently of the original
+		Deck:      fc.Deck,
+		Tags:      fc.Tags,
+		Type:      CardTypeBasic,
+		SiblingOf: fc.ID,
+	}
+}
+
+// DropSiblings keeps only the first card of every sibling group so a card and
+// its siblings are never reviewed in the same session
+func DropSiblings(flashcards []Flashcard) []Flashcard {
+	seen := make(map[string]bool, len(flashcards))
+	result := make([]Flashcard, 0, len(flashcards))
+	for _, fc := range flashcards {
+		key := fc.SiblingKey()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, fc)
+	}
+	return result
 }
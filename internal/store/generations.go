@@ -0,0 +1,42 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// createNoteGenerationsTable records the content each note's model-generated
+// flashcards came from, so an unchanged note is not sent to the model again
+const createNoteGenerationsTable = `CREATE TABLE IF NOT EXISTS note_generations (
+			  file TEXT PRIMARY KEY,
+			  content_hash TEXT NOT NULL,
+			  generated_at DATETIME NOT NULL
+		  );`
+
+// GetGenerationHash returns the content hash the model last generated a
+// file's flashcards from, empty when it never did
+func (s *Store) GetGenerationHash(filePath string) (string, error) {
+	var hash string
+	err := s.DB.QueryRow("SELECT content_hash FROM note_generations WHERE file = ?", filePath).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query generation of %s: %w", filePath, err)
+	}
+	return hash, nil
+}
+
+// SetGenerationHash records the content hash the model generated a file's
+// flashcards from
+func (s *Store) SetGenerationHash(filePath, hash string) error {
+	_, err := s.DB.Exec(`INSERT INTO note_generations (file, content_hash, generated_at) VALUES (?, ?, ?)
+			  ON CONFLICT(file) DO UPDATE SET content_hash = excluded.content_hash, generated_at = excluded.generated_at`,
+		filePath, hash, formatTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to record generation of %s: %w", filePath, err)
+	}
+	return nil
+}
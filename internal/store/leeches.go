@@ -0,0 +1,135 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+)
+
+// LeechTag is added to cards forgotten too many times
+const LeechTag = "leech"
+
+// createRetiredHashesTable records the source hashes of inline cards replaced
+// as leeches, so syncing their note does not bring them back
+const createRetiredHashesTable = `CREATE TABLE IF NOT EXISTS retired_hashes (
+			  file TEXT NOT NULL,
+			  source_hash TEXT NOT NULL,
+			  PRIMARY KEY (file, source_hash)
+		  );`
+
+// MarkLeech tags a flashcard as a leech, and suspends it when asked
+func (s *Store) MarkLeech(id int, suspend bool) error {
+	fc, err := s.GetFlashcard(id)
+	if err != nil {
+		return fmt.Errorf("failed to load flashcard %d: %w", id, err)
+	}
+	if !slices.Contains(fc.Tags, LeechTag) {
+		fc.Tags = append(fc.Tags, LeechTag)
+	}
+	if _, err := s.DB.Exec("UPDATE flashcards SET tags = ?, suspended = (suspended OR ?) WHERE id = ?", JoinTags(fc.Tags), suspend, id); err != nil {
+		return fmt.Errorf("failed to mark flashcard %d as a leech: %w", id, err)
+	}
+	return nil
+}
+
+// UnmarkLeech reverts MarkLeech, unsuspending the card when asked
+func (s *Store) UnmarkLeech(id int, unsuspend bool) error {
+	fc, err := s.GetFlashcard(id)
+	if err != nil {
+		return fmt.Errorf("failed to load flashcard %d: %w", id, err)
+	}
+	tags := slices.DeleteFunc(fc.Tags, func(t string) bool { return t == LeechTag })
+	if _, err := s.DB.Exec("UPDATE flashcards SET tags = ?, suspended = (suspended AND NOT ?) WHERE id = ?", JoinTags(tags), unsuspend, id); err != nil {
+		return fmt.Errorf("failed to unmark leech %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetLeeches returns the flashcards tagged as leeches, most forgotten first
+func (s *Store) GetLeeches() ([]Flashcard, error) {
+	rows, err := s.DB.Query("SELECT "+flashcardColumns+" FROM flashcards WHERE instr(',' || tags || ',', ?) > 0 ORDER BY lapses DESC, id ASC", ","+LeechTag+",")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leeches: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var leeches []Flashcard
+	for rows.Next() {
+		fc, err := scanFlashcard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan flashcard: %w", err)
+		}
+		leeches = append(leeches, fc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leeches: %w", err)
+	}
+	return leeches, nil
+}
+
+// ReplaceFlashcard deletes a flashcard and its reverse siblings and creates
+// the given cards in their place, all at once. The source hashes of deleted
+// inline cards are retired so they are not imported again. The review
+// history of the deleted cards is kept.
+func (s *Store) ReplaceFlashcard(id int, replacements []Flashcard) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	for _, fc := range replacements {
+		if _, err := tx.Exec("INSERT INTO flashcards (file, question, answer, revisitin, deck, tags, source_hash, card_type, cloze_ordinal, sibling_of) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			fc.File, fc.Question, fc.Answer, fc.RevisitIn, fc.Deck, JoinTags(fc.Tags), fc.SourceHash, fc.Type.orDefault(), fc.Ordinal, fc.SiblingOf); err != nil {
+			return fmt.Errorf("failed to insert replacement flashcard: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO retired_hashes (file, source_hash)
+			  SELECT file, source_hash FROM flashcards WHERE (id = ? OR sibling_of = ?) AND source_hash != ''`, id, id); err != nil {
+		return fmt.Errorf("failed to retire source of flashcard %d: %w", id, err)
+	}
+	if _, err := tx.Exec("DELETE FROM distractors WHERE flashcard_id IN (SELECT id FROM flashcards WHERE id = ? OR sibling_of = ?)", id, id); err != nil {
+		return fmt.Errorf("failed to delete distractors of flashcard %d: %w", id, err)
+	}
+	if _, err := tx.Exec("DELETE FROM flashcards WHERE id = ? OR sibling_of = ?", id, id); err != nil {
+		return fmt.Errorf("failed to delete flashcard %d: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to replace flashcard %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetRetiredHashes returns the retired source hashes of a file's inline cards
+func (s *Store) GetRetiredHashes(filePath string) (map[string]bool, error) {
+	rows, err := s.DB.Query("SELECT source_hash FROM retired_hashes WHERE file = ?", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retired hashes: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	retired := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan retired hash: %w", err)
+		}
+		retired[hash] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating retired hashes: %w", err)
+	}
+	return retired, nil
+}
+
+// UnretireHash forgets a retired source hash, once the card is gone from its note
+func (s *Store) UnretireHash(filePath, hash string) error {
+	if _, err := s.DB.Exec("DELETE FROM retired_hashes WHERE file = ? AND source_hash = ?", filePath, hash); err != nil {
+		return fmt.Errorf("failed to unretire hash of %s: %w", filePath, err)
+	}
+	return nil
+}
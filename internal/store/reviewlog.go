@@ -0,0 +1,130 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// createReviewLogTable records every graded review, one row per card answered
+const createReviewLogTable = `CREATE TABLE IF NOT EXISTS review_log (
+			  id INTEGER PRIMARY KEY AUTOINCREMENT,
+			  flashcard_id INTEGER NOT NULL,
+			  reviewed_at DATETIME NOT NULL,
+			  correct INTEGER NOT NULL DEFAULT 0,
+			  revisit_in INTEGER NOT NULL DEFAULT 0,
+			  hints INTEGER NOT NULL DEFAULT 0,
+			  mode TEXT NOT NULL DEFAULT ''
+		  );
+		  CREATE INDEX IF NOT EXISTS idx_review_log_flashcard ON review_log(flashcard_id);
+		  CREATE INDEX IF NOT EXISTS idx_review_log_reviewed_at ON review_log(reviewed_at);`
+
+// ReviewLog is the record of one graded review of a flashcard
+type ReviewLog struct {
+	ID          int
+	FlashcardID int
+	ReviewedAt  time.Time
+	Correct     bool
+	RevisitIn   int           // days until the next review chosen for the card
+	Hints       int           // number of hints revealed before answering
+	Mode        string        // review mode: classic, mc or typed
+	TimedOut    bool          // whether the answer timer ran out before answering
+	SessionID   int           // review session the card was graded in, 0 if none
+	WasNew      bool          // whether the card had never been reviewed before
+	Cram        bool          // reviewed in a cram session, which leaves the schedule unchanged
+	Duration    time.Duration // time spent answering, 0 when unknown
+}
+
+// LogReview records a graded review and returns its id
+func (s *Store) LogReview(entry ReviewLog) (int, error) {
+	if entry.ReviewedAt.IsZero() {
+		entry.ReviewedAt = time.Now()
+	}
+	res, err := s.DB.Exec("INSERT INTO review_log (flashcard_id, reviewed_at, correct, revisit_in, hints, mode, timed_out, session_id, was_new, cram, duration_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.FlashcardID, formatTime(entry.ReviewedAt), entry.Correct, entry.RevisitIn, entry.Hints, entry.Mode, entry.TimedOut, entry.SessionID, entry.WasNew, entry.Cram, entry.Duration.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to log review: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get review log id: %w", err)
+	}
+	return int(id), nil
+}
+
+// DeleteReviewLog removes a review, used when its grade is undone
+func (s *Store) DeleteReviewLog(id int) error {
+	if _, err := s.DB.Exec("DELETE FROM review_log WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete review log %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetReviewLogs returns the review history of a flashcard, oldest first
+func (s *Store) GetReviewLogs(flashcardID int) ([]ReviewLog, error) {
+	rows, err := s.DB.Query(`SELECT id, flashcard_id, reviewed_at, correct, revisit_in, hints, mode, timed_out, session_id, was_new, cram, duration_ms
+			  FROM review_log WHERE flashcard_id = ? ORDER BY reviewed_at ASC, id ASC`, flashcardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review log: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var logs []ReviewLog
+	for rows.Next() {
+		var entry ReviewLog
+		var ms int64
+		if err := rows.Scan(&entry.ID, &entry.FlashcardID, &entry.ReviewedAt, &entry.Correct, &entry.RevisitIn, &entry.Hints, &entry.Mode, &entry.TimedOut, &entry.SessionID, &entry.WasNew, &entry.Cram, &ms); err != nil {
+			return nil, fmt.Errorf("failed to scan review log: %w", err)
+		}
+		entry.Duration = time.Duration(ms) * time.Millisecond
+		logs = append(logs, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review log: %w", err)
+	}
+	return logs, nil
+}
+
+// DayCounts is the number of cards of a deck reviewed in a day
+type DayCounts struct {
+	New     int // cards seen for the first time
+	Reviews int // cards that had been reviewed before
+}
+
+// GetDayCounts returns the cards reviewed since the given time by deck. A card
+// counts once however many times it was answered, as new if it was new the
+// first time. Cram reviews are not counted.
+func (s *Store) GetDayCounts(since time.Time) (map[string]DayCounts, error) {
+	rows, err := s.DB.Query(`SELECT f.deck, MAX(l.was_new)
+			  FROM review_log l JOIN flashcards f ON f.id = l.flashcard_id
+			  WHERE l.reviewed_at >= ? AND l.cram = 0 GROUP BY l.flashcard_id`, formatTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query day counts: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	counts := make(map[string]DayCounts)
+	for rows.Next() {
+		var (
+			deck   string
+			wasNew bool
+		)
+		if err := rows.Scan(&deck, &wasNew); err != nil {
+			return nil, fmt.Errorf("failed to scan day counts: %w", err)
+		}
+		c := counts[deck]
+		if wasNew {
+			c.New++
+		} else {
+			c.Reviews++
+		}
+		counts[deck] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating day counts: %w", err)
+	}
+	return counts, nil
+}
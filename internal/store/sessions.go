@@ -0,0 +1,222 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// createReviewSessionsTable records review sessions so an interrupted one can
+// be resumed
+const createReviewSessionsTable = `CREATE TABLE IF NOT EXISTS review_sessions (
+			  id INTEGER PRIMARY KEY AUTOINCREMENT,
+			  started_at DATETIME NOT NULL,
+			  finished_at DATETIME,
+			  mode TEXT NOT NULL DEFAULT '',
+			  queue TEXT NOT NULL DEFAULT ''
+		  );`
+
+// reviewSessionColumnMigrations lists review_sessions columns added after the
+// table was introduced
+var reviewSessionColumnMigrations = []columnMigration{
+	{"answers", "INTEGER NOT NULL DEFAULT 0"},
+	{"duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"cram", "INTEGER NOT NULL DEFAULT 0"},
+	{"queued_after", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// backfillSessionTotals sets the totals of sessions from their logged reviews
+func backfillSessionTotals(db *sql.DB) error {
+	_, err := db.Exec(`UPDATE review_sessions SET
+			  answers = (SELECT COUNT(*) FROM review_log WHERE session_id = review_sessions.id),
+			  duration_ms = (SELECT COALESCE(SUM(duration_ms), 0) FROM review_log WHERE session_id = review_sessions.id)`)
+	if err != nil {
+		return fmt.Errorf("failed to backfill review session totals: %w", err)
+	}
+	return nil
+}
+
+// ReviewSession is a review of a queue of flashcards. It stays unfinished
+// when the review is interrupted.
+type ReviewSession struct {
+	ID        int
+	StartedAt time.Time
+	Mode      string
+	Queue     []int // flashcard ids in review order
+	// QueuedAfter is the last review logged when the queue was saved, the
+	// reviews before it do not count against the queue
+	QueuedAfter int
+}
+
+// StartSession records the start of a review of queue
+func (s *Store) StartSession(mode string, queue []int) (int, error) {
+	ids := make([]string, len(queue))
+	for i, id := range queue {
+		ids[i] = strconv.Itoa(id)
+	}
+	res, err := s.DB.Exec("INSERT INTO review_sessions (started_at, mode, queue) VALUES (?, ?, ?)",
+		formatTime(time.Now()), mode, strings.Join(ids, ","))
+	if err != nil {
+		return 0, fmt.Errorf("failed to start review session: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get review session id: %w", err)
+	}
+	return int(id), nil
+}
+
+// StartCramSession records the start of a cram session. It keeps the time
+// spent studying but is never resumed.
+func (s *Store) StartCramSession(mode string) (int, error) {
+	res, err := s.DB.Exec("INSERT INTO review_sessions (started_at, mode, cram) VALUES (?, ?, 1)", formatTime(time.Now()), mode)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start cram session: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cram session id: %w", err)
+	}
+	return int(id), nil
+}
+
+// AddSessionAnswers adds answers and the time spent on them to the totals of
+// a session, negative values taking back undone answers
+func (s *Store) AddSessionAnswers(id, answers int, spent time.Duration) error {
+	_, err := s.DB.Exec("UPDATE review_sessions SET answers = answers + ?, duration_ms = duration_ms + ? WHERE id = ?",
+		answers, spent.Milliseconds(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update review session %d: %w", id, err)
+	}
+	return nil
+}
+
+// SetSessionQueue replaces the queue of a session with the cards left when
+// it was stopped, graded cards asked again included
+func (s *Store) SetSessionQueue(id int, queue []int) error {
+	ids := make([]string, len(queue))
+	for i, fc := range queue {
+		ids[i] = strconv.Itoa(fc)
+	}
+	_, err := s.DB.Exec(`UPDATE review_sessions SET queue = ?,
+			  queued_after = (SELECT COALESCE(MAX(id), 0) FROM review_log) WHERE id = ?`,
+		strings.Join(ids, ","), id)
+	if err != nil {
+		return fmt.Errorf("failed to save review session %d queue: %w", id, err)
+	}
+	return nil
+}
+
+// FinishSession marks a session as finished, it will not be offered for resuming
+func (s *Store) FinishSession(id int) error {
+	if _, err := s.DB.Exec("UPDATE review_sessions SET finished_at = ? WHERE id = ?", formatTime(time.Now()), id); err != nil {
+		return fmt.Errorf("failed to finish review session %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetUnfinishedSession returns the latest session that was interrupted, nil
+// when there is none
+func (s *Store) GetUnfinishedSession() (*ReviewSession, error) {
+	var (
+		session ReviewSession
+		queue   string
+	)
+	row := s.DB.QueryRow("SELECT id, started_at, mode, queue, queued_after FROM review_sessions WHERE finished_at IS NULL AND cram = 0 ORDER BY id DESC LIMIT 1")
+	if err := row.Scan(&session.ID, &session.StartedAt, &session.Mode, &queue, &session.QueuedAfter); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get unfinished review session: %w", err)
+	}
+	for _, field := range strings.Split(queue, ",") {
+		if id, err := strconv.Atoi(field); err == nil {
+			session.Queue = append(session.Queue, id)
+		}
+	}
+	return &session, nil
+}
+
+// GetSessionRemaining returns the flashcards of a session's queue that were
+// not graded in it since the queue was saved, in review order. Cards deleted, suspended or buried
+// since are skipped.
+func (s *Store) GetSessionRemaining(session *ReviewSession) ([]Flashcard, error) {
+	graded := make(map[int]bool)
+	rows, err := s.DB.Query("SELECT flashcard_id FROM review_log WHERE session_id = ? AND id > ?", session.ID, session.QueuedAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session reviews: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session review: %w", err)
+		}
+		graded[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session reviews: %w", err)
+	}
+
+	var remaining []Flashcard
+	for _, id := range session.Queue {
+		if graded[id] {
+			continue
+		}
+		fc, err := s.GetFlashcard(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fc.Suspended || fc.IsBuried(time.Now()) {
+			continue
+		}
+		remaining = append(remaining, fc)
+	}
+	return remaining, nil
+}
+
+// StudyDay is the time spent studying on a day
+type StudyDay struct {
+	Day     string // local date, 2006-01-02
+	Answers int
+	Time    time.Duration
+}
+
+// GetStudyDays sums up the answers given each day since the given time, cram
+// included, oldest first. Answers count on the day they were given, whenever
+// their session started. Days without answers are left out. offset is the
+// shift of review days from UTC days, see DayOffset.
+func (s *Store) GetStudyDays(since time.Time, offset time.Duration) ([]StudyDay, error) {
+	rows, err := s.DB.Query(`SELECT date(reviewed_at, ?) AS day, COUNT(*), SUM(duration_ms)
+			  FROM review_log WHERE reviewed_at >= ?
+			  GROUP BY day ORDER BY day ASC`, dayShift(offset), formatTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query study days: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var days []StudyDay
+	for rows.Next() {
+		var d StudyDay
+		var ms int64
+		if err := rows.Scan(&d.Day, &d.Answers, &ms); err != nil {
+			return nil, fmt.Errorf("failed to scan study days: %w", err)
+		}
+		d.Time = time.Duration(ms) * time.Millisecond
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating study days: %w", err)
+	}
+	return days, nil
+}
@@ -0,0 +1,136 @@
+// Package store provides data persistence for flashcards using SQLite
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrReverseNotAllowed is returned when a card cannot get a reverse sibling
+var ErrReverseNotAllowed = errors.New("only basic cards that are not reverses themselves can be reversed")
+
+// ErrReverseExists is returned when a card already has a reverse sibling
+var ErrReverseExists = errors.New("flashcard already has a reverse card")
+
+// GetFlashcard returns a single flashcard by id
+func (s *Store) GetFlashcard(id int) (Flashcard, error) {
+	row := s.DB.QueryRow("SELECT "+flashcardColumns+" FROM flashcards WHERE id = ?", id)
+	fc, err := scanFlashcard(row)
+	if err != nil {
+		return fc, fmt.Errorf("failed to get flashcard %d: %w", id, err)
+	}
+	return fc, nil
+}
+
+// HasReverse checks if a flashcard already has a reverse sibling
+func (s *Store) HasReverse(id int) (bool, error) {
+	var count int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM flashcards WHERE sibling_of = ?", id).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateReverse adds a reverse sibling for a basic flashcard and returns it
+func (s *Store) CreateReverse(fc Flashcard) (Flashcard, error) {
+	if fc.IsCloze() || fc.SiblingOf > 0 {
+		return Flashcard{}, ErrReverseNotAllowed
+	}
+	exists, err := s.HasReverse(fc.ID)
+	if err != nil {
+		return Flashcard{}, err
+	}
+	if exists {
+		return Flashcard{}, ErrReverseExists
+	}
+
+	reverse := fc.Reverse()
+	id, err := s.CreateFlashcard(reverse)
+	if err != nil {
+		return Flashcard{}, err
+	}
+	reverse.ID = id
+	return reverse, nil
+}
+
+// BurySiblings hides the other cards of fc's sibling group from review until
+// the given time, so related cards are not shown on the same day
+func (s *Store) BurySiblings(fc Flashcard, until time.Time) error {
+	where, args := siblingsOf(fc)
+	// #nosec G202 -- the condition is built from constants, values are bound
+	if _, err := s.DB.Exec("UPDATE flashcards SET buried_until = ? WHERE "+where, append([]any{formatTime(until)}, args...)...); err != nil {
+		return fmt.Errorf("failed to bury siblings of flashcard %d: %w", fc.ID, err)
+	}
+	return nil
+}
+
+// UnburySiblings reverts BurySiblings, showing the siblings buried until the
+// given time again
+func (s *Store) UnburySiblings(fc Flashcard, until time.Time) error {
+	where, args := siblingsOf(fc)
+	// #nosec G202 -- the condition is built from constants, values are bound
+	if _, err := s.DB.Exec("UPDATE flashcards SET buried_until = NULL WHERE buried_until = ? AND "+where, append([]any{formatTime(until)}, args...)...); err != nil {
+		return fmt.Errorf("failed to unbury siblings of flashcard %d: %w", fc.ID, err)
+	}
+	return nil
+}
+
+// GetSiblings returns the other cards of fc's sibling group
+func (s *Store) GetSiblings(fc Flashcard) ([]Flashcard, error) {
+	where, args := siblingsOf(fc)
+	// #nosec G202 -- the condition is built from constants, values are bound
+	rows, err := s.DB.Query("SELECT "+flashcardColumns+" FROM flashcards WHERE "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query siblings of flashcard %d: %w", fc.ID, err)
+	}
+	defer rows.Close()
+
+	var siblings []Flashcard
+	for rows.Next() {
+		sibling, err := scanFlashcard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan flashcard: %w", err)
+		}
+		siblings = append(siblings, sibling)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating siblings: %w", err)
+	}
+	return siblings, nil
+}
+
+// UpdateTexts saves the question and answer of cards all at once, such as an
+// edited card and its siblings. Their cached distractors are dropped since
+// they may no longer fit.
+func (s *Store) UpdateTexts(cards []Flashcard) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	for _, fc := range cards {
+		if _, err := tx.Exec("UPDATE flashcards SET question = ?, answer = ? WHERE id = ?", fc.Question, fc.Answer, fc.ID); err != nil {
+			return fmt.Errorf("failed to update flashcard %d: %w", fc.ID, err)
+		}
+		if _, err := tx.Exec("DELETE FROM distractors WHERE flashcard_id = ?", fc.ID); err != nil {
+			return fmt.Errorf("failed to delete distractors of flashcard %d: %w", fc.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// siblingsOf returns the condition selecting the other cards of fc's sibling
+// group: deletions of the same cloze text, or a card and its reverse
+func siblingsOf(fc Flashcard) (string, []any) {
+	if fc.IsCloze() {
+		return "card_type = ? AND file = ? AND question = ? AND id != ?", []any{CardTypeCloze, fc.File, fc.Question, fc.ID}
+	}
+	group := fc.ID
+	if fc.SiblingOf > 0 {
+		group = fc.SiblingOf
+	}
+	return "(id = ? OR sibling_of = ?) AND id != ?", []any{group, group, fc.ID}
+}
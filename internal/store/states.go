@@ -0,0 +1,31 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetSuspended takes a flashcard out of review, or puts it back
+func (s *Store) SetSuspended(id int, suspended bool) error {
+	if _, err := s.DB.Exec("UPDATE flashcards SET suspended = ? WHERE id = ?", suspended, id); err != nil {
+		return fmt.Errorf("failed to suspend flashcard %d: %w", id, err)
+	}
+	return nil
+}
+
+// SetFlagged marks a flashcard for attention, or clears the mark
+func (s *Store) SetFlagged(id int, flagged bool) error {
+	if _, err := s.DB.Exec("UPDATE flashcards SET flagged = ? WHERE id = ?", flagged, id); err != nil {
+		return fmt.Errorf("failed to flag flashcard %d: %w", id, err)
+	}
+	return nil
+}
+
+// Bury hides a flashcard from review until the given time, a zero time shows
+// it again
+func (s *Store) Bury(id int, until time.Time) error {
+	if _, err := s.DB.Exec("UPDATE flashcards SET buried_until = ? WHERE id = ?", nullTime(until), id); err != nil {
+		return fmt.Errorf("failed to bury flashcard %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// matureInterval is the interval in days from which a card counts as mature
+const matureInterval = 21
+
+// StateCounts is the number of flashcards in each state. Suspended cards are
+// only counted as suspended.
+type StateCounts struct {
+	New       int // never scheduled
+	Learning  int // failed or being learned, due again the same day
+	Young     int // scheduled less than matureInterval days ahead
+	Mature    int // scheduled matureInterval days ahead or more
+	Suspended int
+	Due       int // due now, buried cards excluded
+	Leeches   int
+	Flagged   int
+}
+
+// Total is the number of flashcards
+func (c StateCounts) Total() int {
+	return c.New + c.Learning + c.Young + c.Mature + c.Suspended
+}
+
+// GetStateCounts counts the flashcards in each state
+func (s *Store) GetStateCounts() (StateCounts, error) {
+	var c StateCounts
+	err := s.DB.QueryRow(`SELECT
+			  COALESCE(SUM(suspended = 0 AND due_at IS NULL), 0),
+			  COALESCE(SUM(suspended = 0 AND due_at IS NOT NULL AND revisitin <= 0), 0),
+			  COALESCE(SUM(suspended = 0 AND due_at IS NOT NULL AND revisitin > 0 AND revisitin < ?), 0),
+			  COALESCE(SUM(suspended = 0 AND due_at IS NOT NULL AND revisitin >= ?), 0),
+			  COALESCE(SUM(suspended), 0),
+			  COALESCE(SUM(`+isDue+` AND `+notBuried+` AND `+notSuspended+`), 0),
+			  COALESCE(SUM(instr(',' || tags || ',', ?) > 0), 0),
+			  COALESCE(SUM(flagged), 0)
+			  FROM flashcards`, matureInterval, matureInterval, ","+LeechTag+",").
+		Scan(&c.New, &c.Learning, &c.Young, &c.Mature, &c.Suspended, &c.Due, &c.Leeches, &c.Flagged)
+	if err != nil {
+		return c, fmt.Errorf("failed to count flashcard states: %w", err)
+	}
+	return c, nil
+}
+
+// DayStats sums up the reviews of a day
+type DayStats struct {
+	Day      string // local date, 2006-01-02
+	Answers  int    // grades given, cram included
+	Time     time.Duration
+	Recalls  int // first answers of the day to cards already learned, cram excluded
+	Recalled int // recalls answered correctly
+}
+
+// dayShift returns the SQLite date modifier turning a stored UTC time into
+// the review day it belongs to, days starting at shift after UTC midnight
+func dayShift(shift time.Duration) string {
+	return fmt.Sprintf("%+d seconds", int(shift.Seconds()))
+}
+
+// DayOffset returns the shift of review days from UTC days for the time zone
+// of now, days starting at rolloverHour
+func DayOffset(now time.Time, rolloverHour int) time.Duration {
+	_, offset := now.Zone()
+	return time.Duration(offset)*time.Second - time.Duration(rolloverHour)*time.Hour
+}
+
+// GetDailyStats sums up the reviews of each day since the given time, oldest
+// first. Days without reviews are left out. offset is the shift of review days
+// from UTC days, see DayOffset.
+func (s *Store) GetDailyStats(since time.Time, offset time.Duration) ([]DayStats, error) {
+	shift := dayShift(offset)
+	rows, err := s.DB.Query(`SELECT day, COUNT(*), SUM(duration_ms),
+			  SUM(first AND was_new = 0 AND cram = 0), SUM(first AND was_new = 0 AND cram = 0 AND correct)
+			  FROM (SELECT date(reviewed_at, ?) AS day, duration_ms, was_new, cram, correct,
+			          id = MIN(id) OVER (PARTITION BY flashcard_id, date(reviewed_at, ?)) AS first
+			        FROM review_log WHERE reviewed_at >= ?)
+			  GROUP BY day ORDER BY day ASC`, shift, shift, formatTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily stats: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var days []DayStats
+	for rows.Next() {
+		var d DayStats
+		var ms int64
+		if err := rows.Scan(&d.Day, &d.Answers, &ms, &d.Recalls, &d.Recalled); err != nil {
+			return nil, fmt.Errorf("failed to scan daily stats: %w", err)
+		}
+		d.Time = time.Duration(ms) * time.Millisecond
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily stats: %w", err)
+	}
+	return days, nil
+}
+
+// DueCount is the number of cards due on a day
+type DueCount struct {
+	Day   string // local date, 2006-01-02
+	Cards int
+}
+
+// GetDueForecast counts the scheduled cards due on each day before until,
+// oldest first. Overdue cards are counted on the day they were due. offset is
+// the shift of review days from UTC days, see DayOffset.
+func (s *Store) GetDueForecast(until time.Time, offset time.Duration) ([]DueCount, error) {
+	rows, err := s.DB.Query(`SELECT date(due_at, ?) AS day, COUNT(*)
+			  FROM flashcards WHERE due_at IS NOT NULL AND due_at < ? AND `+notSuspended+`
+			  GROUP BY day ORDER BY day ASC`, dayShift(offset), formatTime(until))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due forecast: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var forecast []DueCount
+	for rows.Next() {
+		var d DueCount
+		if err := rows.Scan(&d.Day, &d.Cards); err != nil {
+			return nil, fmt.Errorf("failed to scan due forecast: %w", err)
+		}
+		forecast = append(forecast, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating due forecast: %w", err)
+	}
+	return forecast, nil
+}
+
+// GroupStats sums up the cards of a deck or a file and their recent reviews
+type GroupStats struct {
+	Name     string
+	Cards    int
+	New      int
+	Mature   int
+	Due      int // due now, buried and suspended cards excluded
+	Answers  int // grades given since the time asked for
+	Recalls  int // first answers of a day to cards already learned, cram excluded
+	Recalled int // recalls answered correctly
+}
+
+// Columns flashcards can be grouped by in GetGroupStats
+const (
+	GroupByDeck = "deck"
+	GroupByFile = "file"
+)
+
+// GetGroupStats sums up the cards grouped by deck or file, GroupByDeck or
+// GroupByFile, with their reviews since the given time. offset is the shift of
+// review days from UTC days, see DayOffset.
+func (s *Store) GetGroupStats(column string, since time.Time, offset time.Duration) ([]GroupStats, error) {
+	if column != GroupByDeck && column != GroupByFile {
+		return nil, fmt.Errorf("cannot group flashcards by %q", column)
+	}
+	shift := dayShift(offset)
+	// #nosec G202 -- column is one of the constants checked above
+	query := `SELECT f.` + column + `, COUNT(*), SUM(f.due_at IS NULL), SUM(f.due_at IS NOT NULL AND f.revisitin >= ?),
+			  COALESCE(SUM(` + isDue + ` AND ` + notBuried + ` AND ` + notSuspended + `), 0),
+			  COALESCE(SUM(r.answers), 0), COALESCE(SUM(r.recalls), 0), COALESCE(SUM(r.recalled), 0)
+			  FROM flashcards f LEFT JOIN (
+			    SELECT flashcard_id, COUNT(*) AS answers,
+			      SUM(first AND was_new = 0 AND cram = 0) AS recalls, SUM(first AND was_new = 0 AND cram = 0 AND correct) AS recalled
+			    FROM (SELECT flashcard_id, was_new, cram, correct,
+			            id = MIN(id) OVER (PARTITION BY flashcard_id, date(reviewed_at, ?)) AS first
+			          FROM review_log WHERE reviewed_at >= ?)
+			    GROUP BY flashcard_id
+			  ) r ON r.flashcard_id = f.id
+			  GROUP BY f.` + column + ` ORDER BY f.` + column + ` ASC`
+	rows, err := s.DB.Query(query, matureInterval, shift, formatTime(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s stats: %w", column, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var groups []GroupStats
+	for rows.Next() {
+		var g GroupStats
+		if err := rows.Scan(&g.Name, &g.Cards, &g.New, &g.Mature, &g.Due, &g.Answers, &g.Recalls, &g.Recalled); err != nil {
+			return nil, fmt.Errorf("failed to scan %s stats: %w", column, err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s stats: %w", column, err)
+	}
+	return groups, nil
+}
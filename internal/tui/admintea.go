@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"catv/internal/scheduler"
 	"catv/internal/store"
 	"catv/internal/tui/components"
 	"catv/internal/tui/keys"
@@ -24,21 +26,53 @@ type adminView int
 
 const (
 	adminList adminView = iota
+	adminDetail
 	adminCreate
 	adminEdit
 	adminConfirmDelete
 	adminConfirmBulkReset
 )
 
+// adminFilter narrows the list to the flashcards in a state
+type adminFilter int
+
+const (
+	filterAll adminFilter = iota
+	filterSuspended
+	filterFlagged
+	filterBuried
+)
+
+var filterNames = []string{"all", "suspended", "flagged", "buried"}
+
+// matches reports whether fc is listed under the filter
+func (f adminFilter) matches(fc store.Flashcard, now time.Time) bool {
+	switch f {
+	case filterSuspended:
+		return fc.Suspended
+	case filterFlagged:
+		return fc.Flagged
+	case filterBuried:
+		return fc.IsBuried(now)
+	}
+	return true
+}
+
 type keyMap struct {
 	Up        key.Binding
 	Down      key.Binding
 	PageUp    key.Binding
 	PageDown  key.Binding
+	View      key.Binding
 	Create    key.Binding
 	Edit      key.Binding
 	Delete    key.Binding
 	BulkReset key.Binding
+	Reverse   key.Binding
+	Suspend   key.Binding
+	Bury      key.Binding
+	Flag      key.Binding
+	Filter    key.Binding
 	Reload    key.Binding
 	Help      key.Binding
 	Quit      key.Binding
@@ -52,7 +86,8 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.PageUp, k.PageDown},
-		{k.Create, k.Edit, k.Delete, k.BulkReset, k.Reload},
+		{k.View, k.Create, k.Edit, k.Delete, k.BulkReset, k.Reverse, k.Reload},
+		{k.Suspend, k.Bury, k.Flag, k.Filter},
 	}
 }
 
@@ -61,10 +96,16 @@ var adminKeys = keyMap{
 	Down:      key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j:", "Move Down")),
 	PageUp:    key.NewBinding(key.WithKeys("pgup", "pageup"), key.WithHelp("pgup:", "Page Up")),
 	PageDown:  key.NewBinding(key.WithKeys("pgdown", "pagedown"), key.WithHelp("pgdown:", "Page Down")),
+	View:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter:", "View")),
 	Create:    key.NewBinding(key.WithKeys("c"), key.WithHelp("c:", "Create")),
 	Edit:      key.NewBinding(key.WithKeys("e"), key.WithHelp("e:", "Edit")),
 	Delete:    key.NewBinding(key.WithKeys("d"), key.WithHelp("d:", "Delete")),
 	BulkReset: key.NewBinding(key.WithKeys("b"), key.WithHelp("b:", "Bulk Reset")),
+	Reverse:   key.NewBinding(key.WithKeys("v"), key.WithHelp("v:", "Add Reverse")),
+	Suspend:   key.NewBinding(key.WithKeys("s"), key.WithHelp("s:", "Suspend/Unsuspend")),
+	Bury:      key.NewBinding(key.WithKeys("z"), key.WithHelp("z:", "Bury/Unbury")),
+	Flag:      key.NewBinding(key.WithKeys("f"), key.WithHelp("f:", "Flag/Unflag")),
+	Filter:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab:", "Filter")),
 	Reload:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r:", "Reload")),
 	Help:      key.NewBinding(key.WithKeys("?"), key.WithHelp("?:", "Toggle Help")),
 	Quit:      key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q:", "Quit")),
@@ -72,8 +113,9 @@ var adminKeys = keyMap{
 }
 
 type AdminModel struct {
-	flashcards []store.Flashcard
+	flashcards []store.Flashcard // cards listed under the filter
 	selected   int
+	filter     adminFilter
 	view       adminView
 	width      int
 	height     int
@@ -90,10 +132,31 @@ type AdminModel struct {
 
 	status components.StatusMessage
 
-	storeRef *store.Store
+	storeRef     *store.Store
+	rolloverHour int  // hour buried cards come back at the next day
+	plain        bool // cards are shown as written, without rendering their markdown
 }
 
-func NewAdminModel(storeRef *store.Store, flashcards []store.Flashcard) *AdminModel {
+// AdminOption configures an AdminModel
+type AdminOption func(*AdminModel)
+
+// WithRolloverHour buries cards until the given hour of the next day instead
+// of midnight
+func WithRolloverHour(hour int) AdminOption {
+	return func(m *AdminModel) {
+		m.rolloverHour = hour
+	}
+}
+
+// WithAdminPlainText shows questions and answers as written instead of
+// rendering their markdown
+func WithAdminPlainText() AdminOption {
+	return func(m *AdminModel) {
+		m.plain = true
+	}
+}
+
+func NewAdminModel(storeRef *store.Store, flashcards []store.Flashcard, opts ...AdminOption) *AdminModel {
 	q := textinput.New()
 	q.Placeholder = "Question"
 	q.Focus()
@@ -123,7 +186,7 @@ func NewAdminModel(storeRef *store.Store, flashcards []store.Flashcard) *AdminMo
 	// Style the table using theme
 	t = theme.ApplyTableStyles(t)
 
-	return &AdminModel{
+	m := &AdminModel{
 		flashcards:    flashcards,
 		selected:      0,
 		view:          adminList,
@@ -135,6 +198,10 @@ func NewAdminModel(storeRef *store.Store, flashcards []store.Flashcard) *AdminMo
 		help:          help.New(),
 		keys:          adminKeys,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // makeTableRows converts flashcards to table rows
@@ -145,12 +212,27 @@ func makeTableRows(flashcards []store.Flashcard, columns []table.Column) []table
 			fmt.Sprintf("%d", fc.ID),
 			truncate(fc.Question, columns[1].Width),
 			truncate(fc.Answer, columns[2].Width),
-			fmt.Sprintf("%d days", fc.RevisitIn),
+			revisitLabel(fc),
 		}
 	}
 	return rows
 }
 
+// revisitLabel shows the interval of a flashcard, or why it is out of review
+func revisitLabel(fc store.Flashcard) string {
+	label := fmt.Sprintf("%d days", fc.RevisitIn)
+	switch {
+	case fc.Suspended:
+		label = "suspended"
+	case fc.IsBuried(time.Now()):
+		label = "buried"
+	}
+	if fc.Flagged {
+		label = "⚑ " + label
+	}
+	return label
+}
+
 func (m *AdminModel) Init() tea.Cmd { return nil }
 
 func (m *AdminModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -185,6 +267,8 @@ func (m *AdminModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch m.view {
 		case adminList:
 			return m.handleListView(msg)
+		case adminDetail:
+			return m.handleDetailView(msg)
 		case adminCreate:
 			return m.handleCreateView(msg)
 		case adminEdit:
@@ -206,6 +290,13 @@ func (m *AdminModel) handleListView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.view = adminCreate
 		m.resetForm()
 		return m, nil
+	case key.Matches(msg, m.keys.View):
+		if len(m.flashcards) == 0 {
+			return m, nil
+		}
+		m.selected = m.table.Cursor()
+		m.view = adminDetail
+		return m, nil
 	case key.Matches(msg, m.keys.Edit):
 		if len(m.flashcards) == 0 {
 			return m, nil
@@ -227,6 +318,41 @@ func (m *AdminModel) handleListView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		m.view = adminConfirmBulkReset
 		return m, nil
+	case key.Matches(msg, m.keys.Reverse):
+		if len(m.flashcards) == 0 {
+			return m, nil
+		}
+		m.selected = m.table.Cursor()
+		m.createReverse()
+		return m, nil
+	case key.Matches(msg, m.keys.Suspend):
+		if len(m.flashcards) == 0 {
+			return m, nil
+		}
+		m.selected = m.table.Cursor()
+		m.toggleSuspended()
+		return m, nil
+	case key.Matches(msg, m.keys.Bury):
+		if len(m.flashcards) == 0 {
+			return m, nil
+		}
+		m.selected = m.table.Cursor()
+		m.toggleBuried()
+		return m, nil
+	case key.Matches(msg, m.keys.Flag):
+		if len(m.flashcards) == 0 {
+			return m, nil
+		}
+		m.selected = m.table.Cursor()
+		m.toggleFlagged()
+		return m, nil
+	case key.Matches(msg, m.keys.Filter):
+		m.filter = (m.filter + 1) % adminFilter(len(filterNames))
+		m.selected = 0
+		m.table.SetCursor(0)
+		m.reload()
+		m.status.SetSuccess("Showing " + filterNames[m.filter] + " flashcards")
+		return m, nil
 	case key.Matches(msg, m.keys.Reload):
 		m.reload()
 		m.status.SetSuccess("Table refreshed")
@@ -258,6 +384,26 @@ func (m *AdminModel) handleListView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// handleDetailView handles keys while a flashcard is shown in full
+func (m *AdminModel) handleDetailView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Cancel):
+		m.view = adminList
+	case key.Matches(msg, m.keys.Edit):
+		m.loadSelectedIntoForm()
+		m.view = adminEdit
+	case key.Matches(msg, m.keys.Up):
+		m.selected = max(m.selected-1, 0)
+		m.table.SetCursor(m.selected)
+	case key.Matches(msg, m.keys.Down):
+		m.selected = min(m.selected+1, len(m.flashcards)-1)
+		m.table.SetCursor(m.selected)
+	}
+	return m, nil
+}
+
 func (m *AdminModel) handleCreateView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
 	case key.Matches(msg, m.keys.Cancel):
@@ -350,7 +496,12 @@ func (m *AdminModel) View() string {
 	case adminList:
 		var b strings.Builder
 
-		if len(m.flashcards) == 0 {
+		if m.filter != filterAll {
+			b.WriteString(theme.InfoStyle.Render(fmt.Sprintf("Showing %s flashcards (%d) • tab: next filter", filterNames[m.filter], len(m.flashcards))) + "\n")
+		}
+		if len(m.flashcards) == 0 && m.filter != filterAll {
+			b.WriteString(fmt.Sprintf("No %s flashcards.\n", filterNames[m.filter]))
+		} else if len(m.flashcards) == 0 {
 			b.WriteString("No flashcards. Press 'c' to create.\n")
 		} else {
 			b.WriteString(m.table.View())
@@ -361,6 +512,10 @@ func (m *AdminModel) View() string {
 		mainContent = b.String()
 		exitMsg = ""
 
+	case adminDetail:
+		mainContent = m.detailView(layout.CalculateTextWidth(width)) + statusBar
+		exitMsg = theme.HelpStyle.Render("↑/↓: Previous/Next • e: Edit • esc: Back • q: Quit")
+
 	case adminCreate:
 		formContent := components.RenderFormFields(
 			components.FormField{Label: "Question:", Input: m.questionInput},
@@ -388,6 +543,11 @@ func (m *AdminModel) View() string {
 	case adminConfirmBulkReset:
 		warning := theme.ErrorStyle.Render(fmt.Sprintf("Set RevisitIn to 0 for ALL %d flashcards?", len(m.flashcards)))
 		info := theme.InfoStyle.Render("This will make all flashcards due for immediate review.")
+		if m.filter != filterAll {
+			// Only the cards listed are reset, not the ones hidden by the filter
+			warning = theme.ErrorStyle.Render(fmt.Sprintf("Set RevisitIn to 0 for the %d %s flashcards listed?", len(m.flashcards), filterNames[m.filter]))
+			info = theme.InfoStyle.Render("This will make them due for immediate review, flashcards hidden by the filter are left as they are.")
+		}
 		mainContent = fmt.Sprintf("%s\n\n%s\n", warning, info)
 		exitMsg = theme.HelpStyle.Render("y: Yes • n: No • esc: Cancel")
 	}
@@ -401,6 +561,27 @@ func (m *AdminModel) View() string {
 	return layout.CenterContent(m.width, m.height, framedContent)
 }
 
+// detailView shows the selected flashcard in full, its text rendered from
+// markdown
+func (m *AdminModel) detailView(textWidth int) string {
+	fc := m.flashcards[m.selected]
+	info := []string{revisitLabel(fc)}
+	if fc.Deck != "" {
+		info = append(info, "deck "+fc.Deck)
+	}
+	if len(fc.Tags) > 0 {
+		info = append(info, "tags "+strings.Join(fc.Tags, ", "))
+	}
+	if fc.File != "" {
+		info = append(info, fc.File)
+	}
+	return fmt.Sprintf("%s\n%s\n\n%s\n%s\n\n%s\n%s\n",
+		theme.TitleStyle.Render(fmt.Sprintf("Flashcard (ID %d)", fc.ID)),
+		theme.InfoStyle.Render(strings.Join(info, " • ")),
+		theme.QuestionStyle.Render("Question:"), renderCardText(questionText(fc), textWidth, m.plain),
+		theme.AnswerStyle.Render("Answer:"), renderCardText(answerText(fc), textWidth, m.plain))
+}
+
 // helpers
 func truncate(s string, n int) string {
 	if len(s) <= n {
@@ -508,7 +689,13 @@ func (m *AdminModel) reload() {
 		m.status.SetError(err.Error())
 		return
 	}
-	m.flashcards = list
+	now := time.Now()
+	m.flashcards = list[:0]
+	for _, fc := range list {
+		if m.filter.matches(fc, now) {
+			m.flashcards = append(m.flashcards, fc)
+		}
+	}
 
 	// Update table with new data
 	rows := makeTableRows(m.flashcards, m.table.Columns())
@@ -525,11 +712,74 @@ func (m *AdminModel) reload() {
 	}
 }
 
-// bulkResetRevisitIn resets RevisitIn to 0 for all flashcards
+// createReverse adds a reversed sibling (answer → question) for the selected flashcard
+func (m *AdminModel) createReverse() {
+	reverse, err := m.storeRef.CreateReverse(m.flashcards[m.selected])
+	if err != nil {
+		m.status.SetError(err.Error())
+		return
+	}
+	m.status.SetSuccess(fmt.Sprintf("Created reverse flashcard %d", reverse.ID))
+	m.reload()
+}
+
+// toggleSuspended takes the selected flashcard out of review, or puts it back
+func (m *AdminModel) toggleSuspended() {
+	fc := m.flashcards[m.selected]
+	if err := m.storeRef.SetSuspended(fc.ID, !fc.Suspended); err != nil {
+		m.status.SetError(err.Error())
+		return
+	}
+	if fc.Suspended {
+		m.status.SetSuccess(fmt.Sprintf("Unsuspended flashcard %d", fc.ID))
+	} else {
+		m.status.SetSuccess(fmt.Sprintf("Suspended flashcard %d", fc.ID))
+	}
+	m.reload()
+}
+
+// toggleBuried hides the selected flashcard from review until the next day,
+// or shows it again
+func (m *AdminModel) toggleBuried() {
+	fc := m.flashcards[m.selected]
+	now := time.Now()
+	var until time.Time
+	if !fc.IsBuried(now) {
+		until = scheduler.NextDayStart(now, m.rolloverHour)
+	}
+	if err := m.storeRef.Bury(fc.ID, until); err != nil {
+		m.status.SetError(err.Error())
+		return
+	}
+	if until.IsZero() {
+		m.status.SetSuccess(fmt.Sprintf("Unburied flashcard %d", fc.ID))
+	} else {
+		m.status.SetSuccess(fmt.Sprintf("Buried flashcard %d until tomorrow", fc.ID))
+	}
+	m.reload()
+}
+
+// toggleFlagged marks the selected flashcard for attention, or clears the mark
+func (m *AdminModel) toggleFlagged() {
+	fc := m.flashcards[m.selected]
+	if err := m.storeRef.SetFlagged(fc.ID, !fc.Flagged); err != nil {
+		m.status.SetError(err.Error())
+		return
+	}
+	if fc.Flagged {
+		m.status.SetSuccess(fmt.Sprintf("Unflagged flashcard %d", fc.ID))
+	} else {
+		m.status.SetSuccess(fmt.Sprintf("Flagged flashcard %d", fc.ID))
+	}
+	m.reload()
+}
+
+// bulkResetRevisitIn resets RevisitIn to 0 for the flashcards listed under the filter
 func (m *AdminModel) bulkResetRevisitIn() {
 	count := 0
 	for _, fc := range m.flashcards {
 		fc.RevisitIn = 0
+		fc.DueAt = time.Time{}
 		if err := m.storeRef.UpdateFlashcard(fc); err != nil {
 			m.status.SetError(fmt.Sprintf("Error updating flashcard %d: %v", fc.ID, err))
 			m.view = adminList
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"catv/internal/grading"
+	"catv/internal/notes"
+	"catv/internal/store"
+	"catv/internal/tui/components"
+	"catv/internal/tui/keys"
+	"catv/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// WithEditor lets the current card's question and answer be edited from its
+// answer, saving the edited card with save. Close must be called once the
+// review ends.
+func WithEditor(save func(store.Flashcard) error) ReviewOption {
+	return func(m *ReviewModel) {
+		m.saveCard = save
+		if m.saver == nil {
+			m.saver = newSaver()
+		}
+	}
+}
+
+// cardEditor is the question and answer form of the card being edited. The
+// answer of a cloze card follows from its text and is not edited.
+type cardEditor struct {
+	question textinput.Model
+	answer   textinput.Model
+	cloze    bool
+	err      string
+}
+
+func newCardEditor(fc store.Flashcard) *cardEditor {
+	q := textinput.New()
+	q.Placeholder = "Question"
+	q.CharLimit = 0
+	q.SetValue(fc.Question)
+	q.Focus()
+	a := textinput.New()
+	a.Placeholder = "Answer"
+	a.CharLimit = 0
+	a.SetValue(fc.Answer)
+	return &cardEditor{question: q, answer: a, cloze: fc.IsCloze()}
+}
+
+// canEdit reports whether the current card can be edited: once its answer is
+// shown, so editing cannot reveal it
+func (m *ReviewModel) canEdit() bool {
+	return m.saveCard != nil && (m.view == viewAnswer || m.view == viewChoiceResult)
+}
+
+// openEditor shows the form editing the current card
+func (m *ReviewModel) openEditor() {
+	m.editor = newCardEditor(m.flashcards[m.current])
+	m.editReturn = m.view
+	m.view = viewEdit
+}
+
+// updateEditor handles keys while the card is edited
+func (m *ReviewModel) updateEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := m.editor
+	switch msg.String() {
+	case keys.CtrlC:
+		m.quitting = true
+		return m, tea.Quit
+	case keys.Esc:
+		m.closeEditor()
+	case keys.Tab:
+		if e.cloze {
+			break
+		}
+		if e.question.Focused() {
+			e.question.Blur()
+			e.answer.Focus()
+		} else {
+			e.answer.Blur()
+			e.question.Focus()
+		}
+	case keys.Enter:
+		m.saveEdit()
+	default:
+		var cmd tea.Cmd
+		if e.question.Focused() {
+			e.question, cmd = e.question.Update(msg)
+		} else {
+			e.answer, cmd = e.answer.Update(msg)
+		}
+		return m, cmd
+	}
+	return m, nil
+}
+
+// saveEdit saves the edited card, its siblings following it, and shows its
+// answer again with the new text
+func (m *ReviewModel) saveEdit() {
+	e := m.editor
+	fc := m.flashcards[m.current]
+	group := fc.SiblingKey()
+	ordinals := notes.ClozeOrdinals(fc.Question)
+	fc.Question = strings.TrimSpace(e.question.Value())
+	fc.Answer = strings.TrimSpace(e.answer.Value())
+	if e.cloze {
+		fc.Answer = notes.ClozeAnswer(fc.Question, fc.Ordinal)
+	}
+	switch {
+	case fc.Question == "":
+		e.err = "The question cannot be empty"
+		return
+	case e.cloze && fc.Answer == "":
+		e.err = fmt.Sprintf("The text must keep its {{c%d::...}} deletion", fc.Ordinal)
+		return
+	case fc.Answer == "":
+		e.err = "The answer cannot be empty"
+		return
+	}
+	// The other deletions of the text are cards of their own
+	for _, ordinal := range ordinals {
+		if e.cloze && notes.ClozeAnswer(fc.Question, ordinal) == "" {
+			e.err = fmt.Sprintf("The text must keep the {{c%d::...}} deletion of its sibling card", ordinal)
+			return
+		}
+	}
+
+	save := m.saveCard
+	m.saver.save(func() error { return save(fc) })
+	for i := range m.flashcards {
+		switch {
+		case m.flashcards[i].ID == fc.ID:
+			m.flashcards[i].Question, m.flashcards[i].Answer = fc.Question, fc.Answer
+		case m.flashcards[i].SiblingKey() == group:
+			m.flashcards[i] = m.flashcards[i].FollowEdit(fc)
+		}
+	}
+	if m.typed && m.editReturn == viewAnswer {
+		// A fixed answer may now match what was typed
+		m.grade = grading.Grade(fc.Answer, m.input.Value())
+	}
+	m.closeEditor()
+	m.notice = "Card updated."
+}
+
+// closeEditor returns to the card's answer
+func (m *ReviewModel) closeEditor() {
+	m.editor = nil
+	m.view = m.editReturn
+}
+
+// view renders the form editing the card
+func (e *cardEditor) view() string {
+	fields := []components.FormField{{Label: "Question:", Input: e.question}}
+	if !e.cloze {
+		fields = append(fields, components.FormField{Label: "Answer:", Input: e.answer})
+	}
+	content := theme.TitleStyle.Render("Edit card") + "\n\n" + components.RenderFormFields(fields...)
+	if e.err != "" {
+		content += "\n\n" + theme.ErrorStyle.Render(e.err)
+	}
+	return content
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"context"
+	"strings"
+
+	"catv/internal/tui/keys"
+	"catv/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ChatMessage is one turn of a conversation with the model
+type ChatMessage struct {
+	Role    string // "system", "user" or "assistant"
+	Content string
+}
+
+// Chat sends a conversation to a model, calling onToken for every chunk of
+// the reply as it is streamed
+type Chat func(ctx context.Context, messages []ChatMessage, onToken func(string)) error
+
+// chatTokenMsg carries a streamed chunk of the model's reply
+type chatTokenMsg struct {
+	events <-chan tea.Msg
+	token  string
+}
+
+// chatDoneMsg ends a streamed reply
+type chatDoneMsg struct {
+	events <-chan tea.Msg
+	err    error
+}
+
+// chatPane is a conversation with the model about a card, shown over the
+// review until the user returns to it
+type chatPane struct {
+	chat      Chat
+	messages  []ChatMessage // full conversation sent to the model
+	hidden    int           // leading messages that are context, not shown
+	input     textinput.Model
+	streaming bool
+	events    <-chan tea.Msg
+	cancel    context.CancelFunc
+	err       error
+}
+
+func newChatPane(chat Chat, system string) *chatPane {
+	ti := textinput.New()
+	ti.Placeholder = "Ask a follow-up question"
+	ti.Focus()
+	return &chatPane{
+		chat:     chat,
+		messages: []ChatMessage{{Role: "system", Content: system}},
+		hidden:   1,
+		input:    ti,
+	}
+}
+
+// send asks the model a question, keeping the conversation so far as context
+func (c *chatPane) send(question string) tea.Cmd {
+	c.messages = append(c.messages, ChatMessage{Role: "user", Content: question})
+	history := append([]ChatMessage(nil), c.messages...)
+	c.messages = append(c.messages, ChatMessage{Role: "assistant"})
+	c.streaming = true
+	c.err = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan tea.Msg)
+	c.events, c.cancel = events, cancel
+
+	chat := c.chat
+	go func() {
+		defer close(events)
+		err := chat(ctx, history, func(token string) {
+			select {
+			case events <- chatTokenMsg{events: events, token: token}:
+			case <-ctx.Done():
+			}
+		})
+		select {
+		case events <- chatDoneMsg{events: events, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+	return waitForChat(events)
+}
+
+// waitForChat delivers the next event of a streamed reply
+func waitForChat(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// update handles input and streamed replies, reporting when the user leaves
+func (c *chatPane) update(msg tea.Msg) (closed bool, cmd tea.Cmd) {
+	switch msg := msg.(type) {
+	case chatTokenMsg:
+		if msg.events != c.events {
+			return false, nil // reply to a cancelled request
+		}
+		c.messages[len(c.messages)-1].Content += msg.token
+		return false, waitForChat(c.events)
+	case chatDoneMsg:
+		if msg.events != c.events {
+			return false, nil
+		}
+		c.streaming = false
+		c.err = msg.err
+		return false, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case keys.Esc:
+			c.close()
+			return true, nil
+		case keys.Enter:
+			question := strings.TrimSpace(c.input.Value())
+			if question == "" || c.streaming {
+				return false, nil
+			}
+			c.input.Reset()
+			return false, c.send(question)
+		}
+		c.input, cmd = c.input.Update(msg)
+		return false, cmd
+	}
+	return false, nil
+}
+
+// close stops any reply still streaming
+func (c *chatPane) close() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.streaming = false
+}
+
+// view renders the latest lines of the conversation that fit in height
+func (c *chatPane) view(width, height int) string {
+	wrap := lipgloss.NewStyle().Width(width)
+	var lines []string
+	for _, msg := range c.messages[c.hidden:] {
+		label := theme.LabelStyle.Render("You:")
+		if msg.Role == "assistant" {
+			label = theme.AnswerStyle.Render("Model:")
+		}
+		content := msg.Content
+		if msg.Role == "assistant" && content == "" && c.streaming {
+			content = "…"
+		}
+		lines = append(lines, label)
+		lines = append(lines, strings.Split(wrap.Render(content), "\n")...)
+		lines = append(lines, "")
+	}
+	if c.err != nil {
+		lines = append(lines, theme.ErrorStyle.Render("Model error: "+c.err.Error()), "")
+	}
+	if height > 0 && len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	return strings.Join(lines, "\n") + "\n" + c.input.View()
+}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"catv/internal/stats"
 	"catv/internal/tui/keys"
 	"catv/internal/tui/layout"
 	"catv/internal/tui/theme"
@@ -17,26 +18,42 @@ const (
 
 // FileSelectorModel represents the file selection screen
 type FileSelectorModel struct {
-	files         []string        // List of file paths
-	selected      map[string]bool // Map of selected files
-	cursor        int             // Current cursor position
-	width         int             // Terminal width
-	height        int             // Terminal height
-	confirmed     bool            // Whether user confirmed selection
-	selectedFiles []string        // Final selected files after confirmation
+	files         []string          // List of file paths
+	selected      map[string]bool   // Map of selected files
+	cursor        int               // Current cursor position
+	width         int               // Terminal width
+	height        int               // Terminal height
+	confirmed     bool              // Whether user confirmed selection
+	selectedFiles []string          // Final selected files after confirmation
+	goal          *stats.GoalStatus // Daily goal shown under the title, nil to hide it
+}
+
+// FileSelectorOption configures a FileSelectorModel
+type FileSelectorOption func(*FileSelectorModel)
+
+// WithSelectorGoal shows today's progress towards the daily goal and the
+// streak under the title
+func WithSelectorGoal(status stats.GoalStatus) FileSelectorOption {
+	return func(m *FileSelectorModel) {
+		m.goal = &status
+	}
 }
 
 // NewFileSelectorModel creates a new file selector model
-func NewFileSelectorModel(files []string) *FileSelectorModel {
+func NewFileSelectorModel(files []string, opts ...FileSelectorOption) *FileSelectorModel {
 	selected := make(map[string]bool)
 	// Add "All Files" option at the beginning
 	allFiles := append([]string{allFilesOption}, files...)
 
-	return &FileSelectorModel{
+	m := &FileSelectorModel{
 		files:    allFiles,
 		selected: selected,
 		cursor:   0,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (m *FileSelectorModel) Init() tea.Cmd {
@@ -119,11 +136,20 @@ func (m *FileSelectorModel) View() string {
 	s.WriteString(centeredTitle)
 	s.WriteString("\n")
 
+	// Daily goal and streak
+	if m.goal != nil {
+		s.WriteString(goalView(*m.goal))
+		s.WriteString("\n\n")
+	}
+
 	// Calculate visible area - need to account for title, help, and selected count
 	maxVisible := 15 // Fixed reasonable number of visible items
 	if m.height > 20 {
 		maxVisible = m.height - 15 // Adjust based on screen size
 	}
+	if m.goal != nil {
+		maxVisible -= 3 // Room for the goal and streak
+	}
 	if maxVisible < 5 {
 		maxVisible = 5
 	}
@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"catv/internal/stats"
 	"catv/internal/tui/keys"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -348,3 +350,21 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestFileSelectorModel_Goal(t *testing.T) {
+	model := NewFileSelectorModel([]string{"/file1.md"})
+	model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	if strings.Contains(model.View(), "day(s) in a row") {
+		t.Error("View() should not show a streak without a goal")
+	}
+
+	status := stats.GoalStatus{Goal: stats.Goal{Cards: 20}, Answers: 5, Streak: stats.Streak{Current: 4, Longest: 9}}
+	model = NewFileSelectorModel([]string{"/file1.md"}, WithSelectorGoal(status))
+	model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	view := model.View()
+	for _, want := range []string{"5/20 cards", "🔥 4 day(s) in a row, longest 9", "/file1.md"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() should contain %q, got\n%s", want, view)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"catv/internal/stats"
+	"catv/internal/tui/theme"
+)
+
+// goalBarWidth is the number of cells of the daily goal's progress bar
+const goalBarWidth = 10
+
+// WithGoal shows on the done screen today's progress towards the daily goal,
+// this review included, and the streak. status is the progress before the
+// review.
+func WithGoal(status stats.GoalStatus) ReviewOption {
+	return func(m *ReviewModel) {
+		m.goal = &status
+	}
+}
+
+// goalView renders the progress towards today's goal and the streak
+func goalView(status stats.GoalStatus) string {
+	return goalLine(status) + "\n" + streakLine(status.Streak)
+}
+
+// goalLine renders today's progress towards the goal, with a bar when it has
+// targets
+func goalLine(status stats.GoalStatus) string {
+	g := status.Goal
+	if g.Cards <= 0 && g.Minutes <= 0 {
+		if status.Met() {
+			return fmt.Sprintf("🎯 Studied today ✓ (%d answer(s))", status.Answers)
+		}
+		return "🎯 " + theme.InfoStyle.Render("Review a card today to meet the goal")
+	}
+	var targets []string
+	if g.Cards > 0 {
+		targets = append(targets, fmt.Sprintf("%d/%d cards", status.Answers, g.Cards))
+	}
+	if g.Minutes > 0 {
+		targets = append(targets, fmt.Sprintf("%d/%d min", int(status.Minutes), g.Minutes))
+	}
+	progress := g.Progress(status.Answers, status.Time)
+	filled := int(math.Floor(progress * goalBarWidth))
+	bar := theme.BarStyle.Render(strings.Repeat("▰", filled)) + theme.InfoStyle.Render(strings.Repeat("▱", goalBarWidth-filled))
+	line := fmt.Sprintf("🎯 Today %s %s", bar, strings.Join(targets, " or "))
+	if status.Met() {
+		line += theme.SuccessStyle.Render(" ✓")
+	}
+	return line
+}
+
+// streakLine renders the days in a row the goal was met
+func streakLine(s stats.Streak) string {
+	if s.Current == 0 {
+		return theme.InfoStyle.Render(fmt.Sprintf("No streak, meet today's goal to start one (longest %d day(s))", s.Longest))
+	}
+	line := fmt.Sprintf("🔥 %d day(s) in a row, longest %d", s.Current, s.Longest)
+	if s.Frozen > 0 {
+		line += fmt.Sprintf(", %d frozen ❄", s.Frozen)
+	}
+	if !s.Today {
+		line += theme.InfoStyle.Render(" • meet today's goal to keep it")
+	}
+	return line
+}
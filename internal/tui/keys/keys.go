@@ -24,13 +24,23 @@ const (
 	N        = "n"
 	R        = "r"
 	B        = "b"
+	X        = "x"
+	P        = "p"
+	U        = "u"
+	S        = "s"
+	Z        = "z"
+	F        = "f"
 	CtrlC    = "ctrl+c"
+	CtrlP    = "ctrl+p"
+	CtrlZ    = "ctrl+z"
 	PageUp   = "pgup"
 	PageDown = "pgdown"
 
 	// Number keys for shortcuts
 	One   = "1"
+	Two   = "2"
 	Three = "3"
+	Four  = "4"
 	Seven = "7"
 	Nine  = "9"
 )
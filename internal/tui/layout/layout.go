@@ -18,6 +18,13 @@ func CalculateContentWidth(terminalWidth int) int {
 	return terminalWidth
 }
 
+// CalculateTextWidth returns the width text can be wrapped to inside a frame
+// of the given width, leaving room for its border and padding. It is 0, for
+// unwrapped text, while the frame width is not known.
+func CalculateTextWidth(frameWidth int) int {
+	return max(frameWidth-6, 0)
+}
+
 // CreateFrame creates a standard bordered frame with the application's default styling.
 // Additional options can be passed to customize the frame.
 func CreateFrame(width int, opts ...FrameOption) lipgloss.Style {
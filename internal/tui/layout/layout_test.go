@@ -341,3 +341,20 @@ func TestCreateBottomBar(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateTextWidth(t *testing.T) {
+	tests := []struct {
+		frameWidth int
+		expected   int
+	}{
+		{theme.MaxContentWidth, theme.MaxContentWidth - 6},
+		{40, 34},
+		{4, 0},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		if got := CalculateTextWidth(tt.frameWidth); got != tt.expected {
+			t.Errorf("CalculateTextWidth(%d) = %d, want %d", tt.frameWidth, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"catv/internal/scheduler"
+	"catv/internal/store"
+)
+
+// repeat is the learning state of a failed card asked again later in the
+// session. The zero value is a card asked for the first time.
+type repeat struct {
+	id        int // identifies the entry so undo can take it out of the queue
+	step      int // learning step the card is asked at
+	steps     scheduler.Steps
+	notBefore time.Time // asked once this time has passed, or when nothing else is left
+}
+
+// WithLearningSteps asks failed cards again in the session after each step's
+// delay until they are answered correctly at every step. New cards use
+// learning steps, cards that had been learned use relearning steps. Without
+// steps failed cards are due again the next day.
+func WithLearningSteps(learning, relearning scheduler.Steps) ReviewOption {
+	return func(m *ReviewModel) {
+		m.learning = learning
+		m.relearning = relearning
+	}
+}
+
+// WithLeeches detects cards forgotten threshold times or more, which are
+// tagged as leeches and, when suspend is set, suspended and not relearned
+func WithLeeches(threshold int, suspend bool) ReviewOption {
+	return func(m *ReviewModel) {
+		m.leechThreshold = threshold
+		m.suspendLeeches = suspend
+	}
+}
+
+// isNewLeech reports whether a card lapsed to lapses becomes a leech
+func (m *ReviewModel) isNewLeech(fc store.Flashcard, lapses int) bool {
+	return m.leechThreshold > 0 && lapses >= m.leechThreshold && !slices.Contains(fc.Tags, store.LeechTag)
+}
+
+// leechNotice tells that the card just graded became a leech
+func leechNotice(lapses int, suspended bool) string {
+	notice := fmt.Sprintf("Leech: forgotten %d times, tagged %q", lapses, store.LeechTag)
+	if suspended {
+		notice += " and suspended"
+	}
+	return notice + ". Run catv leeches --fix to rewrite it."
+}
+
+// schedule decides what happens to the graded current card: the days until
+// its next review, 0 while it is still being learned in the session, whether
+// it was forgotten after being learned, and the repeat queued for it
+func (m *ReviewModel) schedule() (revisitIn int, lapse bool, next *repeat) {
+	r := m.repeats[m.current]
+	step, steps := r.step+1, r.steps
+	switch {
+	case r.id == 0 && m.revisitIn[m.current] > 0:
+		// Interval picked after a correct answer
+		return m.revisitIn[m.current], false, nil
+	case !m.correct[m.current] && r.id == 0:
+		// First failure in the session starts the learning steps
+		step, steps = 0, m.learning
+		if !m.flashcards[m.current].IsNew() {
+			steps, lapse = m.relearning, true
+		}
+	case !m.correct[m.current]:
+		step = 0
+	}
+	delay, ok := steps.Delay(step)
+	if !ok {
+		return scheduler.GraduatingInterval, lapse, nil
+	}
+	m.lastRepeat++
+	return 0, lapse, &repeat{id: m.lastRepeat, step: step, steps: steps, notBefore: m.now().Add(delay)}
+}
+
+// requeue adds the current card back at the end of the queue as a repeat,
+// with its schedule after the grade
+func (m *ReviewModel) requeue(fc store.Flashcard, r *repeat) {
+	idx := m.current
+	m.flashcards = append(m.flashcards, fc)
+	m.correct = append(m.correct, false)
+	m.revisitIn = append(m.revisitIn, 0)
+	m.picked = append(m.picked, 0)
+	m.hints = append(m.hints, 0)
+	m.timedOut = append(m.timedOut, false)
+	if len(m.choices) > 0 {
+		m.choices = append(m.choices, m.choices[idx])
+	}
+	m.repeats = append(m.repeats, *r)
+}
+
+// dequeue removes a repeat that has not been asked yet, when the grade that
+// queued it is undone
+func (m *ReviewModel) dequeue(id int) {
+	for i := m.current; i < len(m.repeats); i++ {
+		if m.repeats[i].id == id {
+			m.removeEntry(i)
+			return
+		}
+	}
+}
+
+// removeEntry takes the card at i out of the queue
+func (m *ReviewModel) removeEntry(i int) {
+	m.flashcards = remove(m.flashcards, i)
+	m.correct = remove(m.correct, i)
+	m.revisitIn = remove(m.revisitIn, i)
+	m.picked = remove(m.picked, i)
+	m.hints = remove(m.hints, i)
+	m.timedOut = remove(m.timedOut, i)
+	if len(m.choices) > 0 {
+		m.choices = remove(m.choices, i)
+	}
+	m.repeats = remove(m.repeats, i)
+}
+
+// pickNext brings forward the card to ask now when the next one in the queue
+// is a repeat that is not due yet: the first card that is due, or else the
+// repeat due soonest
+func (m *ReviewModel) pickNext() {
+	now := m.now()
+	best := m.current
+	for i := m.current; i < len(m.repeats); i++ {
+		if !m.repeats[i].notBefore.After(now) {
+			best = i
+			break
+		}
+		if m.repeats[i].notBefore.Before(m.repeats[best].notBefore) {
+			best = i
+		}
+	}
+	if best == m.current {
+		return
+	}
+	m.flashcards = move(m.flashcards, best, m.current)
+	m.correct = move(m.correct, best, m.current)
+	m.revisitIn = move(m.revisitIn, best, m.current)
+	m.picked = move(m.picked, best, m.current)
+	m.hints = move(m.hints, best, m.current)
+	m.timedOut = move(m.timedOut, best, m.current)
+	if len(m.choices) > 0 {
+		m.choices = move(m.choices, best, m.current)
+	}
+	m.repeats = move(m.repeats, best, m.current)
+}
+
+// learningView labels a card asked again in the session
+func (m *ReviewModel) learningView() string {
+	r := m.repeats[m.current]
+	if r.id == 0 {
+		return ""
+	}
+	return fmt.Sprintf("↻ Learning, step %d/%d", r.step+1, len(r.steps))
+}
+
+// remove deletes element i of s
+func remove[T any](s []T, i int) []T {
+	return append(s[:i], s[i+1:]...)
+}
+
+// move takes element from of s and inserts it at to, with to < from
+func move[T any](s []T, from, to int) []T {
+	v := s[from]
+	copy(s[to+1:from+1], s[to:from])
+	s[to] = v
+	return s
+}
@@ -0,0 +1,191 @@
+package markdown
+
+import (
+	"strings"
+
+	"catv/internal/tui/theme"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tokenKind is the syntax class of a piece of code
+type tokenKind int
+
+const (
+	plainToken tokenKind = iota
+	keywordToken
+	stringToken
+	numberToken
+	commentToken
+)
+
+// token is a piece of a code line and its syntax class
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// language describes enough of a programming language's syntax to highlight it
+type language struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string // opening and closing, empty when there are none
+	quotes       string    // characters delimiting string literals
+}
+
+func newLanguage(keywords string, quotes string, blockComment [2]string, lineComments ...string) *language {
+	l := &language{keywords: make(map[string]bool), lineComments: lineComments, blockComment: blockComment, quotes: quotes}
+	for _, k := range strings.Fields(keywords) {
+		l.keywords[k] = true
+	}
+	return l
+}
+
+var (
+	cComment = [2]string{"/*", "*/"}
+
+	goLanguage = newLanguage(`break case chan const continue default defer else fallthrough for func go goto if
+		import interface map package range return select struct switch type var nil true false iota`, "\"'`", cComment, "//")
+	pythonLanguage = newLanguage(`and as assert async await break class continue def del elif else except finally for
+		from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self`, `"'`, [2]string{}, "#")
+	jsLanguage = newLanguage(`async await break case catch class const continue debugger default delete do else export
+		extends finally for function if import in instanceof let new of return super switch this throw try typeof var void
+		while yield null undefined true false interface type enum implements`, "\"'`", cComment, "//")
+	rustLanguage = newLanguage(`as async await break const continue crate else enum extern fn for if impl in let loop
+		match mod move mut pub ref return self Self static struct super trait type unsafe use where while true false`, `"`, cComment, "//")
+	cLanguage = newLanguage(`auto break case catch char class const continue default delete do double else enum extern
+		final float for goto if implements import int long new namespace private protected public return short signed sizeof
+		static struct switch template this throw try typedef union unsigned using virtual void volatile while bool boolean
+		true false null nullptr package extends interface`, `"'`, cComment, "//")
+	shellLanguage = newLanguage(`case do done elif else esac export fi for function if in local return then until while
+		echo exit set unset`, `"'`, [2]string{}, "#")
+	sqlLanguage = newLanguage(`select from where insert into values update set delete create table index drop alter
+		join left right inner outer on group by order having limit offset as and or not null is in like distinct union
+		primary key foreign references default begin commit rollback`, `'"`, cComment, "--")
+
+	languages = map[string]*language{
+		"go": goLanguage, "golang": goLanguage,
+		"python": pythonLanguage, "py": pythonLanguage,
+		"javascript": jsLanguage, "js": jsLanguage, "jsx": jsLanguage, "typescript": jsLanguage, "ts": jsLanguage, "tsx": jsLanguage,
+		"rust": rustLanguage, "rs": rustLanguage,
+		"c": cLanguage, "h": cLanguage, "cpp": cLanguage, "c++": cLanguage, "java": cLanguage, "cs": cLanguage, "csharp": cLanguage, "kotlin": cLanguage,
+		"sh": shellLanguage, "bash": shellLanguage, "shell": shellLanguage, "zsh": shellLanguage, "console": shellLanguage,
+		"sql": sqlLanguage,
+	}
+)
+
+// highlighter splits the lines of a code block into tokens, remembering block
+// comments spanning several lines
+type highlighter struct {
+	lang      *language
+	inComment bool
+}
+
+// newHighlighter highlights code in the language named by a fence info
+// string, code in unknown languages being left plain
+func newHighlighter(name string) *highlighter {
+	return &highlighter{lang: languages[strings.ToLower(name)]}
+}
+
+// tokens splits a line of code into tokens
+func (h *highlighter) tokens(line string) []token {
+	if h.lang == nil {
+		return []token{{plainToken, line}}
+	}
+	var tokens []token
+	add := func(kind tokenKind, text string) {
+		if n := len(tokens); n > 0 && tokens[n-1].kind == kind {
+			tokens[n-1].text += text
+			return
+		}
+		tokens = append(tokens, token{kind, text})
+	}
+	open, closing := h.lang.blockComment[0], h.lang.blockComment[1]
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		if h.inComment {
+			end := strings.Index(rest, closing)
+			if end < 0 {
+				add(commentToken, rest)
+				break
+			}
+			add(commentToken, rest[:end+len(closing)])
+			i += end + len(closing)
+			h.inComment = false
+			continue
+		}
+		if h.isLineComment(rest) {
+			add(commentToken, rest)
+			break
+		}
+		if open != "" && strings.HasPrefix(rest, open) {
+			add(commentToken, open)
+			i += len(open)
+			h.inComment = true
+			continue
+		}
+
+		c := line[i]
+		j := i + 1
+		switch {
+		case strings.IndexByte(h.lang.quotes, c) >= 0:
+			for j < len(line) && line[j] != c {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(line))
+			add(stringToken, line[i:j])
+		case isDigit(c) && (i == 0 || !isWordByte(line[i-1])):
+			for j < len(line) && (isWordByte(line[j]) || line[j] == '.') {
+				j++
+			}
+			add(numberToken, line[i:j])
+		case isWordByte(c):
+			for j < len(line) && isWordByte(line[j]) {
+				j++
+			}
+			word := line[i:j]
+			if h.lang.keywords[word] || (h.lang == sqlLanguage && h.lang.keywords[strings.ToLower(word)]) {
+				add(keywordToken, word)
+			} else {
+				add(plainToken, word)
+			}
+		default:
+			add(plainToken, line[i:j])
+		}
+		i = j
+	}
+	return tokens
+}
+
+func (h *highlighter) isLineComment(s string) bool {
+	for _, prefix := range h.lang.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenStyles are the styles of the highlighted token kinds
+var tokenStyles = map[tokenKind]lipgloss.Style{
+	keywordToken: theme.KeywordStyle,
+	stringToken:  theme.StringStyle,
+	numberToken:  theme.NumberStyle,
+	commentToken: theme.CommentStyle,
+}
+
+// renderTokens renders a line of tokens with their styles
+func renderTokens(tokens []token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if style, ok := tokenStyles[t.kind]; ok {
+			b.WriteString(style.Render(t.text))
+		} else {
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,161 @@
+package markdown
+
+import (
+	"strings"
+
+	"catv/internal/tui/theme"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	italicStyle = lipgloss.NewStyle().Italic(true)
+	boldStyle   = lipgloss.NewStyle().Bold(true)
+	strikeStyle = lipgloss.NewStyle().Strikethrough(true)
+)
+
+// inline renders the code spans, emphasis and links of a line of text
+func inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\x1b':
+			// Keep escape sequences whole so their bytes are not read as markdown
+			j := escapeEnd(s, i)
+			b.WriteString(s[i:j])
+			i = j
+			continue
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_~[]()#+-.!|<>", s[i+1]) >= 0:
+			b.WriteByte(s[i+1])
+			i += 2
+			continue
+		case c == '`':
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			fence := strings.Repeat("`", n)
+			if end := strings.Index(s[i+n:], fence); end >= 0 {
+				code := s[i+n : i+n+end]
+				if strings.TrimSpace(code) != "" {
+					code = strings.TrimSpace(code)
+				}
+				b.WriteString(theme.CodeStyle.Render(code))
+				i += n + end + n
+				continue
+			}
+			b.WriteString(fence)
+			i += n
+			continue
+		case c == '*' || c == '_' || c == '~':
+			if text, n, style, ok := emphasis(s, i); ok {
+				b.WriteString(style.Render(inline(text)))
+				i += n
+				continue
+			}
+		case c == '[' || (c == '!' && strings.HasPrefix(s[i:], "![")):
+			if text, url, n, ok := link(s, i); ok {
+				if c == '!' {
+					b.WriteString(theme.InfoStyle.Render("[image: " + text + "]"))
+				} else {
+					b.WriteString(theme.LinkStyle.Render(inline(text)))
+					if url != "" && url != text {
+						b.WriteString(" " + theme.InfoStyle.Render("("+url+")"))
+					}
+				}
+				i += n
+				continue
+			}
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// emphasis parses the emphasis opening at s[i], returning its text, its
+// length in s and the style it is rendered with. Underscores inside words,
+// as in snake_case, are not emphasis.
+func emphasis(s string, i int) (text string, n int, style lipgloss.Style, ok bool) {
+	c := s[i]
+	delim := s[i : i+1]
+	style = italicStyle
+	if strings.HasPrefix(s[i:], delim+delim) {
+		delim += delim
+		style = boldStyle
+	}
+	if c == '~' {
+		if len(delim) != 2 {
+			return "", 0, style, false
+		}
+		style = strikeStyle
+	}
+	start := i + len(delim)
+	if start >= len(s) || s[start] == ' ' || (len(delim) == 1 && s[start] == c) {
+		return "", 0, style, false
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0, style, false
+	}
+	for j := start + 1; j+len(delim) <= len(s); j++ {
+		if s[j:j+len(delim)] != delim || s[j-1] == ' ' || s[j-1] == '\\' {
+			continue
+		}
+		end := j + len(delim)
+		// Close on the last delimiter of a run, so ***both*** nests
+		if end < len(s) && s[end] == c {
+			continue
+		}
+		if c == '_' && end < len(s) && isWordByte(s[end]) {
+			continue
+		}
+		return s[start:j], end - i, style, true
+	}
+	return "", 0, style, false
+}
+
+// link parses the link or image opening at s[i], returning its text, its
+// destination and its length in s
+func link(s string, i int) (text, url string, n int, ok bool) {
+	open := i
+	if s[i] == '!' {
+		open++
+	}
+	closeText := strings.IndexByte(s[open:], ']')
+	if closeText < 0 {
+		return "", "", 0, false
+	}
+	closeText += open
+	if closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeText+1:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	closeURL += closeText + 1
+	// A title after the destination is dropped
+	fields := strings.Fields(s[closeText+2 : closeURL])
+	if len(fields) > 0 {
+		url = fields[0]
+	}
+	return s[open+1 : closeText], url, closeURL + 1 - i, true
+}
+
+// escapeEnd returns the index after the ANSI escape sequence starting at s[i]
+func escapeEnd(s string, i int) int {
+	j := i + 1
+	if j < len(s) && s[j] == '[' {
+		for j++; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7e {
+				return j + 1
+			}
+		}
+		return len(s)
+	}
+	return min(j+1, len(s))
+}
+
+// isWordByte reports whether c is part of a word, any byte of a multibyte
+// character counting as a letter
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || isDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
@@ -0,0 +1,231 @@
+// Package markdown renders the markdown of flashcards for the terminal:
+// headings, emphasis, lists, quotes, tables and fenced code blocks with syntax
+// highlighting, wrapped to the width of the frame they are shown in.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"catv/internal/tui/theme"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	fencePattern     = regexp.MustCompile("^(`{3,}|~{3,})\\s*([^`\\s]*)")
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(\s+#+)?\s*$`)
+	listItemPattern  = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	taskPattern      = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	rulePattern      = regexp.MustCompile(`^\s{0,3}((\*\s*){3,}|(-\s*){3,}|(_\s*){3,})$`)
+	tableRulePattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// ruleWidth is the width of horizontal rules when text is not wrapped
+const ruleWidth = 40
+
+// Render renders markdown text for the terminal, wrapped to width columns or
+// unwrapped when width is 0 or less. Single line breaks are kept as cards are
+// short and rarely written to be reflowed. ANSI escape sequences already in
+// the text, such as a highlighted cloze deletion, are kept as they are.
+func Render(src string, width int) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	blank := false
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "" {
+			blank = true
+			i++
+			continue
+		}
+		// Blocks are apart when the text has blank lines between them
+		if b.Len() > 0 {
+			b.WriteString("\n")
+			if blank {
+				b.WriteString("\n")
+			}
+		}
+		var block string
+		block, i = renderBlock(lines, i, width)
+		b.WriteString(block)
+		blank = false
+	}
+	return b.String()
+}
+
+// Plain wraps text to width columns without interpreting its markdown, for
+// terminals or users that prefer the raw text
+func Plain(src string, width int) string {
+	return wrap(strings.TrimSpace(strings.ReplaceAll(src, "\r\n", "\n")), width)
+}
+
+// renderBlock renders the block starting at lines[i] and returns the index of
+// the line after it
+func renderBlock(lines []string, i, width int) (string, int) {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case fencePattern.MatchString(trimmed):
+		return renderCode(lines, i, width)
+	case headingPattern.MatchString(trimmed):
+		text := headingPattern.FindStringSubmatch(trimmed)[2]
+		return theme.TitleStyle.Render(wrap(inline(text), width)), i + 1
+	case rulePattern.MatchString(line):
+		n := ruleWidth
+		if width > 0 {
+			n = min(width, ruleWidth)
+		}
+		return theme.CodeBorderStyle.Render(strings.Repeat("─", n)), i + 1
+	case strings.HasPrefix(trimmed, ">"):
+		return renderQuote(lines, i, width)
+	case listItemPattern.MatchString(line):
+		return renderList(lines, i, width)
+	case isTableStart(lines, i):
+		return renderTable(lines, i, width)
+	}
+
+	// A paragraph runs until a blank line or the start of another block
+	var out []string
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || (len(out) > 0 && startsBlock(lines, i)) {
+			break
+		}
+		out = append(out, wrap(inline(strings.TrimSpace(lines[i])), width))
+	}
+	return strings.Join(out, "\n"), i
+}
+
+// startsBlock reports whether lines[i] starts a block other than a paragraph
+func startsBlock(lines []string, i int) bool {
+	trimmed := strings.TrimSpace(lines[i])
+	return fencePattern.MatchString(trimmed) || headingPattern.MatchString(trimmed) ||
+		rulePattern.MatchString(lines[i]) || strings.HasPrefix(trimmed, ">") ||
+		listItemPattern.MatchString(lines[i]) || isTableStart(lines, i)
+}
+
+// renderCode renders a fenced code block with a gutter, highlighting its
+// language when known. Long lines are broken rather than reflowed.
+func renderCode(lines []string, i, width int) (string, int) {
+	open := fencePattern.FindStringSubmatch(strings.TrimSpace(lines[i]))
+	fence, h := open[1], newHighlighter(open[2])
+	gutter := theme.CodeBorderStyle.Render("│ ")
+	var out []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		code := strings.ReplaceAll(lines[i], "\t", "    ")
+		highlighted := renderTokens(h.tokens(code))
+		if width > 2 && ansi.StringWidth(code) > width-2 {
+			highlighted = ansi.Hardwrap(highlighted, width-2, true)
+		}
+		for _, part := range strings.Split(highlighted, "\n") {
+			out = append(out, gutter+part)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, gutter)
+	}
+	return strings.Join(out, "\n"), i
+}
+
+// renderQuote renders consecutive quoted lines, which may hold any markdown,
+// behind a gutter
+func renderQuote(lines []string, i, width int) (string, int) {
+	var inner []string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, ">") {
+			break
+		}
+		trimmed = strings.TrimPrefix(trimmed, ">")
+		inner = append(inner, strings.TrimPrefix(trimmed, " "))
+	}
+	gutter := theme.CodeBorderStyle.Render("│ ")
+	rendered := strings.Split(Render(strings.Join(inner, "\n"), max(width-2, 0)), "\n")
+	for j, line := range rendered {
+		rendered[j] = gutter + line
+	}
+	return strings.Join(rendered, "\n"), i
+}
+
+// listItem is an item of a list with its nesting level
+type listItem struct {
+	level  int
+	marker string
+	text   string
+}
+
+// renderList renders consecutive list items, indented lines continuing the
+// item above them
+func renderList(lines []string, i, width int) (string, int) {
+	var items []listItem
+	for ; i < len(lines); i++ {
+		line := strings.ReplaceAll(lines[i], "\t", "    ")
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		parts := listItemPattern.FindStringSubmatch(line)
+		if parts == nil {
+			if line[0] != ' ' || len(items) == 0 {
+				break
+			}
+			items[len(items)-1].text += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		marker := parts[2]
+		if !isDigit(marker[0]) {
+			marker = "•"
+			if len(parts[1]) >= 2 {
+				marker = "◦"
+			}
+		}
+		text := parts[3]
+		if task := taskPattern.FindStringSubmatch(text); task != nil {
+			marker = "☐"
+			if task[1] != " " {
+				marker = "☑"
+			}
+			text = text[len(task[0]):]
+		}
+		items = append(items, listItem{level: len(parts[1]) / 2, marker: marker, text: text})
+	}
+
+	var out []string
+	for _, item := range items {
+		indent := strings.Repeat("  ", item.level)
+		hanging := indent + strings.Repeat(" ", ansi.StringWidth(item.marker)+1)
+		textWidth := 0
+		if width > 0 {
+			textWidth = max(width-ansi.StringWidth(hanging), 10)
+		}
+		var wrapped []string
+		for _, line := range strings.Split(item.text, "\n") {
+			wrapped = append(wrapped, wrap(inline(line), textWidth))
+		}
+		for j, line := range strings.Split(strings.Join(wrapped, "\n"), "\n") {
+			if j == 0 {
+				line = indent + item.marker + " " + line
+			} else {
+				line = hanging + line
+			}
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n"), i
+}
+
+// wrap breaks text at word boundaries to fit width columns, long words being
+// broken too. A width of 0 or less leaves the text as it is.
+func wrap(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return ansi.Wrap(s, width, "")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,141 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		width int
+		want  string
+	}{
+		{"plain text", "What is a goroutine?", 0, "What is a goroutine?"},
+		{"line breaks kept", "first\nsecond", 0, "first\nsecond"},
+		{"blank lines collapsed", "first\n\n\n\nsecond", 0, "first\n\nsecond"},
+		{"emphasis", "**bold**, *italic*, _also_ and ~~gone~~", 0, "bold, italic, also and gone"},
+		{"nested emphasis", "***both***", 0, "both"},
+		{"snake case", "call snake_case_name or 2 * 3 * 4", 0, "call snake_case_name or 2 * 3 * 4"},
+		{"unclosed emphasis", "a *b and __c", 0, "a *b and __c"},
+		{"code span", "use `fmt.Println(*p)` here", 0, "use fmt.Println(*p) here"},
+		{"escapes", `\*not italic\*`, 0, "*not italic*"},
+		{"link", "see [the spec](https://go.dev/ref/spec \"Spec\")", 0, "see the spec (https://go.dev/ref/spec)"},
+		{"bare link", "[https://go.dev](https://go.dev)", 0, "https://go.dev"},
+		{"image", "![diagram](a.png)", 0, "[image: diagram]"},
+		{"heading", "## Channels ##\ntext", 0, "Channels\ntext"},
+		{"bullets", "- one\n- two\n  - nested\n  continued", 0, "• one\n• two\n  ◦ nested\n    continued"},
+		{"ordered", "Steps:\n1. open\n2. close", 0, "Steps:\n1. open\n2. close"},
+		{"tasks", "- [x] done\n- [ ] todo", 0, "☑ done\n☐ todo"},
+		{"quote", "> quoted **text**\n> more", 0, "│ quoted text\n│ more"},
+		{"rule", "***", 0, strings.Repeat("─", 40)},
+		{"code block", "```go\nfunc main() {\n\t*p = 1\n}\n```\n\nafter", 0, "│ func main() {\n│     *p = 1\n│ }\n\nafter"},
+		{"unterminated code", "~~~\n# not a heading", 0, "│ # not a heading"},
+		{"wrapped paragraph", "the quick brown fox jumps", 10, "the quick\nbrown fox\njumps"},
+		{"wrapped list", "- the quick brown fox", 12, "• the quick\n  brown fox"},
+		{"long code line", "```\nabcdefghij\n```", 6, "│ abcd\n│ efgh\n│ ij"},
+		{"escape sequences kept", "\x1b[1m[...]\x1b[0m is *here*", 0, "\x1b[1m[...]\x1b[0m is here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src, tt.width); got != tt.want {
+				t.Errorf("Render(%q) =\n%s\nwant\n%s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	src := "| Op | Complexity |\n|:---|---:|\n| get | O(1) |\n| sort `n` | O(n log n) |"
+	want := "Op     │ Complexity\n" +
+		"───────┼───────────\n" +
+		"get    │       O(1)\n" +
+		"sort n │ O(n log n)"
+	if got := Render(src, 0); got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Too wide tables shrink their widest columns
+	got := Render(src, 16)
+	for _, line := range strings.Split(got, "\n") {
+		if w := ansi.StringWidth(line); w > 16 {
+			t.Errorf("line %q is %d columns wide, want at most 16", line, w)
+		}
+	}
+	if !strings.Contains(got, "O(n lo…") {
+		t.Errorf("Expected truncated cells, got\n%s", got)
+	}
+
+	// A pipe without a rule below is plain text
+	if got := Render("a | b", 0); got != "a | b" {
+		t.Errorf("Render() = %q, want plain text", got)
+	}
+}
+
+func TestPlain(t *testing.T) {
+	if got := Plain("  **raw** text here\n", 8); got != "**raw**\ntext\nhere" {
+		t.Errorf("Plain() = %q", got)
+	}
+}
+
+func TestHighlighterTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		lang  string
+		lines []string
+		want  [][]token
+	}{
+		{
+			name:  "go",
+			lang:  "go",
+			lines: []string{`if x := "a\"b"; x != 42 { // done`},
+			want: [][]token{{
+				{keywordToken, "if"}, {plainToken, " x := "}, {stringToken, `"a\"b"`}, {plainToken, "; x != "},
+				{numberToken, "42"}, {plainToken, " { "}, {commentToken, "// done"},
+			}},
+		},
+		{
+			name:  "block comment across lines",
+			lang:  "js",
+			lines: []string{"let a /* start", "end */ return"},
+			want: [][]token{
+				{{keywordToken, "let"}, {plainToken, " a "}, {commentToken, "/* start"}},
+				{{commentToken, "end */"}, {plainToken, " "}, {keywordToken, "return"}},
+			},
+		},
+		{
+			name:  "case insensitive sql",
+			lang:  "SQL",
+			lines: []string{"SELECT id FROM t -- all"},
+			want: [][]token{{
+				{keywordToken, "SELECT"}, {plainToken, " id "}, {keywordToken, "FROM"}, {plainToken, " t "}, {commentToken, "-- all"},
+			}},
+		},
+		{
+			name:  "identifiers with digits",
+			lang:  "python",
+			lines: []string{"x2 = 3.14 # pi"},
+			want:  [][]token{{{plainToken, "x2 = "}, {numberToken, "3.14"}, {plainToken, " "}, {commentToken, "# pi"}}},
+		},
+		{
+			name:  "unknown language",
+			lang:  "brainfuck",
+			lines: []string{"if 42"},
+			want:  [][]token{{{plainToken, "if 42"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHighlighter(tt.lang)
+			for i, line := range tt.lines {
+				if got := h.tokens(line); !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("tokens(%q) = %v, want %v", line, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package markdown
+
+import (
+	"strings"
+
+	"catv/internal/tui/theme"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// alignment is the alignment of a table column
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+// isTableStart reports whether lines[i] is the header row of a table: a row
+// of cells followed by a rule such as |---|:--:|
+func isTableStart(lines []string, i int) bool {
+	return i+1 < len(lines) && strings.Contains(lines[i], "|") &&
+		strings.Contains(lines[i+1], "-") && tableRulePattern.MatchString(lines[i+1]) &&
+		len(splitRow(lines[i])) == len(splitRow(lines[i+1]))
+}
+
+// splitRow returns the cells of a table row, \| escaping a pipe inside a cell
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// renderTable renders a table with aligned columns, shrinking the widest
+// columns and truncating their cells when it does not fit width
+func renderTable(lines []string, i, width int) (string, int) {
+	header := splitRow(lines[i])
+	aligns := make([]alignment, len(header))
+	for c, rule := range splitRow(lines[i+1]) {
+		switch {
+		case strings.HasPrefix(rule, ":") && strings.HasSuffix(rule, ":"):
+			aligns[c] = alignCenter
+		case strings.HasSuffix(rule, ":"):
+			aligns[c] = alignRight
+		}
+	}
+
+	rows := [][]string{header}
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		rows = append(rows, splitRow(lines[i]))
+	}
+	widths := make([]int, len(header))
+	for r, row := range rows {
+		// Rows are cut or padded to the header's columns
+		cells := make([]string, len(header))
+		for c := range cells {
+			if c < len(row) {
+				cells[c] = inline(row[c])
+			}
+			widths[c] = max(widths[c], ansi.StringWidth(cells[c]), 1)
+		}
+		rows[r] = cells
+	}
+
+	separator := " │ "
+	if width > 0 {
+		total := func() int {
+			sum := (len(widths) - 1) * ansi.StringWidth(separator)
+			for _, w := range widths {
+				sum += w
+			}
+			return sum
+		}
+		for total() > width {
+			widest := 0
+			for c, w := range widths {
+				if w > widths[widest] {
+					widest = c
+				}
+			}
+			if widths[widest] <= 3 {
+				break
+			}
+			widths[widest]--
+		}
+	}
+
+	border := theme.CodeBorderStyle
+	bold := lipgloss.NewStyle().Bold(true)
+	var out []string
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for c, cell := range row {
+			if ansi.StringWidth(cell) > widths[c] {
+				cell = ansi.Truncate(cell, widths[c], "…")
+			}
+			cell = pad(cell, widths[c], aligns[c])
+			if r == 0 {
+				cell = bold.Render(cell)
+			}
+			cells[c] = cell
+		}
+		out = append(out, strings.Join(cells, border.Render(separator)))
+		if r == 0 {
+			rules := make([]string, len(widths))
+			for c, w := range widths {
+				rules[c] = strings.Repeat("─", w)
+			}
+			out = append(out, border.Render(strings.Join(rules, "─┼─")))
+		}
+	}
+	return strings.Join(out, "\n"), i
+}
+
+// pad pads a cell with spaces to width columns
+func pad(s string, width int, align alignment) string {
+	gap := width - ansi.StringWidth(s)
+	if gap <= 0 {
+		return s
+	}
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", gap) + s
+	case alignCenter:
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+	return s + strings.Repeat(" ", gap)
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"time"
+
+	"catv/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Result is the grade given to a card during review
+type Result struct {
+	Index     int             // position of the card in the review
+	Flashcard store.Flashcard // card as it was before the grade
+	Scheduled store.Flashcard // card with its schedule after the grade
+	Correct   bool
+	RevisitIn int  // days until the next review, 0 while the card is learned in the session
+	Lapse     bool // a learned card was forgotten
+	Hints     int
+	TimedOut  bool
+	Duration  time.Duration // time spent answering, pauses excluded
+	Leech     bool          // the lapse made the card a leech
+	Suspend   bool          // the new leech is suspended
+}
+
+// Recorder saves grades as they are given, so an interrupted review keeps
+// every answer given before it stopped
+type Recorder interface {
+	Record(r Result) error
+	// Unrecord reverts Record when a grade is undone
+	Unrecord(r Result) error
+}
+
+// Marker changes the state of cards from the review. Its calls are saved in
+// order with the grades.
+type Marker interface {
+	Suspend(fc store.Flashcard) error
+	// Bury hides the card until the next day
+	Bury(fc store.Flashcard) error
+	Flag(fc store.Flashcard, flagged bool) error
+}
+
+// saveErrMsg reports a grade that could not be saved
+type saveErrMsg struct {
+	err error
+}
+
+// saver runs recorder calls one at a time in the order they were made, off
+// the UI loop
+type saver struct {
+	ops  chan func() error
+	errs chan error
+	done chan struct{}
+	err  error // first failure
+}
+
+func newSaver() *saver {
+	s := &saver{
+		ops:  make(chan func() error, 64),
+		errs: make(chan error, 1),
+		done: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *saver) run() {
+	defer close(s.done)
+	for op := range s.ops {
+		err := op()
+		if err == nil {
+			continue
+		}
+		if s.err == nil {
+			s.err = err
+		}
+		select {
+		case s.errs <- err:
+		default: // the UI still has a failure to show
+		}
+	}
+}
+
+// save queues a recorder call
+func (s *saver) save(op func() error) {
+	s.ops <- op
+}
+
+// close waits for queued calls and returns the first failure
+func (s *saver) close() error {
+	close(s.ops)
+	<-s.done
+	close(s.errs)
+	return s.err
+}
+
+// waitForSaveErr delivers the next failure to save a grade
+func waitForSaveErr(errs <-chan error) tea.Cmd {
+	return func() tea.Msg {
+		err, ok := <-errs
+		if !ok {
+			return nil
+		}
+		return saveErrMsg{err: err}
+	}
+}
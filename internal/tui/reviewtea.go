@@ -1,19 +1,35 @@
 package tui
 
 import (
+	"catv/internal/choice"
+	"catv/internal/grading"
+	"catv/internal/hint"
+	"catv/internal/notes"
+	"catv/internal/scheduler"
+	"catv/internal/stats"
 	"catv/internal/store"
+	"catv/internal/tui/components"
 	"catv/internal/tui/keys"
 	"catv/internal/tui/layout"
+	"catv/internal/tui/markdown"
 	"catv/internal/tui/theme"
+	"context"
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/timer"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // ReviewModel manages the state for the review session
@@ -27,8 +43,18 @@ const (
 	viewRevisitIn
 	viewDone
 	viewTimeout
+	viewChoiceResult
+	viewChat
+	viewEdit
 )
 
+// revisitDays are the intervals offered after a correct answer, picked with
+// the matching number key
+var revisitDays = []int{1, 3, 7, 9}
+
+// choiceKeys selects multiple-choice options by position
+var choiceKeys = []string{keys.One, keys.Two, keys.Three, keys.Four}
+
 var completionMessages = []string{
 	"The Void retreats… for now 🕳️🐾",
 	"Knowledge absorbed. The Void purrs in approval 😼",
@@ -51,36 +77,277 @@ type ReviewModel struct {
 	height        int
 	progress      progress.Model
 	timer         timer.Model
-	startTime     time.Time
-	duration      time.Duration
+	duration      time.Duration // answer time of the current card, 0 when untimed
 	interval      time.Duration // add interval for timer ticks
+	timerFor      func(store.Flashcard) time.Duration
+	paused        bool
+	timedOut      []bool // whether each card ran out of time before being answered
 	completionMsg string
+	choices       []choice.Set // multiple-choice options per card, empty for self-graded cards
+	picked        []int        // option chosen for each multiple-choice card, -1 on timeout
+	typed         bool         // answers are typed and auto-graded
+	input         textinput.Model
+	grade         grading.Result // comparison of the current typed answer
+
+	grader        Grader // optional model grading of typed answers
+	graderTimeout time.Duration
+	grading       bool             // waiting for the grader's verdict on the current card
+	verdict       *grading.Verdict // grader's verdict on the current card
+	verdictErr    error            // grader failure, the user grades themselves
+	spinner       spinner.Model
+
+	chat       Chat                         // optional model used to explain cards
+	excerpt    func(store.Flashcard) string // source note excerpt of a card
+	chatPane   *chatPane
+	chatReturn viewState // view to return to when the chat is closed
+
+	saveCard   func(store.Flashcard) error // optional store of edited cards
+	editor     *cardEditor
+	editReturn viewState // view to return to when the editor is closed
+
+	hints       []int    // hints revealed for each card
+	hintTexts   []string // hints revealed for the current card
+	hinter      Hinter   // optional model-written nudge, the strongest hint
+	hintTimeout time.Duration
+	hintLoading bool
+
+	undo []gradedCard // graded cards, most recent last
+
+	learning   scheduler.Steps // learning steps of failed new cards
+	relearning scheduler.Steps // learning steps of failed learned cards
+	repeats    []repeat        // learning state of each card in the queue
+	lastRepeat int             // id of the last repeat queued
+	now        func() time.Time
+
+	leechThreshold int  // lapses that make a card a leech, 0 to not detect them
+	suspendLeeches bool // suspend cards when they become leeches
+
+	recorder Recorder // optional store of grades as they are given
+	marker   Marker   // optional store of card states set during review
+	notice   string   // confirms the last card state change
+	saver    *saver
+	saveErr  error // last failure to save a grade
+
+	deferred int               // due cards left for tomorrow by the daily limits
+	goal     *stats.GoalStatus // progress towards the daily goal before the review, nil to not show it
+	cram     bool              // cards are drilled without being rescheduled
+	plain    bool              // cards are shown as written, without rendering their markdown
+
+	started    time.Time // when the review started
+	shownAt    time.Time // when the current card was asked, moved forward by pauses
+	pausedAt   time.Time
+	summaryTop int // first card listed on the summary
 }
 
-func NewReviewModel(flashcards []store.Flashcard) *ReviewModel {
-	// Use a custom gradient for the progress bar
-	d := 30 * time.Second
-	interval := 100 * time.Millisecond // smoother animation
-	p := progress.New(progress.WithGradient("#ff00e1ff", "#ff00e1ff"))
-	p.ShowPercentage = false
-	return &ReviewModel{
+// gradedCard keeps what is needed to show a graded card's answer again when
+// its grade is undone
+type gradedCard struct {
+	idx        int
+	result     Result
+	requeued   int // id of the repeat queued by the grade, 0 if none
+	input      string
+	grade      grading.Result
+	verdict    *grading.Verdict
+	verdictErr error
+	hintTexts  []string
+}
+
+// Hinter asks a model for a nudge towards the answer that does not give it away
+type Hinter func(ctx context.Context, question, answer string) (string, error)
+
+// hintMsg delivers the model's nudge for the card at idx
+type hintMsg struct {
+	idx  int
+	text string
+	err  error
+}
+
+// Grader asks a model to grade a typed answer. It is run asynchronously so
+// the review stays responsive.
+type Grader func(ctx context.Context, question, expected, given string) (grading.Verdict, error)
+
+// verdictMsg delivers the grader's result for the card at idx
+type verdictMsg struct {
+	idx     int
+	verdict grading.Verdict
+	err     error
+}
+
+// ReviewOption configures a ReviewModel
+type ReviewOption func(*ReviewModel)
+
+// WithChoices reviews cards as multiple choice. choices is aligned with the
+// flashcards; cards whose set is not valid fall back to self-grading.
+func WithChoices(choices []choice.Set) ReviewOption {
+	return func(m *ReviewModel) {
+		m.choices = choices
+	}
+}
+
+// WithTypedAnswers asks for the answer in a text input and suggests a grade
+// by comparing it with the stored answer
+func WithTypedAnswers() ReviewOption {
+	return func(m *ReviewModel) {
+		m.typed = true
+	}
+}
+
+// WithGrader grades typed answers with a model, falling back to self-grading
+// when it fails or takes longer than timeout. It implies typed answers.
+func WithGrader(grader Grader, timeout time.Duration) ReviewOption {
+	return func(m *ReviewModel) {
+		m.typed = true
+		m.grader = grader
+		m.graderTimeout = timeout
+	}
+}
+
+// WithExplainer lets the user ask the model about a card from the answer
+// view. excerpt returns the part of the card's note given as context.
+func WithExplainer(chat Chat, excerpt func(store.Flashcard) string) ReviewOption {
+	return func(m *ReviewModel) {
+		m.chat = chat
+		m.excerpt = excerpt
+	}
+}
+
+// WithTimer sets the answer time of each card, 0 disabling the timer for it.
+// Cards are timed at 30 seconds otherwise.
+func WithTimer(timerFor func(store.Flashcard) time.Duration) ReviewOption {
+	return func(m *ReviewModel) {
+		m.timerFor = timerFor
+	}
+}
+
+// WithHinter adds a model-written nudge as the last hint, given up after timeout
+func WithHinter(hinter Hinter, timeout time.Duration) ReviewOption {
+	return func(m *ReviewModel) {
+		m.hinter = hinter
+		m.hintTimeout = timeout
+	}
+}
+
+// WithRecorder saves every grade as soon as it is given, and reverts it when
+// it is undone. Close must be called once the review ends.
+func WithRecorder(recorder Recorder) ReviewOption {
+	return func(m *ReviewModel) {
+		m.recorder = recorder
+		if m.saver == nil {
+			m.saver = newSaver()
+		}
+	}
+}
+
+// WithMarker lets the current card be suspended, buried or flagged during
+// review. Close must be called once the review ends.
+func WithMarker(marker Marker) ReviewOption {
+	return func(m *ReviewModel) {
+		m.marker = marker
+		if m.saver == nil {
+			m.saver = newSaver()
+		}
+	}
+}
+
+// WithDeferred tells on the done screen how many due cards the daily limits
+// left for tomorrow
+func WithDeferred(n int) ReviewOption {
+	return func(m *ReviewModel) {
+		m.deferred = n
+	}
+}
+
+// WithCram drills cards without rescheduling them: correct answers move on
+// without asking when to revisit the card
+func WithCram() ReviewOption {
+	return func(m *ReviewModel) {
+		m.cram = true
+	}
+}
+
+// WithPlainText shows questions and answers as written instead of rendering
+// their markdown
+func WithPlainText() ReviewOption {
+	return func(m *ReviewModel) {
+		m.plain = true
+	}
+}
+
+func NewReviewModel(flashcards []store.Flashcard, opts ...ReviewOption) *ReviewModel {
+	m := &ReviewModel{
 		flashcards: flashcards,
 		current:    0,
 		view:       viewQuestion,
 		correct:    make([]bool, len(flashcards)),
 		revisitIn:  make([]int, len(flashcards)),
-		progress:   p,
-		timer:      timer.NewWithInterval(d, interval),
-		startTime:  time.Now(),
-		duration:   d,
-		interval:   interval,
+		picked:     make([]int, len(flashcards)),
+		hints:      make([]int, len(flashcards)),
+		input:      newAnswerInput(),
+		spinner:    spinner.New(spinner.WithSpinner(spinner.Dot)),
+		timedOut:   make([]bool, len(flashcards)),
+		repeats:    make([]repeat, len(flashcards)),
+		now:        time.Now,
+		interval:   100 * time.Millisecond, // smoother animation
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.started = m.now()
+	m.shownAt = m.started
+	m.resetTimer()
+	return m
 }
 
-func (m *ReviewModel) Init() tea.Cmd {
+// defaultTimer is the answer time of cards when no timer is configured
+const defaultTimer = 30 * time.Second
+
+// timedOutMaxRevisitIn caps the interval of cards answered after the timer ran
+// out, slow recall being weaker than a quick answer
+const timedOutMaxRevisitIn = 3
+
+// resetTimer starts the answer timer of the current card
+func (m *ReviewModel) resetTimer() tea.Cmd {
+	m.duration = defaultTimer
+	if m.timerFor != nil && m.current < len(m.flashcards) {
+		m.duration = m.timerFor(m.flashcards[m.current])
+	}
+	m.paused = false
+	// Use a custom gradient for the progress bar
+	m.progress = progress.New(progress.WithGradient("#ff00e1ff", "#ff00e1ff"))
+	m.progress.ShowPercentage = false
+	m.timer = timer.NewWithInterval(m.duration, m.interval)
+	if m.duration <= 0 {
+		return nil
+	}
 	return m.timer.Init()
 }
 
+func newAnswerInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Type your answer"
+	ti.Focus()
+	return ti
+}
+
+func (m *ReviewModel) Init() tea.Cmd {
+	var cmds []tea.Cmd
+	if m.duration > 0 {
+		cmds = append(cmds, m.timer.Init())
+	}
+	if m.saver != nil {
+		cmds = append(cmds, waitForSaveErr(m.saver.errs))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Close waits for grades still being saved and returns the first failure
+func (m *ReviewModel) Close() error {
+	if m.saver == nil {
+		return nil
+	}
+	return m.saver.close()
+}
+
 func (m *ReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
@@ -90,56 +357,172 @@ func (m *ReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 	case timer.TimeoutMsg:
-		m.view = viewAnswer
-		return m, tea.Batch(cmds...)
+		if m.view != viewQuestion || m.duration <= 0 {
+			// The answer is already showing, the timer only limits thinking time
+			return m, nil
+		}
+		m.timedOut[m.current] = true
+		switch {
+		case m.isChoice():
+			// Running out of time on a multiple-choice card counts as a wrong pick
+			m.pickChoice(-1)
+		case m.typed:
+			// Grade whatever was typed before the time ran out
+			return m, m.submitTyped()
+		default:
+			m.view = viewAnswer
+		}
+		return m, nil
+	case verdictMsg:
+		// Ignore late verdicts for cards that were already graded
+		if msg.idx == m.current && m.grading {
+			m.grading = false
+			if msg.err != nil {
+				m.verdictErr = msg.err
+			} else {
+				m.verdict = &msg.verdict
+			}
+		}
+		return m, nil
+	case saveErrMsg:
+		m.saveErr = msg.err
+		return m, waitForSaveErr(m.saver.errs)
+	case hintMsg:
+		if msg.idx == m.current && m.hintLoading {
+			m.hintLoading = false
+			if msg.err != nil || msg.text == "" {
+				m.hintTexts = append(m.hintTexts, "The model has no hint right now")
+			} else {
+				m.hintTexts = append(m.hintTexts, msg.text)
+			}
+		}
+		return m, nil
+	case chatTokenMsg, chatDoneMsg:
+		if m.chatPane != nil {
+			_, cmd = m.chatPane.update(msg)
+		}
+		return m, cmd
+	case spinner.TickMsg:
+		if !m.grading && !m.hintLoading {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	case tea.KeyMsg:
+		if m.view == viewChat {
+			if msg.String() == keys.CtrlC {
+				m.chatPane.close()
+				m.quitting = true
+				return m, tea.Quit
+			}
+			closed, cmd := m.chatPane.update(msg)
+			if closed {
+				m.chatPane = nil
+				m.view = m.chatReturn
+			}
+			return m, cmd
+		}
+		if m.view == viewEdit {
+			return m.updateEditor(msg)
+		}
+		if msg.String() == keys.X && m.chat != nil && (m.view == viewAnswer || m.view == viewChoiceResult) {
+			return m, m.openChat()
+		}
+		if msg.String() == keys.E && m.canEdit() {
+			m.openEditor()
+			return m, nil
+		}
+		if m.view == viewQuestion && m.typed {
+			// Every key goes to the input so answers can contain "q"
+			switch key := msg.String(); {
+			case key == keys.CtrlC:
+				m.quitting = true
+				return m, tea.Quit
+			case key == keys.CtrlP:
+				cmds = append(cmds, m.togglePause())
+			case key == keys.CtrlZ:
+				m.undoGrade()
+			case m.paused:
+				// Nothing can be typed while the question is hidden
+			case key == keys.Tab:
+				cmds = append(cmds, m.nextHint())
+			case key == keys.Enter:
+				cmds = append(cmds, m.submitTyped())
+			default:
+				m.input, cmd = m.input.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+			return m, tea.Batch(cmds...)
+		}
 		if msg.String() == keys.Q {
 			m.quitting = true
 			return m, tea.Quit
 		}
+		if msg.String() == keys.U && m.canUndo() {
+			m.undoGrade()
+			return m, nil
+		}
+		if m.canMark() {
+			switch msg.String() {
+			case keys.S:
+				return m, m.dropCard(true)
+			case keys.Z:
+				return m, m.dropCard(false)
+			case keys.F:
+				m.toggleFlag()
+				return m, nil
+			}
+		}
 		switch m.view {
 		case viewQuestion:
-			if msg.String() == keys.Enter {
+			if msg.String() == keys.P {
+				cmds = append(cmds, m.togglePause())
+			} else if m.paused {
+				// The question stays hidden until the timer is resumed
+			} else if msg.String() == keys.Tab {
+				cmds = append(cmds, m.nextHint())
+			} else if m.isChoice() {
+				for i, k := range choiceKeys {
+					if msg.String() == k && i < len(m.choices[m.current].Options) {
+						m.pickChoice(i)
+					}
+				}
+			} else if msg.String() == keys.Enter {
 				m.view = viewAnswer
 			}
+		case viewChoiceResult:
+			if msg.String() == keys.Enter {
+				if m.correct[m.current] {
+					cmds = append(cmds, m.passCard())
+				} else {
+					cmd = m.nextCard()
+					cmds = append(cmds, cmd)
+				}
+			}
 		case viewAnswer:
-			if msg.String() == keys.C {
+			switch {
+			case msg.String() == keys.C, msg.String() == keys.Enter && m.typed && m.suggestCorrect():
 				m.correct[m.current] = true
-				m.view = viewRevisitIn
-			} else if msg.String() == keys.I {
+				cmds = append(cmds, m.passCard())
+			case msg.String() == keys.I, msg.String() == keys.Enter && m.typed:
 				m.correct[m.current] = false
-				m.resultMsg = "Marked incorrect. Card will not be scheduled for repetition."
 				cmd = m.nextCard()
 				cmds = append(cmds, cmd)
 			}
 		case viewRevisitIn:
-			switch msg.String() {
-			case keys.One:
-				m.revisitIn[m.current] = 1
-				m.resultMsg = "Revisit in 1 day"
-				cmd = m.nextCard()
-				cmds = append(cmds, cmd)
-			case keys.Three:
-				m.revisitIn[m.current] = 3
-				m.resultMsg = "Revisit in 3 days"
-				cmd = m.nextCard()
-				cmds = append(cmds, cmd)
-			case keys.Seven:
-				m.revisitIn[m.current] = 7
-				m.resultMsg = "Revisit in 7 days"
-				cmd = m.nextCard()
-				cmds = append(cmds, cmd)
-			case keys.Nine:
-				m.revisitIn[m.current] = 9
-				m.resultMsg = "Revisit in 9 days"
-				cmd = m.nextCard()
-				cmds = append(cmds, cmd)
+			for _, days := range m.revisitChoices() {
+				if msg.String() == strconv.Itoa(days) {
+					m.revisitIn[m.current] = days
+					m.resultMsg = fmt.Sprintf("Revisit in %d days", days)
+					if days == 1 {
+						m.resultMsg = "Revisit in 1 day"
+					}
+					cmd = m.nextCard()
+					cmds = append(cmds, cmd)
+				}
 			}
 		case viewDone:
-			if msg.String() == keys.Q {
-				m.quitting = true
-				return m, tea.Quit
-			}
+			m.scrollSummary(msg.String())
 		}
 	case tea.QuitMsg:
 		m.quitting = true
@@ -149,28 +532,101 @@ func (m *ReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 	case timer.TickMsg:
+		m.timer, cmd = m.timer.Update(msg)
+		cmds = append(cmds, cmd)
 		// Animate progress bar on every timer tick
-		if m.view == viewQuestion {
-			elapsed := time.Since(m.startTime)
-			percent := float64(elapsed) / float64(m.duration)
-			if percent < 0 {
-				percent = 0
-			}
-			if percent > 1.0 {
-				percent = 1.0
-			}
-			cmd = m.progress.SetPercent(percent)
+		if m.view == viewQuestion && m.duration > 0 {
+			percent := 1 - float64(m.timer.Timeout)/float64(m.duration)
+			cmd = m.progress.SetPercent(math.Min(math.Max(percent, 0), 1))
 			cmds = append(cmds, cmd)
 		}
-		m.timer, cmd = m.timer.Update(msg)
-		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
+	case timer.StartStopMsg:
+		m.timer, cmd = m.timer.Update(msg)
+		return m, cmd
 	}
 	return m, tea.Batch(cmds...)
 }
 
 func (m *ReviewModel) nextCard() tea.Cmd {
+	revisitIn, lapse, next := m.schedule()
+	m.revisitIn[m.current] = revisitIn
+	scheduled := m.flashcards[m.current]
+	scheduled.RevisitIn = revisitIn
+	switch {
+	case revisitIn > 0:
+		scheduled.DueAt = scheduler.DueAt(m.now(), revisitIn)
+	case scheduled.IsNew():
+		// A new card stays new until it graduates from its learning steps, so
+		// failing it again after an interrupted review is not a lapse
+	default:
+		scheduled.DueAt = m.now()
+	}
+	if lapse {
+		scheduled.Lapses++
+	}
+	leech := lapse && m.isNewLeech(scheduled, scheduled.Lapses)
+	suspend := leech && m.suspendLeeches
+	if suspend {
+		// A suspended leech is not relearned in the session
+		next = nil
+	}
+	result := Result{
+		Index:     m.current,
+		Flashcard: m.flashcards[m.current],
+		Scheduled: scheduled,
+		Correct:   m.correct[m.current],
+		RevisitIn: revisitIn,
+		Lapse:     lapse,
+		Hints:     m.hints[m.current],
+		TimedOut:  m.timedOut[m.current],
+		Duration:  m.answerTime(),
+		Leech:     leech,
+		Suspend:   suspend,
+	}
+	var requeued int
+	if next != nil {
+		m.requeue(scheduled, next)
+		requeued = next.id
+	}
+	if m.recorder != nil {
+		recorder := m.recorder
+		m.saver.save(func() error { return recorder.Record(result) })
+	}
+	m.undo = append(m.undo, gradedCard{
+		idx:        m.current,
+		result:     result,
+		requeued:   requeued,
+		input:      m.input.Value(),
+		grade:      m.grade,
+		verdict:    m.verdict,
+		verdictErr: m.verdictErr,
+		hintTexts:  m.hintTexts,
+	})
 	m.current++
+	cmd := m.showCurrent()
+	if leech {
+		m.notice = leechNotice(scheduled.Lapses, suspend)
+	}
+	return cmd
+}
+
+// answerTime is the time spent on the current card since it was asked, pauses
+// excluded and capped at maxAnswerTime
+func (m *ReviewModel) answerTime() time.Duration {
+	elapsed := m.now().Sub(m.shownAt)
+	switch {
+	case elapsed < 0:
+		return 0
+	case elapsed > maxAnswerTime:
+		return maxAnswerTime
+	}
+	return elapsed
+}
+
+// showCurrent asks the current card, or ends the review when none is left
+func (m *ReviewModel) showCurrent() tea.Cmd {
+	m.notice = ""
 	if m.current >= len(m.flashcards) {
 		m.view = viewDone
 		b := make([]byte, 4)
@@ -182,17 +638,418 @@ func (m *ReviewModel) nextCard() tea.Cmd {
 		}
 		return nil
 	}
+	m.pickNext()
 	m.view = viewQuestion
+	m.shownAt = m.now()
 	m.resultMsg = ""
-	m.duration = 30 * time.Second
-	m.startTime = time.Now()
-	m.timer = timer.NewWithInterval(m.duration, m.interval)
-	m.progress = progress.New(progress.WithGradient("#ff00e1ff", "#ff00e1ff"))
-	m.progress.ShowPercentage = false
-	return m.timer.Init()
+	m.input.Reset()
+	m.grading = false
+	m.verdict = nil
+	m.verdictErr = nil
+	m.hintTexts = nil
+	m.hintLoading = false
+	return m.resetTimer()
+}
+
+// passCard moves on from a correct answer: a new or due card asks when to
+// revisit it, a card being learned or crammed goes to its next step
+func (m *ReviewModel) passCard() tea.Cmd {
+	if m.cram || m.repeats[m.current].id != 0 {
+		return m.nextCard()
+	}
+	m.view = viewRevisitIn
+	return nil
+}
+
+// undoGrade returns to the answer of the last graded card so it can be graded
+// again, clearing its result. On the revisit prompt the card being graded is
+// taken back instead.
+func (m *ReviewModel) undoGrade() {
+	if m.view == viewRevisitIn {
+		m.correct[m.current] = false
+		m.resultMsg = ""
+		m.view = viewAnswer
+		return
+	}
+	if len(m.undo) == 0 {
+		return
+	}
+	last := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+	if m.recorder != nil {
+		recorder := m.recorder
+		m.saver.save(func() error { return recorder.Unrecord(last.result) })
+	}
+
+	m.current = last.idx
+	if last.requeued != 0 {
+		m.dequeue(last.requeued)
+	}
+	m.correct[m.current] = false
+	m.revisitIn[m.current] = 0
+	m.input.SetValue(last.input)
+	m.grade = last.grade
+	m.grading = false
+	m.verdict = last.verdict
+	m.verdictErr = last.verdictErr
+	m.hintTexts = last.hintTexts
+	m.hintLoading = false
+	m.paused = false
+	// Time spent regrading adds to the time already spent on the card
+	m.shownAt = m.now().Add(-last.result.Duration)
+	m.resultMsg = "Grade undone, grade the card again."
+	// Multiple-choice cards are graded by hand too so a wrong pick can be overridden
+	m.view = viewAnswer
+}
+
+// canUndo reports whether there is a grade to take back from the current view
+func (m *ReviewModel) canUndo() bool {
+	switch m.view {
+	case viewRevisitIn:
+		return true
+	case viewQuestion, viewAnswer, viewDone:
+		return len(m.undo) > 0
+	}
+	return false
+}
+
+// canMark reports whether the current card's state can be changed: while it
+// is asked or answered, but not while an answer is being typed
+func (m *ReviewModel) canMark() bool {
+	switch m.view {
+	case viewQuestion:
+		return m.marker != nil && !m.paused && !m.typed
+	case viewAnswer, viewChoiceResult:
+		return m.marker != nil
+	}
+	return false
+}
+
+// dropCard suspends or buries the current card and takes it out of the
+// session, along with the repeats queued for it
+func (m *ReviewModel) dropCard(suspend bool) tea.Cmd {
+	fc, marker := m.flashcards[m.current], m.marker
+	notice := "Card buried until tomorrow."
+	if suspend {
+		m.saver.save(func() error { return marker.Suspend(fc) })
+		notice = "Card suspended."
+	} else {
+		m.saver.save(func() error { return marker.Bury(fc) })
+	}
+	for i := len(m.flashcards) - 1; i >= m.current; i-- {
+		if m.flashcards[i].ID == fc.ID {
+			m.removeEntry(i)
+		}
+	}
+	cmd := m.showCurrent()
+	m.notice = notice
+	return cmd
+}
+
+// toggleFlag flags the current card, or clears its flag
+func (m *ReviewModel) toggleFlag() {
+	fc, marker := m.flashcards[m.current], m.marker
+	flagged := !fc.Flagged
+	m.saver.save(func() error { return marker.Flag(fc, flagged) })
+	for i := range m.flashcards {
+		if m.flashcards[i].ID == fc.ID {
+			m.flashcards[i].Flagged = flagged
+		}
+	}
+	m.notice = "Card flagged."
+	if !flagged {
+		m.notice = "Flag removed."
+	}
+}
+
+// flagMark marks the header of a flagged card
+func (m *ReviewModel) flagMark() string {
+	if m.flashcards[m.current].Flagged {
+		return " ⚑"
+	}
+	return ""
+}
+
+// togglePause stops or resumes the answer timer
+func (m *ReviewModel) togglePause() tea.Cmd {
+	if m.duration <= 0 {
+		return nil
+	}
+	m.paused = !m.paused
+	if m.paused {
+		m.pausedAt = m.now()
+		return m.timer.Stop()
+	}
+	m.shownAt = m.shownAt.Add(m.now().Sub(m.pausedAt))
+	return m.timer.Start()
+}
+
+// isChoice reports whether the current card is asked as multiple choice
+func (m *ReviewModel) isChoice() bool {
+	return m.current < len(m.choices) && m.choices[m.current].Valid()
+}
+
+// pickChoice grades the current multiple-choice card, idx -1 meaning no pick
+func (m *ReviewModel) pickChoice(idx int) {
+	set := m.choices[m.current]
+	m.picked[m.current] = idx
+	m.correct[m.current] = idx == set.Correct
+	if m.correct[m.current] {
+		m.resultMsg = theme.SuccessStyle.Render("Correct!")
+	} else {
+		m.resultMsg = theme.ErrorStyle.Render("Incorrect. You will see this card again.")
+	}
+	m.view = viewChoiceResult
+}
+
+// submitTyped compares the typed answer with the stored one and shows the
+// answer with the suggested grade
+func (m *ReviewModel) submitTyped() tea.Cmd {
+	fc := m.flashcards[m.current]
+	given := m.input.Value()
+	m.grade = grading.Grade(fc.Answer, given)
+	m.view = viewAnswer
+	if m.grader == nil {
+		return nil
+	}
+
+	m.grading = true
+	idx, grader, timeout := m.current, m.grader, m.graderTimeout
+	question := fc.Question
+	if fc.IsCloze() {
+		question = notes.RenderCloze(fc.Question, fc.Ordinal, false, nil)
+	}
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		verdict, err := grader(ctx, question, fc.Answer, given)
+		return verdictMsg{idx: idx, verdict: verdict, err: err}
+	})
+}
+
+// suggestCorrect is the grade accepted with Enter in typed mode: the model's
+// verdict when there is one, otherwise the fuzzy match
+func (m *ReviewModel) suggestCorrect() bool {
+	if m.verdict != nil {
+		return m.verdict.Correct()
+	}
+	return m.grade.Correct
+}
+
+// typedView shows the typed answer diffed against the stored one with the
+// suggested grade
+func (m *ReviewModel) typedView() string {
+	var diff strings.Builder
+	for _, seg := range m.grade.Diff {
+		switch seg.Op {
+		case grading.Missing:
+			diff.WriteString(theme.DiffMissingStyle.Render(seg.Text))
+		case grading.Extra:
+			diff.WriteString(theme.DiffExtraStyle.Render(seg.Text))
+		default:
+			diff.WriteString(seg.Text)
+		}
+	}
+
+	suggestion := theme.ErrorStyle.Render("incorrect")
+	if m.suggestCorrect() {
+		suggestion = theme.SuccessStyle.Render("correct")
+	}
+	view := fmt.Sprintf("%s\n%s\n\n%s %.0f%% similar",
+		theme.LabelStyle.Render("Your answer:"), diff.String(),
+		theme.InfoStyle.Render("Match:"), m.grade.Score*100)
+
+	switch {
+	case m.grading:
+		view += fmt.Sprintf("\n%s Asking the model to grade your answer...", m.spinner.View())
+	case m.verdict != nil:
+		style := theme.ErrorStyle
+		switch m.verdict.Grade {
+		case grading.GradeCorrect:
+			style = theme.SuccessStyle
+		case grading.GradePartial:
+			style = theme.ClozeStyle
+		}
+		view += fmt.Sprintf("\n%s %s", theme.InfoStyle.Render("Model verdict:"), style.Render(m.verdict.Grade))
+		if m.verdict.Feedback != "" {
+			view += "\n" + m.verdict.Feedback
+		}
+	case m.verdictErr != nil:
+		view += "\n" + theme.InfoStyle.Render("Model grading unavailable, grade yourself")
+	}
+	return view + "\nSuggested grade: " + suggestion
+}
+
+// nextHint reveals the next, stronger hint for the current card
+func (m *ReviewModel) nextHint() tea.Cmd {
+	if m.hintLoading {
+		return nil
+	}
+	fc := m.flashcards[m.current]
+	switch m.hints[m.current] + 1 {
+	case hint.LevelLetter:
+		m.hintTexts = append(m.hintTexts, hint.Letter(fc.Answer))
+	case hint.LevelShape:
+		m.hintTexts = append(m.hintTexts, hint.Shape(fc.Answer))
+	case hint.LevelNudge:
+		if m.hinter == nil {
+			return nil
+		}
+		m.hintLoading = true
+		idx, hinter, timeout := m.current, m.hinter, m.hintTimeout
+		question := fc.Question
+		if fc.IsCloze() {
+			question = notes.RenderCloze(fc.Question, fc.Ordinal, false, nil)
+		}
+		m.hints[m.current]++
+		return tea.Batch(m.spinner.Tick, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			text, err := hinter(ctx, question, fc.Answer)
+			return hintMsg{idx: idx, text: text, err: err}
+		})
+	default:
+		return nil
+	}
+	m.hints[m.current]++
+	return nil
+}
+
+// hintsView lists the hints revealed for the current card
+func (m *ReviewModel) hintsView() string {
+	var lines []string
+	for _, h := range m.hintTexts {
+		lines = append(lines, theme.InfoStyle.Render("💡 "+h))
+	}
+	if m.hintLoading {
+		lines = append(lines, m.spinner.View()+" Thinking of a hint...")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// revisitChoices are the intervals allowed for the current card, capped when
+// hints were used or the timer ran out
+func (m *ReviewModel) revisitChoices() []int {
+	limit := hint.MaxRevisitIn(m.hints[m.current])
+	if m.timedOut[m.current] && (limit == 0 || limit > timedOutMaxRevisitIn) {
+		limit = timedOutMaxRevisitIn
+	}
+	if limit == 0 {
+		return revisitDays
+	}
+	var allowed []int
+	for _, days := range revisitDays {
+		if days <= limit {
+			allowed = append(allowed, days)
+		}
+	}
+	return allowed
+}
+
+// openChat starts a conversation with the model about the current card,
+// seeded with the card, its source excerpt and the user's answer
+func (m *ReviewModel) openChat() tea.Cmd {
+	fc := m.flashcards[m.current]
+	question := fc.Question
+	if fc.IsCloze() {
+		question = notes.RenderCloze(fc.Question, fc.Ordinal, false, nil)
+	}
+
+	var system strings.Builder
+	system.WriteString("You are a patient tutor helping a student review a flashcard. ")
+	system.WriteString("Explain the answer clearly and concisely, using the source notes when relevant, ")
+	system.WriteString("and answer follow-up questions about the topic.\n\n")
+	fmt.Fprintf(&system, "Question: %s\nCorrect answer: %s\n", question, fc.Answer)
+	if answer := m.userAnswer(); answer != "" {
+		fmt.Fprintf(&system, "Student's answer: %s\n", answer)
+	}
+	if m.excerpt != nil {
+		if excerpt := m.excerpt(fc); excerpt != "" {
+			fmt.Fprintf(&system, "\nSource notes:\n%s\n", excerpt)
+		}
+	}
+
+	m.chatPane = newChatPane(m.chat, system.String())
+	m.chatReturn = m.view
+	m.view = viewChat
+	return m.chatPane.send("Explain this card. If my answer was wrong, explain why.")
+}
+
+// userAnswer is what the user answered for the current card, empty when the
+// card is self-graded
+func (m *ReviewModel) userAnswer() string {
+	switch {
+	case m.isChoice():
+		if idx := m.picked[m.current]; idx >= 0 {
+			return m.choices[m.current].Options[idx]
+		}
+		return "(ran out of time)"
+	case m.typed:
+		return m.input.Value()
+	}
+	return ""
+}
+
+// choicesView lists the options of the current card, marking the answer and
+// the pick once the card has been graded
+func (m *ReviewModel) choicesView(graded bool) string {
+	set := m.choices[m.current]
+	var b strings.Builder
+	for i, option := range set.Options {
+		line := fmt.Sprintf("[%d] %s", i+1, option)
+		switch {
+		case graded && i == set.Correct:
+			line = theme.SuccessStyle.Render("✅ " + line)
+		case graded && i == m.picked[m.current]:
+			line = theme.ErrorStyle.Render("❌ " + line)
+		case graded:
+			line = "   " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
 }
 
 // Results API for review command
+
+// FlashcardHints returns the number of hints revealed for the card at idx
+func (m *ReviewModel) FlashcardHints(idx int) int {
+	if idx < 0 || idx >= len(m.hints) {
+		return 0
+	}
+	return m.hints[idx]
+}
+
+// Results returns the grades given during the review, in order. A card
+// failed and asked again has a result for each time it was graded.
+func (m *ReviewModel) Results() []Result {
+	results := make([]Result, len(m.undo))
+	for i, graded := range m.undo {
+		results[i] = graded.result
+	}
+	return results
+}
+
+// Pending returns the cards left to grade in the review, in queue order,
+// failed cards queued again included. It is empty once the review is done.
+func (m *ReviewModel) Pending() []store.Flashcard {
+	if m.current >= len(m.flashcards) {
+		return nil
+	}
+	return slices.Clone(m.flashcards[m.current:])
+}
+
+// FlashcardTimedOut reports whether the timer ran out before the card at idx
+// was answered
+func (m *ReviewModel) FlashcardTimedOut(idx int) bool {
+	return idx >= 0 && idx < len(m.timedOut) && m.timedOut[idx]
+}
+
+// FlashcardWasAnswered reports whether the card at idx was graded during the session
+func (m *ReviewModel) FlashcardWasAnswered(idx int) bool {
+	return idx >= 0 && idx < len(m.flashcards) && idx < m.current
+}
+
 func (m *ReviewModel) FlashcardWasCorrect(idx int) bool {
 	if idx < 0 || idx >= len(m.correct) {
 		return false
@@ -246,19 +1103,179 @@ func (m *ReviewModel) View() string {
 		fmt.Sprintf("❌ %d", incorrectCount))
 
 	exitMsg := theme.InfoStyle.Render("Enter: Confirm • q: Quit")
+	textWidth := layout.CalculateTextWidth(width)
 
 	var content string
 	switch m.view {
 	case viewQuestion:
-		// Animated progress bar for countdown
-		progressBar := m.progress.View()
-		content = fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s", theme.QuestionStyle.Render("Question:"), m.flashcards[m.current].Question, progressBar, bottomBar)
+		question := renderCardText(questionText(m.flashcards[m.current]), textWidth, m.plain)
+		if learning := m.learningView(); learning != "" {
+			question = theme.InfoStyle.Render(learning) + "\n\n" + question
+		}
+		if hints := m.hintsView(); hints != "" {
+			question += "\n\n" + hints
+		}
+		confirm, pauseKey, quit := "Enter: Confirm", keys.P, keys.Q
+		switch {
+		case m.isChoice():
+			question += "\n\n" + m.choicesView(false)
+			confirm = "1-4: Choose"
+		case m.typed:
+			question += "\n\n" + components.RenderLabeledInput("Your answer:", m.input)
+			confirm, pauseKey, quit = "Enter: Submit", keys.CtrlP, keys.CtrlC
+		}
+		help := []string{confirm, "tab: Hint"}
+		if m.duration > 0 {
+			help = append(help, pauseKey+": Pause")
+		}
+		if len(m.undo) > 0 {
+			undoKey := keys.U
+			if m.typed && !m.isChoice() {
+				undoKey = keys.CtrlZ
+			}
+			help = append(help, undoKey+": Undo")
+		}
+		exitMsg = theme.InfoStyle.Render(strings.Join(append(help, quit+": Quit"), " • "))
+		if m.paused {
+			// Hide the card so pausing cannot be used to think for free
+			question = theme.InfoStyle.Render(fmt.Sprintf("⏸ Paused • %s: Resume", pauseKey))
+		}
+		content = fmt.Sprintf("%s\n\n%s\n\n%s%s", theme.QuestionStyle.Render("Question:"+m.flagMark()), question, m.timerView(), bottomBar)
+	case viewChoiceResult:
+		question := renderCardText(questionText(m.flashcards[m.current]), textWidth, m.plain)
+		content = fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n%s", theme.QuestionStyle.Render("Question:"+m.flagMark()), question,
+			m.choicesView(true), m.resultMsg, bottomBar)
 	case viewAnswer:
-		content = fmt.Sprintf("%s\n\n%s\n\n%s\n%s", theme.AnswerStyle.Render("Answer:"), m.flashcards[m.current].Answer, theme.InfoStyle.Render("Was your answer correct? [c]orrect / [i]ncorrect\n"), bottomBar)
+		answer := renderCardText(answerText(m.flashcards[m.current]), textWidth, m.plain)
+		prompt := "Was your answer correct? [c]orrect / [i]ncorrect\n"
+		if m.typed {
+			answer += "\n\n" + m.typedView()
+			prompt = "Enter: accept suggestion • [c]orrect / [i]ncorrect\n"
+		}
+		if m.resultMsg != "" {
+			prompt = m.resultMsg + "\n" + prompt
+		}
+		content = fmt.Sprintf("%s\n\n%s\n\n%s\n%s", theme.AnswerStyle.Render("Answer:"+m.flagMark()), answer, theme.InfoStyle.Render(prompt), bottomBar)
 	case viewRevisitIn:
-		content = fmt.Sprintf("%s\n%s\n%s", theme.InfoStyle.Render("\nRevisit in (days): [1]  [3]  [7]  [9]"), m.resultMsg, bottomBar)
+		choices := make([]string, 0, len(revisitDays))
+		for _, days := range m.revisitChoices() {
+			choices = append(choices, fmt.Sprintf("[%d]", days))
+		}
+		prompt := "\nRevisit in (days): " + strings.Join(choices, "  ")
+		if n := m.hints[m.current]; n > 0 {
+			prompt += fmt.Sprintf("\n(capped after %d hint(s))", n)
+		} else if m.timedOut[m.current] {
+			prompt += "\n(capped after running out of time)"
+		}
+		content = fmt.Sprintf("%s\n%s\n%s", theme.InfoStyle.Render(prompt), m.resultMsg, bottomBar)
+		exitMsg = theme.InfoStyle.Render("u: Undo • q: Quit")
 	case viewDone:
-		content = fmt.Sprintf("\n%s\n%s", theme.SuccessStyle.Render(m.completionMsg+"\n"), bottomBar)
+		frame = layout.CreateFrame(width, layout.WithAlignment(lipgloss.Left, lipgloss.Top))
+		content = fmt.Sprintf("%s\n\n%s", m.summaryView(textWidth), bottomBar)
+		if m.deferred > 0 {
+			content += "\n" + theme.InfoStyle.Render(fmt.Sprintf("Daily limit reached, %d card(s) left for tomorrow.", m.deferred))
+		}
+		help := []string{"↑/↓: Scroll"}
+		if len(m.undo) > 0 {
+			help = append(help, "u: Undo")
+		}
+		exitMsg = theme.InfoStyle.Render(strings.Join(append(help, "q: Quit"), " • "))
+	case viewEdit:
+		frame = layout.CreateFrame(width, layout.WithAlignment(lipgloss.Left, lipgloss.Top))
+		content = m.editor.view()
+		exitMsg = theme.InfoStyle.Render("tab: Next Field • Enter: Save • esc: Cancel")
+		if m.editor.cloze {
+			exitMsg = theme.InfoStyle.Render("Enter: Save • esc: Cancel")
+		}
+	case viewChat:
+		chatHeight := 15
+		if m.height > 0 {
+			chatHeight = max(m.height-12, 5)
+		}
+		frame = layout.CreateFrame(width, layout.WithAlignment(lipgloss.Left, lipgloss.Top))
+		header := truncateLabel(cardLabel(m.flashcards[m.current]), width-6)
+		content = fmt.Sprintf("%s\n\n%s", theme.QuestionStyle.Render(header), m.chatPane.view(width-6, chatHeight))
+		exitMsg = theme.InfoStyle.Render("Enter: Send • esc: Back to review • ctrl+c: Quit")
+	}
+	if m.view == viewAnswer || m.view == viewChoiceResult {
+		help := []string{"Enter: Confirm"}
+		if m.chat != nil {
+			help = append(help, "x: Explain")
+		}
+		if m.canUndo() {
+			help = append(help, "u: Undo")
+		}
+		exitMsg = theme.InfoStyle.Render(strings.Join(append(help, "q: Quit"), " • "))
+	}
+	var cardKeys []string
+	if m.canMark() {
+		cardKeys = append(cardKeys, "s: Suspend", "z: Bury", "f: Flag")
+	}
+	if m.canEdit() {
+		cardKeys = append(cardKeys, "e: Edit")
+	}
+	if len(cardKeys) > 0 {
+		exitMsg += "\n" + theme.InfoStyle.Render(strings.Join(cardKeys, " • "))
+	}
+	if m.notice != "" {
+		exitMsg = theme.InfoStyle.Render(m.notice) + "\n" + exitMsg
+	}
+	if m.saveErr != nil {
+		exitMsg = theme.ErrorStyle.Render("Failed to save grade: "+m.saveErr.Error()) + "\n" + exitMsg
 	}
 	return layout.CenterContent(m.width, m.height, frame.Render(content)+"\n"+exitMsg)
 }
+
+// timerView renders the countdown bar and remaining seconds, empty when the
+// card is untimed
+func (m *ReviewModel) timerView() string {
+	if m.duration <= 0 {
+		return ""
+	}
+	remaining := int(math.Ceil(m.timer.Timeout.Seconds()))
+	return fmt.Sprintf("%s %s\n\n", m.progress.View(), theme.InfoStyle.Render(fmt.Sprintf("%ds", remaining)))
+}
+
+// questionText returns the text shown on the question view, blanking the
+// deletion under review for cloze cards
+func questionText(fc store.Flashcard) string {
+	if fc.IsCloze() {
+		return notes.RenderCloze(fc.Question, fc.Ordinal, false, highlightCloze)
+	}
+	return fc.Question
+}
+
+// answerText returns the text shown on the answer view, revealing the
+// highlighted deletion in context for cloze cards
+func answerText(fc store.Flashcard) string {
+	if fc.IsCloze() {
+		return notes.RenderCloze(fc.Question, fc.Ordinal, true, highlightCloze)
+	}
+	return fc.Answer
+}
+
+func highlightCloze(s string) string {
+	return theme.ClozeStyle.Render(s)
+}
+
+// renderCardText renders the markdown of a card's text wrapped to width, or
+// wraps it as written when plain is set. Lines are padded to the same width
+// so a centered frame keeps code and lists aligned.
+func renderCardText(text string, width int, plain bool) string {
+	rendered := markdown.Render(text, width)
+	if plain {
+		rendered = markdown.Plain(text, width)
+	}
+	lines := strings.Split(rendered, "\n")
+	if len(lines) == 1 {
+		return rendered
+	}
+	widest := 0
+	for _, line := range lines {
+		widest = max(widest, ansi.StringWidth(line))
+	}
+	for i, line := range lines {
+		lines[i] = line + strings.Repeat(" ", widest-ansi.StringWidth(line))
+	}
+	return strings.Join(lines, "\n")
+}
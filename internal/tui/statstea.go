@@ -0,0 +1,277 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"catv/internal/stats"
+	"catv/internal/tui/keys"
+	"catv/internal/tui/layout"
+	"catv/internal/tui/theme"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// forecastHeight is the number of rows of the due forecast chart
+const forecastHeight = 5
+
+// StatsModel shows a stats report, scrolling when it is taller than the screen
+type StatsModel struct {
+	report *stats.Report
+	top    int // first line shown
+	width  int
+	height int
+}
+
+// NewStatsModel creates the stats screen of a report
+func NewStatsModel(report *stats.Report) *StatsModel {
+	return &StatsModel{report: report}
+}
+
+func (m *StatsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *StatsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		key := msg.String()
+		switch {
+		case keys.IsQuit(key) || key == keys.Esc:
+			return m, tea.Quit
+		case keys.IsUp(key):
+			m.scroll(-1)
+		case keys.IsDown(key):
+			m.scroll(1)
+		case key == keys.PageUp:
+			m.scroll(-m.rows())
+		case key == keys.PageDown:
+			m.scroll(m.rows())
+		}
+	}
+	return m, nil
+}
+
+// rows is the number of lines of the report shown at once
+func (m *StatsModel) rows() int {
+	if m.height <= 0 {
+		return 40
+	}
+	// Room for the frame's border and padding and the help line
+	return max(m.height-5, 3)
+}
+
+func (m *StatsModel) scroll(delta int) {
+	lines := strings.Count(m.content(), "\n") + 1
+	m.top = min(max(m.top+delta, 0), max(lines-m.rows(), 0))
+}
+
+// textWidth is the width of the report inside its frame
+func (m *StatsModel) textWidth() int {
+	if m.width <= 0 {
+		return theme.MaxContentWidth - 6
+	}
+	return layout.CalculateTextWidth(layout.CalculateContentWidth(m.width))
+}
+
+func (m *StatsModel) View() string {
+	lines := strings.Split(m.content(), "\n")
+	top := min(m.top, max(len(lines)-m.rows(), 0))
+	end := min(top+m.rows(), len(lines))
+
+	help := "q: Quit"
+	if len(lines) > m.rows() {
+		help = fmt.Sprintf("↑/↓: Scroll (%d-%d of %d) • q: Quit", top+1, end, len(lines))
+	}
+	frame := layout.CreateFrame(layout.CalculateContentWidth(m.width), layout.WithAlignment(lipgloss.Left, lipgloss.Top))
+	view := frame.Render(strings.Join(lines[top:end], "\n")) + "\n" + theme.InfoStyle.Render(help)
+	if m.width <= 0 {
+		return view
+	}
+	return layout.CenterContent(m.width, m.height, view)
+}
+
+// content renders the whole report
+func (m *StatsModel) content() string {
+	r := m.report
+	width := m.textWidth()
+	var b strings.Builder
+	section := func(title string) {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(theme.TitleStyle.Render(title) + "\n")
+	}
+
+	section("📊 Statistics for " + r.Date)
+	c := r.Cards
+	b.WriteString(fmt.Sprintf("%d card(s): %d new • %d learning • %d young • %d mature • %d suspended\n", c.Total, c.New, c.Learning, c.Young, c.Mature, c.Suspended))
+	b.WriteString(fmt.Sprintf("%d due now • %d leech(es) • %d flagged", c.Due, c.Leeches, c.Flagged))
+
+	section("Retention")
+	var retention []string
+	for _, ret := range r.Retention {
+		retention = append(retention, fmt.Sprintf("%s %s (%d/%d)",
+			theme.LabelStyle.Render(fmt.Sprintf("%dd", ret.Days)), stats.Percent(ret.Rate, ret.Recalls), ret.Recalled, ret.Recalls))
+	}
+	b.WriteString(strings.Join(retention, "   "))
+
+	section("Daily goal")
+	b.WriteString(goalView(r.Goal))
+
+	section("Reviews, last 26 weeks")
+	b.WriteString(heatmap(r.Activity))
+
+	section("Due in the next 30 days")
+	b.WriteString(forecastChart(r.Forecast))
+
+	section("Decks")
+	b.WriteString(groupTable(r.Decks, width, func(name string) string {
+		if name == "" {
+			return "(default)"
+		}
+		return name
+	}))
+	section("Files")
+	b.WriteString(groupTable(r.Files, width, nil))
+	return b.String()
+}
+
+// heatmap renders days as a calendar of weeks, Monday on top, shaded by
+// their number of answers
+func heatmap(days []stats.Day) string {
+	if len(days) == 0 {
+		return ""
+	}
+	first, err := time.Parse("2006-01-02", days[0].Date)
+	if err != nil {
+		return ""
+	}
+	// Weeks start on Monday, the first column is padded before the first day
+	pad := (int(first.Weekday()) + 6) % 7
+	weeks := (pad + len(days) + 6) / 7
+	busiest := 0
+	for _, d := range days {
+		busiest = max(busiest, d.Answers)
+	}
+
+	months := []rune(strings.Repeat(" ", 4+2*weeks))
+	for w := range weeks {
+		monday := first.AddDate(0, 0, 7*w-pad)
+		if w == 0 || monday.Day() <= 7 {
+			label := []rune(monday.Format("Jan"))
+			if at := 4 + 2*w; at+len(label) <= len(months) && (at == 4 || months[at-1] == ' ') {
+				copy(months[at:], label)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(theme.InfoStyle.Render(strings.TrimRight(string(months), " ")))
+	for weekday, name := range []string{"Mon", "", "Wed", "", "Fri", "", "Sun"} {
+		b.WriteString("\n" + theme.InfoStyle.Render(fmt.Sprintf("%-4s", name)))
+		for w := range weeks {
+			i := 7*w + weekday - pad
+			if i < 0 || i >= len(days) {
+				b.WriteString("  ")
+				continue
+			}
+			b.WriteString(theme.HeatmapStyles[heatLevel(days[i].Answers, busiest)].Render("■") + " ")
+		}
+	}
+	less := ""
+	for _, style := range theme.HeatmapStyles {
+		less += style.Render("■") + " "
+	}
+	b.WriteString("\n" + theme.InfoStyle.Render("    less ") + less + theme.InfoStyle.Render("more, busiest day "+fmt.Sprint(busiest)))
+	return b.String()
+}
+
+// heatLevel returns the shade of a day with n answers, the busiest day
+// having busiest
+func heatLevel(n, busiest int) int {
+	if n <= 0 || busiest <= 0 {
+		return 0
+	}
+	levels := len(theme.HeatmapStyles) - 1
+	return min(max(int(math.Ceil(float64(n)*float64(levels)/float64(busiest))), 1), levels)
+}
+
+// forecastChart renders the cards due each day as vertical bars
+func forecastChart(forecast []stats.Due) string {
+	most, total := 0, 0
+	for _, d := range forecast {
+		most = max(most, d.Cards)
+		total += d.Cards
+	}
+	if total == 0 {
+		return theme.InfoStyle.Render("Nothing due, add cards or review new ones.")
+	}
+	eighths := []rune(" ▁▂▃▄▅▆▇█")
+	var b strings.Builder
+	for row := forecastHeight - 1; row >= 0; row-- {
+		axis := ""
+		if row == forecastHeight-1 {
+			axis = fmt.Sprint(most)
+		}
+		b.WriteString(theme.InfoStyle.Render(fmt.Sprintf("%4s ", axis)))
+		var bars strings.Builder
+		for _, d := range forecast {
+			// The height of the bar in eighths of a row, at least a sliver when not empty
+			height := int(math.Round(float64(d.Cards) * forecastHeight * 8 / float64(most)))
+			if d.Cards > 0 {
+				height = max(height, 1)
+			}
+			fill := min(max(height-row*8, 0), 8)
+			bars.WriteRune(eighths[fill])
+			bars.WriteRune(' ')
+		}
+		b.WriteString(theme.BarStyle.Render(strings.TrimRight(bars.String(), " ")) + "\n")
+	}
+	// Mark today and each following week under the bars
+	axis := []rune(strings.Repeat(" ", 5+2*len(forecast)))
+	for i := 0; i < len(forecast); i += 7 {
+		label := "+" + fmt.Sprint(i)
+		if i == 0 {
+			label = "today"
+		}
+		copy(axis[5+2*i:], []rune(label))
+	}
+	b.WriteString(theme.InfoStyle.Render(strings.TrimRight(string(axis), " ")) + "\n")
+	b.WriteString(fmt.Sprintf("%d card(s) due, %d today", total, forecast[0].Cards))
+	return b.String()
+}
+
+// groupTable renders the stats of decks or files, one per line. name turns a
+// group's name into its label, nil keeping it as it is.
+func groupTable(groups []stats.Group, width int, name func(string) string) string {
+	if len(groups) == 0 {
+		return theme.InfoStyle.Render("No cards yet.")
+	}
+	const numbers = " %6s %5s %6s %5s %7s %7s"
+	nameWidth := max(width-ansi.StringWidth(fmt.Sprintf(numbers, "", "", "", "", "", "")), 10)
+	var b strings.Builder
+	b.WriteString(theme.LabelStyle.Render(fmt.Sprintf("%-*s"+numbers, nameWidth, "", "Cards", "New", "Mature", "Due", "Answers", "Recall")))
+	for _, g := range groups {
+		label := g.Name
+		if name != nil {
+			label = name(label)
+		}
+		// Keep the end of long labels, where file names are
+		if ansi.StringWidth(label) > nameWidth {
+			label = ansi.TruncateLeft(label, ansi.StringWidth(label)-nameWidth+1, "…")
+		}
+		b.WriteString("\n" + label + strings.Repeat(" ", max(nameWidth-ansi.StringWidth(label), 0)))
+		b.WriteString(fmt.Sprintf(numbers, fmt.Sprint(g.Cards), fmt.Sprint(g.New), fmt.Sprint(g.Mature), fmt.Sprint(g.Due),
+			fmt.Sprint(g.Answers), stats.Percent(g.Retention, g.Recalls)))
+	}
+	b.WriteString("\n" + theme.InfoStyle.Render(fmt.Sprintf("Answers and recall over the last %d days", stats.GroupDays)))
+	return b.String()
+}
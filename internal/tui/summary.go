@@ -0,0 +1,345 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"catv/internal/notes"
+	"catv/internal/store"
+	"catv/internal/tui/keys"
+	"catv/internal/tui/theme"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxAnswerTime caps the time counted for an answer, so a review left open
+// does not count as time spent studying
+const maxAnswerTime = 5 * time.Minute
+
+// Summary sums up the grades given during a review
+type Summary struct {
+	Started time.Time
+	Cram    bool          // cards were drilled without being rescheduled
+	Cards   []CardSummary // graded cards, in the order they were first answered
+	Answers int           // grades given, a card asked again counting each time
+	Time    time.Duration // time spent answering
+}
+
+// CardSummary is what happened to a card during a review
+type CardSummary struct {
+	Flashcard store.Flashcard
+	Grades    []bool        // whether each answer was correct, in order
+	Time      time.Duration // time spent on all its answers
+	Due       time.Time     // when the card is due next, zero for a card never scheduled
+}
+
+// FirstTry reports whether the card was right on its first answer
+func (c CardSummary) FirstTry() bool {
+	return len(c.Grades) > 0 && c.Grades[0]
+}
+
+// Failed reports whether any answer to the card was wrong
+func (c CardSummary) Failed() bool {
+	return slices.Contains(c.Grades, false)
+}
+
+// summarize sums up review results. Crammed cards keep their schedule, so
+// they are due when they were before.
+func summarize(results []Result, started time.Time, cram bool) Summary {
+	s := Summary{Started: started, Cram: cram, Answers: len(results)}
+	index := make(map[int]int)
+	for _, res := range results {
+		i, ok := index[res.Flashcard.ID]
+		if !ok {
+			i = len(s.Cards)
+			index[res.Flashcard.ID] = i
+			s.Cards = append(s.Cards, CardSummary{Flashcard: res.Flashcard, Due: res.Flashcard.DueAt})
+		}
+		card := &s.Cards[i]
+		card.Grades = append(card.Grades, res.Correct)
+		card.Time += res.Duration
+		if !cram {
+			card.Due = res.Scheduled.DueAt
+		}
+		s.Time += res.Duration
+	}
+	return s
+}
+
+// FirstTry returns the number of cards right on their first answer
+func (s Summary) FirstTry() int {
+	n := 0
+	for _, c := range s.Cards {
+		if c.FirstTry() {
+			n++
+		}
+	}
+	return n
+}
+
+// Accuracy is the share of cards right on their first answer, 0 without cards
+func (s Summary) Accuracy() float64 {
+	if len(s.Cards) == 0 {
+		return 0
+	}
+	return float64(s.FirstTry()) / float64(len(s.Cards))
+}
+
+// AverageTime is the mean time spent on a card, all its answers included
+func (s Summary) AverageTime() time.Duration {
+	if len(s.Cards) == 0 {
+		return 0
+	}
+	return s.Time / time.Duration(len(s.Cards))
+}
+
+// Slowest returns up to n cards that took the longest, slowest first
+func (s Summary) Slowest(n int) []CardSummary {
+	cards := slices.DeleteFunc(slices.Clone(s.Cards), func(c CardSummary) bool { return c.Time <= 0 })
+	slices.SortStableFunc(cards, func(a, b CardSummary) int { return cmp.Compare(b.Time, a.Time) })
+	return cards[:min(n, len(cards))]
+}
+
+// Failed returns the cards answered wrong at least once
+func (s Summary) Failed() []CardSummary {
+	var failed []CardSummary
+	for _, c := range s.Cards {
+		if c.Failed() {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// DueCounts returns how many cards are next due on each day, soonest first,
+// then how many are still new
+func (s Summary) DueCounts(now time.Time) []string {
+	counts := make(map[int]int)
+	unscheduled := 0
+	for _, c := range s.Cards {
+		if c.Due.IsZero() {
+			unscheduled++
+			continue
+		}
+		counts[daysUntil(c.Due, now)]++
+	}
+	days := make([]int, 0, len(counts))
+	for d := range counts {
+		days = append(days, d)
+	}
+	slices.Sort(days)
+	labels := make([]string, len(days))
+	for i, d := range days {
+		labels[i] = fmt.Sprintf("%s %d", dayLabel(d), counts[d])
+	}
+	if unscheduled > 0 {
+		labels = append(labels, fmt.Sprintf("new %d", unscheduled))
+	}
+	return labels
+}
+
+// Report renders the summary as a markdown document
+func (s Summary) Report(now time.Time) string {
+	var b strings.Builder
+	title := "Review"
+	if s.Cram {
+		title = "Cram session"
+	}
+	fmt.Fprintf(&b, "# %s of %s\n\n", title, s.Started.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "- Accuracy: %.0f%% (%d of %d card(s) right the first time)\n", s.Accuracy()*100, s.FirstTry(), len(s.Cards))
+	fmt.Fprintf(&b, "- Answers: %d\n", s.Answers)
+	fmt.Fprintf(&b, "- Time: %s, %s per card\n", formatDuration(s.Time), formatDuration(s.AverageTime()))
+	if due := s.DueCounts(now); len(due) > 0 {
+		fmt.Fprintf(&b, "- Next due: %s\n", strings.Join(due, ", "))
+	}
+	if slowest := s.Slowest(3); len(slowest) > 0 {
+		b.WriteString("\n## Slowest cards\n\n")
+		for _, c := range slowest {
+			fmt.Fprintf(&b, "- %s (%s)\n", cardLabel(c.Flashcard), formatDuration(c.Time))
+		}
+	}
+	if failed := s.Failed(); len(failed) > 0 {
+		b.WriteString("\n## Failed cards\n\n")
+		for _, c := range failed {
+			fmt.Fprintf(&b, "- %s\n", cardLabel(c.Flashcard))
+		}
+	}
+	b.WriteString("\n## Cards\n\n| Card | Grades | Time | Next due |\n|---|---|---|---|\n")
+	for _, c := range s.Cards {
+		due := dueLabel(c.Due, now)
+		if !c.Due.IsZero() {
+			due += " (" + c.Due.Local().Format("2006-01-02") + ")"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", strings.ReplaceAll(cardLabel(c.Flashcard), "|", `\|`),
+			gradeMarks(c.Grades), formatDuration(c.Time), due)
+	}
+	return b.String()
+}
+
+// Summary sums up the grades given so far
+func (m *ReviewModel) Summary() Summary {
+	return summarize(m.Results(), m.started, m.cram)
+}
+
+// summaryRows is the number of cards listed at once on the summary
+func (m *ReviewModel) summaryRows() int {
+	if m.height <= 0 {
+		return 10
+	}
+	return max(m.height-30, 3)
+}
+
+// scrollSummary scrolls the list of cards on the summary with the arrow and
+// page keys
+func (m *ReviewModel) scrollSummary(key string) {
+	delta := 0
+	switch {
+	case keys.IsUp(key):
+		delta = -1
+	case keys.IsDown(key):
+		delta = 1
+	case key == keys.PageUp:
+		delta = -m.summaryRows()
+	case key == keys.PageDown:
+		delta = m.summaryRows()
+	}
+	last := max(len(m.Summary().Cards)-m.summaryRows(), 0)
+	m.summaryTop = min(max(m.summaryTop+delta, 0), last)
+}
+
+// summaryView renders the end of the review: the daily goal, accuracy, time,
+// the slowest and failed cards, when cards are due next and a scrollable list
+// of the cards
+func (m *ReviewModel) summaryView(width int) string {
+	s := m.Summary()
+	var b strings.Builder
+	b.WriteString(theme.SuccessStyle.Render(m.completionMsg) + "\n")
+	if m.goal != nil {
+		status := m.goal.Add(s.Answers, s.Time)
+		b.WriteString("\n" + goalView(status) + "\n")
+		if status.Met() && !m.goal.Met() {
+			b.WriteString(theme.SuccessStyle.Render("Daily goal reached 🎉") + "\n")
+		}
+	}
+	if len(s.Cards) == 0 {
+		b.WriteString("\n" + theme.InfoStyle.Render("No card was graded."))
+		return b.String()
+	}
+	if width <= 0 {
+		width = 60
+	}
+	now := m.now()
+
+	stat := func(label, value string) {
+		b.WriteString(theme.LabelStyle.Render(fmt.Sprintf("%-10s", label)) + value + "\n")
+	}
+	b.WriteString("\n")
+	stat("Accuracy", fmt.Sprintf("%.0f%% (%d of %d card(s) right the first time)", s.Accuracy()*100, s.FirstTry(), len(s.Cards)))
+	stat("Time", fmt.Sprintf("%s, %s per card", formatDuration(s.Time), formatDuration(s.AverageTime())))
+	stat("Next due", strings.Join(s.DueCounts(now), " • "))
+
+	if slowest := s.Slowest(3); len(slowest) > 0 {
+		b.WriteString("\n" + theme.LabelStyle.Render("Slowest") + "\n")
+		for _, c := range slowest {
+			b.WriteString(fmt.Sprintf("%7s  %s\n", formatDuration(c.Time), truncateLabel(cardLabel(c.Flashcard), width-9)))
+		}
+	}
+	if failed := s.Failed(); len(failed) > 0 {
+		b.WriteString("\n" + theme.LabelStyle.Render("Failed") + "\n")
+		for i, c := range failed {
+			if i == 3 {
+				b.WriteString(theme.InfoStyle.Render(fmt.Sprintf("  and %d more", len(failed)-i)) + "\n")
+				break
+			}
+			b.WriteString("  " + truncateLabel(cardLabel(c.Flashcard), width-2) + "\n")
+		}
+	}
+
+	rows := m.summaryRows()
+	top := min(m.summaryTop, max(len(s.Cards)-rows, 0))
+	end := min(top+rows, len(s.Cards))
+	b.WriteString("\n" + theme.LabelStyle.Render("Cards"))
+	if len(s.Cards) > rows {
+		b.WriteString(theme.InfoStyle.Render(fmt.Sprintf(" %d-%d of %d", top+1, end, len(s.Cards))))
+	}
+	b.WriteString("\n")
+	for _, c := range s.Cards[top:end] {
+		marks := gradeMarks(c.Grades)
+		info := fmt.Sprintf("%7s  %-8s", formatDuration(c.Time), dueLabel(c.Due, now))
+		labelWidth := max(width-ansi.StringWidth(marks)-ansi.StringWidth(info)-2, 10)
+		label := truncateLabel(cardLabel(c.Flashcard), labelWidth)
+		label += strings.Repeat(" ", max(labelWidth-ansi.StringWidth(label), 0))
+		b.WriteString(marks + " " + label + " " + theme.InfoStyle.Render(info) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// cardLabel is a card's question on a single line, with the deletion of a
+// cloze card blanked out
+func cardLabel(fc store.Flashcard) string {
+	question := fc.Question
+	if fc.IsCloze() {
+		question = notes.RenderCloze(fc.Question, fc.Ordinal, false, nil)
+	}
+	return strings.Join(strings.Fields(question), " ")
+}
+
+func truncateLabel(s string, width int) string {
+	if ansi.StringWidth(s) <= width {
+		return s
+	}
+	return ansi.Truncate(s, width, "…")
+}
+
+// gradeMarks shows the grades of a card's answers, ✓ when right and ✗ when wrong
+func gradeMarks(grades []bool) string {
+	var b strings.Builder
+	for _, correct := range grades {
+		if correct {
+			b.WriteString("✓")
+		} else {
+			b.WriteString("✗")
+		}
+	}
+	return b.String()
+}
+
+// formatDuration shows a time in seconds under a minute, in minutes and
+// seconds above
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
+
+// daysUntil returns the number of calendar days from now to t, 0 when t is
+// today or past
+func daysUntil(t, now time.Time) int {
+	y, mo, d := now.Date()
+	today := time.Date(y, mo, d, 0, 0, 0, 0, now.Location())
+	return max(int(math.Floor(t.In(now.Location()).Sub(today).Hours()/24)), 0)
+}
+
+// dueLabel names the day a card is due next, "new" for a card never scheduled
+func dueLabel(due, now time.Time) string {
+	if due.IsZero() {
+		return "new"
+	}
+	return dayLabel(daysUntil(due, now))
+}
+
+// dayLabel names a day by its distance from today
+func dayLabel(days int) string {
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	}
+	return fmt.Sprintf("in %d days", days)
+}
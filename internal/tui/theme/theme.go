@@ -38,12 +38,78 @@ var (
 	AnswerStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(ColorSuccessAlt))
 
+	// ClozeStyle highlights the cloze deletion being reviewed
+	ClozeStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color(ColorHighlight)).
+			Background(lipgloss.Color(ColorHighlightBg))
+
+	// DiffMissingStyle marks expected text missing from a typed answer
+	DiffMissingStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(ColorSuccess)).
+				Underline(true)
+
+	// DiffExtraStyle marks typed text that is not part of the answer
+	DiffExtraStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorError)).
+			Strikethrough(true)
+
 	// LabelStyle is used for form field labels
 	LabelStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(ColorSuccessAlt)).
 			Bold(true)
 )
 
+// Markdown styles - for card content rendered from markdown
+var (
+	// CodeStyle is used for inline code spans
+	CodeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorWarning))
+
+	// CodeBorderStyle is used for the gutter of code blocks and quotes
+	CodeBorderStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorMuted))
+
+	// KeywordStyle highlights language keywords in code blocks
+	KeywordStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorPrimary)).
+			Bold(true)
+
+	// StringStyle highlights string literals in code blocks
+	StringStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorSuccessAlt))
+
+	// NumberStyle highlights number literals in code blocks
+	NumberStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorCursor))
+
+	// CommentStyle highlights comments in code blocks
+	CommentStyle = lipgloss.NewStyle().
+			Italic(true).
+			Foreground(lipgloss.Color(ColorInfo))
+
+	// LinkStyle is used for link text
+	LinkStyle = lipgloss.NewStyle().
+			Underline(true)
+)
+
+// Chart styles - for the statistics screen
+var (
+	// HeatmapStyles shade the days of the activity heatmap, from days without
+	// review to the busiest days
+	HeatmapStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("237")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("22")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("28")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("34")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("40")),
+	}
+
+	// BarStyle is used for the bars of charts
+	BarStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorPrimary))
+)
+
 // Status styles - for feedback messages
 var (
 	// SuccessStyle is used for success messages
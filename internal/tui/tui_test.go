@@ -1,14 +1,24 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"catv/internal/choice"
+	"catv/internal/grading"
+	"catv/internal/scheduler"
+	"catv/internal/stats"
 	"catv/internal/store"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/timer"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestTruncate(t *testing.T) {
@@ -466,6 +476,93 @@ func TestAdminModelHandleBulkResetConfirm(t *testing.T) {
 	}
 }
 
+func TestAdminModelReverse(t *testing.T) {
+	tempDB := t.TempDir() + "/test.db"
+	s, err := store.NewStore(tempDB)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.InsertFlashcard(store.Flashcard{File: "/a.md", Question: "Q1", Answer: "A1"}); err != nil {
+		t.Fatalf("Failed to insert flashcard: %v", err)
+	}
+	flashcards, _ := s.GetAllFlashcards()
+	model := NewAdminModel(s, flashcards)
+
+	reverseMsg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}}
+	model.Update(reverseMsg)
+	if len(model.flashcards) != 2 {
+		t.Fatalf("Expected reverse card to be added, got %d flashcards", len(model.flashcards))
+	}
+	if model.flashcards[1].Question != "A1" || model.flashcards[1].SiblingOf != model.flashcards[0].ID {
+		t.Errorf("Unexpected reverse card: %+v", model.flashcards[1])
+	}
+
+	// A second reverse is refused
+	model.table.SetCursor(0)
+	model.Update(reverseMsg)
+	if len(model.flashcards) != 2 || !strings.Contains(model.status.Render(), "already has a reverse") {
+		t.Errorf("Expected duplicate reverse to be refused, status %q", model.status.Render())
+	}
+}
+
+func TestAdminModelCardStates(t *testing.T) {
+	tempDB := t.TempDir() + "/test.db"
+	s, err := store.NewStore(tempDB)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	for _, q := range []string{"Q1", "Q2", "Q3"} {
+		if err := s.InsertFlashcard(store.Flashcard{File: "/a.md", Question: q, Answer: "A"}); err != nil {
+			t.Fatalf("Failed to insert flashcard: %v", err)
+		}
+	}
+	flashcards, _ := s.GetAllFlashcards()
+	model := NewAdminModel(s, flashcards, WithRolloverHour(4))
+	key := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+	at := func(row int, r rune) {
+		model.table.SetCursor(row)
+		model.Update(key(r))
+	}
+
+	at(0, 's')
+	at(1, 'z')
+	at(1, 'f')
+	at(2, 'f')
+	if got := model.table.Rows()[0][3]; got != "suspended" {
+		t.Errorf("Expected the suspended card labelled, got %q", got)
+	}
+	if got := model.table.Rows()[1][3]; got != "⚑ buried" {
+		t.Errorf("Expected the buried and flagged card labelled, got %q", got)
+	}
+	if due, _ := s.GetFlashcardsForReview(); len(due) != 1 || due[0].Question != "Q3" {
+		t.Errorf("Expected only the flagged card left in review, got %+v", due)
+	}
+
+	// Tab cycles through suspended, flagged and buried cards
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	for _, want := range []string{"[Q1]", "[Q2 Q3]", "[Q2]", "[Q1 Q2 Q3]"} {
+		model.Update(tab)
+		var got []string
+		for _, fc := range model.flashcards {
+			got = append(got, fc.Question)
+		}
+		if fmt.Sprint(got) != want {
+			t.Errorf("Filter %s lists %v, want %s", filterNames[model.filter], got, want)
+		}
+	}
+
+	// Keys toggle the states back
+	at(0, 's')
+	at(1, 'z')
+	if due, _ := s.GetFlashcardsForReview(); len(due) != 3 {
+		t.Errorf("Expected every card back in review, got %d", len(due))
+	}
+}
+
 func TestAdminModelView(t *testing.T) {
 	tempDB := t.TempDir() + "/test.db"
 	s, err := store.NewStore(tempDB)
@@ -517,6 +614,12 @@ func TestAdminModelView(t *testing.T) {
 	if !strings.Contains(view, "RevisitIn") {
 		t.Error("Bulk reset confirm view should contain 'RevisitIn'")
 	}
+	model.filter = filterFlagged
+	view = model.View()
+	if !strings.Contains(view, "flagged flashcards listed") || strings.Contains(view, "ALL") {
+		t.Errorf("Bulk reset confirm view should say only the filtered cards are reset, got:\n%s", view)
+	}
+	model.filter = filterAll
 
 	// Test view with error message
 	model.view = adminList
@@ -884,6 +987,23 @@ func TestReviewModelFlashcardWasCorrect(t *testing.T) {
 	}
 }
 
+func TestReviewModelFlashcardWasAnswered(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Q1", Answer: "A1"},
+		{ID: 2, Question: "Q2", Answer: "A2"},
+	}
+
+	model := NewReviewModel(flashcards)
+	model.current = 1
+
+	if !model.FlashcardWasAnswered(0) {
+		t.Error("Expected first card to be answered")
+	}
+	if model.FlashcardWasAnswered(1) {
+		t.Error("Expected current card not to be answered yet")
+	}
+}
+
 func TestReviewModelFlashcardRevisitIn(t *testing.T) {
 	flashcards := []store.Flashcard{
 		{ID: 1, Question: "Q1", Answer: "A1"},
@@ -939,6 +1059,13 @@ func TestReviewModelView(t *testing.T) {
 	if !strings.Contains(view, "Review complete") {
 		t.Error("View should contain 'Review complete'")
 	}
+	if strings.Contains(view, "left for tomorrow") {
+		t.Error("View should not mention cards left for tomorrow without daily limits")
+	}
+	WithDeferred(3)(model)
+	if view = model.View(); !strings.Contains(view, "3 card(s) left for tomorrow") {
+		t.Error("View should tell how many cards the daily limits left for tomorrow")
+	}
 
 	// Test quitting
 	model.quitting = true
@@ -948,6 +1075,742 @@ func TestReviewModelView(t *testing.T) {
 	}
 }
 
+func TestReviewModelClozeView(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "{{c1::Go}} was created at {{c2::Google}}", Answer: "Google", Type: store.CardTypeCloze, Ordinal: 2},
+	}
+
+	model := NewReviewModel(flashcards)
+	model.width = 80
+	model.height = 24
+
+	view := model.View()
+	if !strings.Contains(view, "[...]") || strings.Contains(view, "Google") {
+		t.Error("Question view should blank the cloze deletion under review")
+	}
+	if !strings.Contains(view, "Go was created at") || strings.Contains(view, "{{c") {
+		t.Error("Question view should show other deletions as plain text")
+	}
+
+	model.view = viewAnswer
+	view = model.View()
+	if !strings.Contains(view, "Google") || strings.Contains(view, "[...]") {
+		t.Error("Answer view should reveal the cloze deletion")
+	}
+}
+
+func TestReviewModelMultipleChoice(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Capital of France?", Answer: "Paris"},
+		{ID: 2, Question: "Capital of Italy?", Answer: "Rome"},
+		{ID: 3, Question: "Capital of Spain?", Answer: "Madrid"},
+	}
+	choices := []choice.Set{
+		{Options: []string{"Lyon", "Paris", "Nice", "Lille"}, Correct: 1},
+		{Options: []string{"Rome", "Milan", "Turin", "Naples"}, Correct: 0},
+		{}, // not enough distractors, self-graded
+	}
+	model := NewReviewModel(flashcards, WithChoices(choices))
+	model.width = 80
+	model.height = 24
+
+	view := model.View()
+	if !strings.Contains(view, "[2] Paris") || !strings.Contains(view, "[3] Nice") {
+		t.Errorf("Question view should list the options, got:\n%s", view)
+	}
+
+	// Enter does not reveal the answer, and out of range keys are ignored
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'5'}})
+	if model.view != viewQuestion {
+		t.Fatalf("Expected to stay on the question, got view %v", model.view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+	if model.view != viewChoiceResult || !model.FlashcardWasCorrect(0) {
+		t.Fatalf("Expected a correct pick, got view %v", model.view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewRevisitIn {
+		t.Fatalf("Correct picks should continue to scheduling, got view %v", model.view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+
+	// A wrong pick is graded incorrect and shows the right answer
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+	if model.FlashcardWasCorrect(1) {
+		t.Error("Expected the wrong pick to be graded incorrect")
+	}
+	if view := model.View(); !strings.Contains(view, "✅ [1] Rome") || !strings.Contains(view, "❌ [2] Milan") {
+		t.Errorf("Result view should mark the answer and the pick, got:\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.current != 2 || model.view != viewQuestion {
+		t.Fatalf("Wrong picks should move to the next card, got card %d view %v", model.current, model.view)
+	}
+
+	// Cards without choices are self-graded
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewAnswer {
+		t.Errorf("Expected self-graded answer view, got %v", model.view)
+	}
+}
+
+func TestReviewModelMultipleChoiceTimeout(t *testing.T) {
+	flashcards := []store.Flashcard{{ID: 1, Question: "Capital of France?", Answer: "Paris"}}
+	model := NewReviewModel(flashcards, WithChoices([]choice.Set{{Options: []string{"Lyon", "Paris", "Nice", "Lille"}, Correct: 1}}))
+
+	model.Update(timer.TimeoutMsg{})
+	if model.view != viewChoiceResult || model.FlashcardWasCorrect(0) {
+		t.Errorf("Timing out should grade the card incorrect, got view %v", model.view)
+	}
+
+	// The timer no longer matters once the card is graded
+	model.Update(timer.TimeoutMsg{})
+	if model.view != viewChoiceResult {
+		t.Errorf("Timeout after grading should be ignored, got view %v", model.view)
+	}
+}
+
+func TestReviewModelTypedAnswer(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Which protocol guarantees delivery?", Answer: "TCP"},
+		{ID: 2, Question: "Largest planet?", Answer: "Jupiter"},
+	}
+	model := NewReviewModel(flashcards, WithTypedAnswers())
+	model.width = 80
+	model.height = 24
+
+	// "q" is typed into the answer instead of quitting
+	for _, r := range "tcq" {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if model.quitting || model.input.Value() != "tcq" {
+		t.Fatalf("Expected keys to go to the input, got %q (quitting %v)", model.input.Value(), model.quitting)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if model.view != viewAnswer || !model.grade.Correct {
+		t.Fatalf("Expected a correct suggestion on the answer view, got view %v grade %+v", model.view, model.grade)
+	}
+	if view := model.View(); !strings.Contains(view, "Your answer:") || !strings.Contains(view, "Suggested grade") {
+		t.Errorf("Answer view should show the typed answer and suggestion, got:\n%s", view)
+	}
+
+	// Enter accepts the suggestion
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewRevisitIn || !model.FlashcardWasCorrect(0) {
+		t.Fatalf("Expected accepted correct grade, got view %v", model.view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	if model.input.Value() != "" {
+		t.Errorf("Input should be cleared for the next card, got %q", model.input.Value())
+	}
+
+	// A wrong answer can be overridden as correct
+	for _, r := range "Saturn" {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.grade.Correct {
+		t.Fatal("Expected an incorrect suggestion")
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if !model.FlashcardWasCorrect(1) || model.view != viewRevisitIn {
+		t.Errorf("Expected override to correct, got view %v", model.view)
+	}
+}
+
+func TestReviewModelTypedAnswerTimeout(t *testing.T) {
+	model := NewReviewModel([]store.Flashcard{{ID: 1, Question: "Q", Answer: "Paris"}}, WithTypedAnswers())
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Par")})
+	model.Update(timer.TimeoutMsg{})
+	if model.view != viewAnswer || model.grade.Correct {
+		t.Errorf("Timeout should grade the partial answer, got view %v grade %+v", model.view, model.grade)
+	}
+}
+
+func TestReviewModelGrader(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Why use TCP?", Answer: "Reliable ordered delivery"},
+		{ID: 2, Question: "Why use UDP?", Answer: "Low latency"},
+	}
+	var gotQuestion, gotGiven string
+	grader := func(ctx context.Context, question, expected, given string) (grading.Verdict, error) {
+		gotQuestion, gotGiven = question, given
+		return grading.Verdict{Grade: grading.GradeCorrect, Feedback: "Same idea."}, nil
+	}
+	model := NewReviewModel(flashcards, WithGrader(grader, time.Second))
+	model.width = 80
+	model.height = 24
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("packets arrive in order")})
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !model.grading || cmd == nil {
+		t.Fatal("Submitting should start grading asynchronously")
+	}
+	if view := model.View(); !strings.Contains(view, "Asking the model") {
+		t.Errorf("Answer view should show grading progress, got:\n%s", view)
+	}
+
+	// Run the grader as bubbletea would and deliver its verdict
+	model.Update(runGrader(t, cmd))
+	if gotQuestion != "Why use TCP?" || gotGiven != "packets arrive in order" {
+		t.Errorf("Grader got question %q and answer %q", gotQuestion, gotGiven)
+	}
+	if model.grading || model.verdict == nil || !model.suggestCorrect() {
+		t.Fatalf("Expected the model's correct verdict, got %+v", model.verdict)
+	}
+	if view := model.View(); !strings.Contains(view, "Same idea.") {
+		t.Errorf("Answer view should show the feedback, got:\n%s", view)
+	}
+
+	// A late verdict for a previous card is ignored
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	model.Update(verdictMsg{idx: 0, verdict: grading.Verdict{Grade: grading.GradeIncorrect}})
+	if model.verdict != nil {
+		t.Error("Stale verdict should be ignored")
+	}
+}
+
+func TestReviewModelGraderFailure(t *testing.T) {
+	grader := func(ctx context.Context, question, expected, given string) (grading.Verdict, error) {
+		<-ctx.Done()
+		return grading.Verdict{}, ctx.Err()
+	}
+	model := NewReviewModel([]store.Flashcard{{ID: 1, Question: "Q", Answer: "Paris"}}, WithGrader(grader, 10*time.Millisecond))
+	model.width = 80
+	model.height = 24
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Paris")})
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(runGrader(t, cmd))
+
+	if model.grading || model.verdictErr == nil {
+		t.Fatal("Expected the grader to time out")
+	}
+	if view := model.View(); !strings.Contains(view, "grade yourself") {
+		t.Errorf("Answer view should fall back to self-grading, got:\n%s", view)
+	}
+	// The fuzzy suggestion still applies
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !model.FlashcardWasCorrect(0) {
+		t.Error("Expected the fuzzy match to be accepted")
+	}
+}
+
+// runGrader executes the batched commands returned on submit and returns the verdict
+func runGrader(t *testing.T, cmd tea.Cmd) tea.Msg {
+	t.Helper()
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatal("Expected a batch of commands")
+	}
+	for _, c := range batch {
+		if c == nil {
+			continue
+		}
+		if msg, ok := c().(verdictMsg); ok {
+			return msg
+		}
+	}
+	t.Fatal("No verdict in batch")
+	return nil
+}
+
+func TestReviewModelExplain(t *testing.T) {
+	flashcards := []store.Flashcard{{ID: 1, File: "/net.md", Question: "What does TCP guarantee?", Answer: "Ordered delivery"}}
+	var requests [][]ChatMessage
+	chat := func(ctx context.Context, messages []ChatMessage, onToken func(string)) error {
+		requests = append(requests, messages)
+		for _, token := range []string{"TCP ", "retransmits."} {
+			onToken(token)
+		}
+		return nil
+	}
+	excerpt := func(fc store.Flashcard) string { return "TCP provides ordered delivery." }
+	model := NewReviewModel(flashcards, WithTypedAnswers(), WithExplainer(chat, excerpt))
+	model.width = 80
+	model.height = 30
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("speed")})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if model.view != viewChat {
+		t.Fatalf("Expected chat view, got %v", model.view)
+	}
+	drainChat(model, cmd)
+
+	system := requests[0][0].Content
+	for _, want := range []string{"What does TCP guarantee?", "Correct answer: Ordered delivery", "Student's answer: speed", "TCP provides ordered delivery."} {
+		if !strings.Contains(system, want) {
+			t.Errorf("Chat context missing %q:\n%s", want, system)
+		}
+	}
+	if view := model.View(); !strings.Contains(view, "TCP retransmits.") {
+		t.Errorf("Chat view should show the streamed reply, got:\n%s", view)
+	}
+
+	// Follow-ups keep the conversation, "q" is typed rather than quitting
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("and quic?")})
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drainChat(model, cmd)
+	if model.quitting || len(requests) != 2 || len(requests[1]) != 4 {
+		t.Fatalf("Expected follow-up with the conversation so far, got %d messages", len(requests[1]))
+	}
+	if requests[1][2].Content != "TCP retransmits." || requests[1][3].Content != "and quic?" {
+		t.Errorf("Unexpected follow-up history: %+v", requests[1])
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if model.view != viewAnswer || model.chatPane != nil {
+		t.Errorf("Esc should return to the answer view, got %v", model.view)
+	}
+}
+
+func TestReviewModelExplainView(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Q", Answer: "A"},
+		{ID: 2, File: "/go.md", Question: "{{c1::Go}} has goroutines", Answer: "Go", Type: store.CardTypeCloze, Ordinal: 1},
+	}
+	chat := func(ctx context.Context, messages []ChatMessage, onToken func(string)) error { return nil }
+	excerpt := func(fc store.Flashcard) string { return "" }
+	model := NewReviewModel(flashcards, WithExplainer(chat, excerpt), WithEditor(func(store.Flashcard) error { return nil }))
+	model.width = 80
+	model.height = 30
+	key := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('c'))
+	model.Update(key('3'))
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewAnswer {
+		t.Fatalf("Expected the answer of the second card, got view %v", model.view)
+	}
+	// Offering to explain keeps the other keys of the answer
+	view := model.View()
+	for _, want := range []string{"x: Explain", "u: Undo", "e: Edit"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the answer view, got\n%s", want, view)
+		}
+	}
+
+	_, cmd := model.Update(key('x'))
+	drainChat(model, cmd)
+	if view := model.View(); !strings.Contains(view, "[...] has goroutines") || strings.Contains(view, "{{c1::") {
+		t.Errorf("Expected the cloze card shown as asked in the chat header, got\n%s", view)
+	}
+}
+
+func TestReviewModelExplainUnavailable(t *testing.T) {
+	model := NewReviewModel([]store.Flashcard{{ID: 1, Question: "Q", Answer: "A"}})
+	model.view = viewAnswer
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if model.view != viewAnswer {
+		t.Errorf("Explain should be disabled without a model, got view %v", model.view)
+	}
+}
+
+// drainChat runs the commands of a streamed chat reply until it is done
+func drainChat(model *ReviewModel, cmd tea.Cmd) {
+	for cmd != nil {
+		msg := cmd()
+		if msg == nil {
+			return
+		}
+		_, cmd = model.Update(msg)
+	}
+}
+
+func TestReviewModelHints(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "What does TCP stand for?", Answer: "Transmission Control Protocol"},
+		{ID: 2, Question: "Largest planet?", Answer: "Jupiter"},
+	}
+	hinter := func(ctx context.Context, question, answer string) (string, error) {
+		return "Think about reliable delivery", nil
+	}
+	model := NewReviewModel(flashcards, WithHinter(hinter, time.Second))
+	model.width = 80
+	model.height = 30
+
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	model.Update(tab)
+	model.Update(tab)
+	view := model.View()
+	if !strings.Contains(view, `Starts with "T"`) || !strings.Contains(view, "3 words: T___________ C______ P_______") {
+		t.Errorf("Question view should show the letter and shape hints, got:\n%s", view)
+	}
+
+	_, cmd := model.Update(tab)
+	if !model.hintLoading || model.FlashcardHints(0) != 3 {
+		t.Fatalf("Third hint should ask the model, hints = %d", model.FlashcardHints(0))
+	}
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg, ok := c().(hintMsg); ok {
+			model.Update(msg)
+		}
+	}
+	if !strings.Contains(model.View(), "Think about reliable delivery") {
+		t.Error("Question view should show the model's nudge")
+	}
+
+	// No more hints after the nudge
+	model.Update(tab)
+	if model.FlashcardHints(0) != 3 {
+		t.Errorf("Expected hints to stop at 3, got %d", model.FlashcardHints(0))
+	}
+
+	// Hints cap the revisit interval
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if view := model.View(); strings.Contains(view, "[3]") || !strings.Contains(view, "capped after 3 hint(s)") {
+		t.Errorf("Revisit view should only offer 1 day, got:\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'9'}})
+	if model.current != 0 {
+		t.Fatal("Capped intervals should be ignored")
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	if model.current != 1 || model.FlashcardRevisitIn(0) != 1 {
+		t.Fatalf("Expected 1 day interval, got %d", model.FlashcardRevisitIn(0))
+	}
+
+	// Hints are per card
+	if len(model.hintTexts) != 0 || model.FlashcardHints(1) != 0 {
+		t.Error("Hints should reset for the next card")
+	}
+}
+
+func TestReviewModelHintsWithoutModel(t *testing.T) {
+	model := NewReviewModel([]store.Flashcard{{ID: 1, Question: "Q", Answer: "Paris"}}, WithTypedAnswers())
+	for range 4 {
+		model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	}
+	if model.FlashcardHints(0) != 2 || model.input.Value() != "" {
+		t.Errorf("Expected two hints without a model and no typed text, got %d and %q", model.FlashcardHints(0), model.input.Value())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if got := model.revisitChoices(); fmt.Sprint(got) != "[1 3]" {
+		t.Errorf("revisitChoices() = %v, want [1 3]", got)
+	}
+}
+
+func TestReviewModelTimer(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Capital of France?", Answer: "Paris", Deck: "geo"},
+		{ID: 2, Question: "Largest planet?", Answer: "Jupiter", Deck: "untimed"},
+	}
+	model := NewReviewModel(flashcards, WithTimer(func(fc store.Flashcard) time.Duration {
+		if fc.Deck == "untimed" {
+			return 0
+		}
+		return 10 * time.Second
+	}))
+	model.width = 80
+	model.height = 30
+
+	if model.Init() == nil || !strings.Contains(model.View(), "10s") {
+		t.Errorf("Expected a 10 second timer, got:\n%s", model.View())
+	}
+
+	p := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}
+	model.Update(p)
+	view := model.View()
+	if !model.paused || strings.Contains(view, "Capital of France?") || !strings.Contains(view, "Paused") {
+		t.Errorf("Pausing should hide the question, got:\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewQuestion {
+		t.Error("Answer should not be revealed while paused")
+	}
+	model.Update(p)
+	if model.paused {
+		t.Error("Second p should resume the timer")
+	}
+
+	// Running out of time caps the revisit interval
+	model.Update(timer.TimeoutMsg{})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if got := fmt.Sprint(model.revisitChoices()); got != "[1 3]" || !model.FlashcardTimedOut(0) {
+		t.Errorf("Expected timed out card capped at [1 3], got %s", got)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'7'}})
+	if model.FlashcardRevisitIn(0) != 0 {
+		t.Error("Revisit beyond the cap should be ignored")
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+
+	// Untimed decks show no countdown and ignore timeouts
+	if model.current != 1 || strings.Contains(model.View(), "Pause") {
+		t.Errorf("Untimed card should not offer a pause, got:\n%s", model.View())
+	}
+	model.Update(timer.TimeoutMsg{})
+	if model.view != viewQuestion || model.FlashcardTimedOut(1) {
+		t.Error("Untimed card should ignore timeouts")
+	}
+}
+
+func TestReviewModelTypedPause(t *testing.T) {
+	model := NewReviewModel([]store.Flashcard{{ID: 1, Question: "Q", Answer: "Paris"}}, WithTypedAnswers())
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if model.paused || model.input.Value() != "p" {
+		t.Errorf("p should be typed into the answer, got %q", model.input.Value())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if !model.paused || model.input.Value() != "p" {
+		t.Errorf("ctrl+p should pause and block typing, got %q", model.input.Value())
+	}
+}
+
+func TestReviewModelUndo(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Capital of France?", Answer: "Paris"},
+		{ID: 2, Question: "Largest planet?", Answer: "Jupiter"},
+	}
+	model := NewReviewModel(flashcards)
+	key := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+	// Nothing to undo on the first card
+	model.Update(key('u'))
+	if model.current != 0 || model.view != viewQuestion {
+		t.Fatal("Undo without a graded card should do nothing")
+	}
+
+	// Mark the first card incorrect by mistake, then take it back
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('i'))
+	if model.current != 1 {
+		t.Fatal("Expected to move to the second card")
+	}
+	model.Update(key('u'))
+	if model.current != 0 || model.view != viewAnswer || model.FlashcardWasAnswered(0) {
+		t.Fatalf("Undo should return to the first card's answer, got card %d view %d", model.current, model.view)
+	}
+	model.Update(key('c'))
+	model.Update(key('7'))
+	if !model.FlashcardWasCorrect(0) || model.FlashcardRevisitIn(0) != 7 {
+		t.Error("First card should be re-graded correct in 7 days")
+	}
+
+	// Undo on the revisit prompt takes back the correct grade of the same card
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('c'))
+	model.Update(key('u'))
+	if model.current != 1 || model.view != viewAnswer || model.FlashcardWasCorrect(1) {
+		t.Error("Undo on the revisit prompt should return to the answer")
+	}
+	model.Update(key('i'))
+
+	// Undo from the done screen, twice, clears both results
+	model.Update(key('u'))
+	model.Update(key('u'))
+	if model.current != 0 || model.FlashcardWasCorrect(0) || model.FlashcardRevisitIn(0) != 0 || model.FlashcardWasAnswered(1) {
+		t.Errorf("Expected both grades undone, at card %d", model.current)
+	}
+}
+
+func TestReviewModelUndoTyped(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Capital of France?", Answer: "Paris"},
+		{ID: 2, Question: "Largest planet?", Answer: "Jupiter"},
+	}
+	model := NewReviewModel(flashcards, WithTypedAnswers())
+	model.input.SetValue("Pariss")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	if model.current != 1 || model.input.Value() != "u" {
+		t.Fatalf("u should be typed into the answer, got %q", model.input.Value())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if model.current != 0 || model.view != viewAnswer || model.input.Value() != "Pariss" || !model.grade.Correct {
+		t.Errorf("ctrl+z should restore the typed answer and its grade, got %q", model.input.Value())
+	}
+}
+
+// fakeRecorder keeps the calls made by the review's saver
+type fakeRecorder struct {
+	calls []string
+	err   error
+}
+
+func (r *fakeRecorder) Record(res Result) error {
+	r.calls = append(r.calls, fmt.Sprintf("record %d %v %d", res.Flashcard.ID, res.Correct, res.RevisitIn))
+	return r.err
+}
+
+func (r *fakeRecorder) Unrecord(res Result) error {
+	r.calls = append(r.calls, fmt.Sprintf("unrecord %d", res.Flashcard.ID))
+	return nil
+}
+
+func (r *fakeRecorder) Suspend(fc store.Flashcard) error {
+	r.calls = append(r.calls, fmt.Sprintf("suspend %d", fc.ID))
+	return nil
+}
+
+func (r *fakeRecorder) Bury(fc store.Flashcard) error {
+	r.calls = append(r.calls, fmt.Sprintf("bury %d", fc.ID))
+	return nil
+}
+
+func (r *fakeRecorder) Flag(fc store.Flashcard, flagged bool) error {
+	r.calls = append(r.calls, fmt.Sprintf("flag %d %v", fc.ID, flagged))
+	return nil
+}
+
+func TestReviewModelRecorder(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Capital of France?", Answer: "Paris"},
+		{ID: 2, Question: "Largest planet?", Answer: "Jupiter"},
+	}
+	recorder := &fakeRecorder{}
+	model := NewReviewModel(flashcards, WithRecorder(recorder))
+	key := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('i'))
+	model.Update(key('u'))
+	model.Update(key('c'))
+	model.Update(key('3'))
+	if err := model.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "[record 1 false 1 unrecord 1 record 1 true 3]"
+	if got := fmt.Sprint(recorder.calls); got != want {
+		t.Errorf("Recorder calls = %s, want %s", got, want)
+	}
+}
+
+func TestReviewModelRecorderFailure(t *testing.T) {
+	recorder := &fakeRecorder{err: errors.New("disk full")}
+	model := NewReviewModel([]store.Flashcard{{ID: 1, Question: "Q", Answer: "A"}, {ID: 2, Question: "Q2", Answer: "A2"}}, WithRecorder(recorder))
+	model.width = 80
+	model.height = 30
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	msg := waitForSaveErr(model.saver.errs)()
+	model.Update(msg)
+	if !strings.Contains(model.View(), "Failed to save grade: disk full") {
+		t.Errorf("Expected the save failure to be shown, got:\n%s", model.View())
+	}
+	if err := model.Close(); err == nil || err.Error() != "disk full" {
+		t.Errorf("Close() should return the failure, got %v", err)
+	}
+}
+
+func TestReviewModelLearningSteps(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "New card?", Answer: "A"},
+		{ID: 2, Question: "Learned card?", Answer: "B", RevisitIn: 3, DueAt: time.Now(), Lapses: 1},
+	}
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	model := NewReviewModel(flashcards, WithLearningSteps(scheduler.Steps{10 * time.Minute, time.Hour}, scheduler.Steps{time.Minute}))
+	model.now = func() time.Time { return now }
+	model.width = 80
+	model.height = 30
+	fail := func() {
+		model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	}
+	pass := func() {
+		model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	}
+
+	fail()
+	fail()
+	if len(model.flashcards) != 4 {
+		t.Fatalf("Expected both failed cards queued again, got %d cards", len(model.flashcards))
+	}
+	// The learned card relearns in 1 minute, before the new card's 10 minute step
+	if model.flashcards[model.current].ID != 2 || !strings.Contains(model.View(), "Learning, step 1/1") {
+		t.Fatalf("Expected the relearning card first, got card %d:\n%s", model.flashcards[model.current].ID, model.View())
+	}
+
+	now = now.Add(15 * time.Minute)
+	pass() // graduates after its only relearning step, no revisit prompt
+	pass() // new card moves on to its second step
+	now = now.Add(2 * time.Hour)
+	pass()
+	if model.view != viewDone {
+		t.Fatalf("Expected the review to be done, view %d", model.view)
+	}
+
+	var got []string
+	for _, r := range model.Results() {
+		got = append(got, fmt.Sprintf("%d:%v:%d:%v:%d", r.Flashcard.ID, r.Correct, r.RevisitIn, r.Lapse, r.Scheduled.Lapses))
+	}
+	want := "[1:false:0:false:0 2:false:0:true:2 2:true:1:false:2 1:true:0:false:0 1:true:1:false:0]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("Results() = %v, want %s", got, want)
+	}
+	// The new card only gets a due date once it graduates
+	for i, r := range model.Results() {
+		if r.Flashcard.ID == 1 && r.Scheduled.IsNew() != (i < 4) {
+			t.Errorf("Result %d: new card scheduled %v, want it new until it graduates", i, r.Scheduled.DueAt)
+		}
+	}
+	if relearning := model.Results()[1].Scheduled; relearning.IsNew() {
+		t.Error("A lapsed card being relearned should not become new")
+	}
+	last := model.Results()[4].Scheduled
+	if want := time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local); !last.DueAt.Equal(want) || last.RevisitIn != 1 {
+		t.Errorf("Graduated card should be due tomorrow, got %v", last.DueAt)
+	}
+}
+
+func TestReviewModelUndoRequeue(t *testing.T) {
+	flashcards := []store.Flashcard{{ID: 1, Question: "Q1", Answer: "A1"}, {ID: 2, Question: "Q2", Answer: "A2"}}
+	model := NewReviewModel(flashcards, WithLearningSteps(scheduler.Steps{time.Minute}, nil))
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	if len(model.flashcards) != 3 {
+		t.Fatalf("Expected the failed card queued again, got %d cards", len(model.flashcards))
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	if len(model.flashcards) != 2 || len(model.repeats) != 2 || model.current != 0 {
+		t.Errorf("Undo should take the repeat out of the queue, got %d cards", len(model.flashcards))
+	}
+}
+
+func TestReviewModelPending(t *testing.T) {
+	flashcards := []store.Flashcard{{ID: 1, Question: "Q1", Answer: "A1"}, {ID: 2, Question: "Q2", Answer: "A2"}}
+	model := NewReviewModel(flashcards, WithLearningSteps(scheduler.Steps{time.Minute}, nil))
+	pendingIDs := func() []int {
+		var ids []int
+		for _, fc := range model.Pending() {
+			ids = append(ids, fc.ID)
+		}
+		return ids
+	}
+	if got := pendingIDs(); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("Pending() = %v before the review, want [1 2]", got)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	if got := pendingIDs(); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("Pending() = %v after a failure, want the failed card queued again", got)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if got := pendingIDs(); model.view != viewDone || len(got) != 0 {
+		t.Errorf("Pending() = %v once the review is done, view %d", got, model.view)
+	}
+}
+
 func TestReviewModelInit(t *testing.T) {
 	flashcards := []store.Flashcard{
 		{ID: 1, Question: "Q1", Answer: "A1"},
@@ -1140,3 +2003,534 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+func TestReviewModelCram(t *testing.T) {
+	flashcards := []store.Flashcard{{ID: 1, Question: "Q1", Answer: "A1"}, {ID: 2, Question: "Q2", Answer: "A2"}}
+	model := NewReviewModel(flashcards, WithCram())
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if model.view != viewQuestion || model.current != 1 {
+		t.Fatalf("Expected a correct answer to move on without the revisit prompt, view %d card %d", model.view, model.current)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	if model.view != viewDone {
+		t.Errorf("Expected the cram to be done, view %d", model.view)
+	}
+}
+
+func TestReviewModelMarker(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Q1", Answer: "A1"},
+		{ID: 2, Question: "Q2", Answer: "A2"},
+		{ID: 3, Question: "Q3", Answer: "A3"},
+		{ID: 4, Question: "Q4", Answer: "A4"},
+	}
+	recorder := &fakeRecorder{}
+	model := NewReviewModel(flashcards, WithRecorder(recorder), WithMarker(recorder), WithLearningSteps(scheduler.Steps{time.Minute}, nil))
+	model.width = 80
+	model.height = 30
+	key := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+	// A flagged card is marked, a suspended one taken out of the queue
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('i'))
+	model.Update(key('f'))
+	if view := model.View(); !strings.Contains(view, "Question: ⚑") || !strings.Contains(view, "Card flagged.") {
+		t.Errorf("Expected the flagged card to be marked, got:\n%s", view)
+	}
+	model.Update(key('s'))
+	if len(model.flashcards) != 4 || model.flashcards[model.current].ID != 3 {
+		t.Fatalf("Expected the suspended card out of the queue, got %d cards, current %d", len(model.flashcards), model.flashcards[model.current].ID)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('z'))
+	if model.flashcards[model.current].ID != 4 || model.view != viewQuestion || !strings.Contains(model.View(), "Card buried until tomorrow.") {
+		t.Fatalf("Expected the buried card skipped from its answer, got card %d", model.flashcards[model.current].ID)
+	}
+	// Suspending the card being learned takes its repeat out too
+	model.now = func() time.Time { return time.Now().Add(time.Hour) }
+	model.Update(key('s'))
+	model.Update(key('s'))
+	if model.view != viewDone {
+		t.Fatalf("Expected the review to be done once every card is dropped, view %d", model.view)
+	}
+	if err := model.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "[record 1 false 0 flag 2 true suspend 2 bury 3 suspend 4 suspend 1]"
+	if got := fmt.Sprint(recorder.calls); got != want {
+		t.Errorf("Calls = %s, want %s", got, want)
+	}
+}
+
+func TestReviewModelLeeches(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Q1", Answer: "A1", RevisitIn: 3, DueAt: time.Now(), Lapses: 7},
+		{ID: 2, Question: "Q2", Answer: "A2", RevisitIn: 3, DueAt: time.Now(), Lapses: 9, Tags: []string{store.LeechTag}},
+		{ID: 3, Question: "Q3", Answer: "A3", RevisitIn: 3, DueAt: time.Now(), Lapses: 2},
+	}
+	tests := []struct {
+		name    string
+		suspend bool
+		queued  int
+		notice  string
+	}{
+		{"tagged", false, 6, `Leech: forgotten 8 times, tagged "leech".`},
+		{"suspended", true, 5, `tagged "leech" and suspended.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := NewReviewModel(flashcards, WithLearningSteps(nil, scheduler.Steps{time.Minute}), WithLeeches(8, tt.suspend))
+			model.width = 80
+			model.height = 30
+			fail := func() {
+				model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+				model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+			}
+
+			fail()
+			if view := model.View(); !strings.Contains(view, tt.notice) {
+				t.Errorf("Expected the leech notice %q, got:\n%s", tt.notice, view)
+			}
+			// Already a leech, or not forgotten often enough
+			fail()
+			fail()
+			if len(model.flashcards) != tt.queued {
+				t.Errorf("Expected %d cards queued, got %d", tt.queued, len(model.flashcards))
+			}
+			var leeches []string
+			for _, r := range model.Results() {
+				if r.Leech {
+					leeches = append(leeches, fmt.Sprintf("%d:%v", r.Flashcard.ID, r.Suspend))
+				}
+			}
+			if want := fmt.Sprintf("[1:%v]", tt.suspend); fmt.Sprint(leeches) != want {
+				t.Errorf("Leech results = %v, want %s", leeches, want)
+			}
+		})
+	}
+}
+
+func TestReviewModelEdit(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Capital of Frnace?", Answer: "Lyon"},
+		{ID: 2, Question: "{{c1::Go}} was created at {{c2::Google}}", Answer: "Google", Type: store.CardTypeCloze, Ordinal: 2},
+		{ID: 3, Question: "{{c1::Go}} was created at {{c2::Google}}", Answer: "Go", Type: store.CardTypeCloze, Ordinal: 1},
+		{ID: 4, Question: "Lyon", Answer: "Capital of Frnace?", SiblingOf: 1},
+	}
+	var saved []store.Flashcard
+	model := NewReviewModel(flashcards, WithTypedAnswers(), WithEditor(func(fc store.Flashcard) error {
+		saved = append(saved, fc)
+		return nil
+	}))
+	model.width = 80
+	model.height = 30
+	key := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+	typeText := func(s string) {
+		for _, r := range s {
+			model.Update(key(r))
+		}
+	}
+
+	// Editing is not offered before the answer is shown
+	typeText("Paris")
+	if model.canEdit() {
+		t.Fatal("Expected no editing on the question")
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.grade.Correct {
+		t.Fatal("Expected the wrong stored answer to fail the grade")
+	}
+
+	model.Update(key('e'))
+	if model.view != viewEdit || !strings.Contains(model.View(), "Edit card") {
+		t.Fatalf("Expected the editor, got view %d", model.view)
+	}
+	model.editor.question.SetValue("Capital of France?")
+	model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model.editor.answer.SetValue("Pari")
+	typeText("s")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewAnswer || model.flashcards[0].Question != "Capital of France?" || model.flashcards[0].Answer != "Paris" {
+		t.Fatalf("Expected the answer with the edited card, got view %d, card %+v", model.view, model.flashcards[0])
+	}
+	if !model.grade.Correct || !strings.Contains(model.View(), "Card updated.") {
+		t.Error("Expected the typed answer graded again against the fixed answer")
+	}
+	if model.flashcards[3].Question != "Paris" || model.flashcards[3].Answer != "Capital of France?" {
+		t.Errorf("Expected the reverse to follow the edit, got %+v", model.flashcards[3])
+	}
+
+	// The answer of a cloze card follows from its text
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('3'))
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('e'))
+	model.editor.question.SetValue("{{c1::Go}} was created at Google")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewEdit || !strings.Contains(model.View(), "{{c2::...}}") {
+		t.Fatalf("Expected the missing deletion refused, got view %d", model.view)
+	}
+	model.editor.question.SetValue("Go was created at {{c2::Google}}")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != viewEdit || !strings.Contains(model.View(), "{{c1::...}} deletion of its sibling") {
+		t.Fatalf("Expected the sibling's missing deletion refused, got view %d", model.view)
+	}
+	model.editor.question.SetValue("{{c1::Go}} was designed at {{c2::Google Inc}}")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.flashcards[2].Question != "{{c1::Go}} was designed at {{c2::Google Inc}}" || model.flashcards[2].Answer != "Go" {
+		t.Errorf("Expected the other deletion to follow the edit, got %+v", model.flashcards[2])
+	}
+	if err := model.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(saved) != 2 || saved[1].Answer != "Google Inc" || saved[1].Ordinal != 2 {
+		t.Errorf("Expected both edits saved, got %+v", saved)
+	}
+}
+
+func TestReviewModelMarkdown(t *testing.T) {
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "What does **this** print?\n```go\nfmt.Println(`hi`)\n```", Answer: "- `hi`\n- a newline"},
+	}
+	model := NewReviewModel(flashcards)
+	model.width = 80
+	model.height = 30
+	view := model.View()
+	if !strings.Contains(view, "What does this print?") || !strings.Contains(view, "│ fmt.Println(`hi`)") {
+		t.Errorf("Expected the question rendered from markdown, got\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := model.View(); !strings.Contains(view, "• hi") || !strings.Contains(view, "• a newline") {
+		t.Errorf("Expected the answer rendered as a list, got\n%s", view)
+	}
+
+	plain := NewReviewModel(flashcards, WithPlainText())
+	plain.width = 80
+	plain.height = 30
+	if view := plain.View(); !strings.Contains(view, "What does **this** print?") || !strings.Contains(view, "```go") {
+		t.Errorf("Expected the question as written, got\n%s", view)
+	}
+}
+
+func TestRenderCardText(t *testing.T) {
+	// Lines are padded so a centered frame keeps them aligned
+	if got := renderCardText("- one\n- three", 0, false); got != "• one  \n• three" {
+		t.Errorf("renderCardText() = %q", got)
+	}
+	if got := renderCardText("the **quick** brown fox", 10, false); got != "the quick\nbrown fox" {
+		t.Errorf("renderCardText() = %q", got)
+	}
+	if got := renderCardText("**raw**", 10, true); got != "**raw**" {
+		t.Errorf("renderCardText() = %q, want the text as written", got)
+	}
+}
+
+func TestAdminModelDetail(t *testing.T) {
+	s, err := store.NewStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Q1 with `code`", Answer: "| a | b |\n|---|---|\n| 1 | 2 |", Deck: "go", Tags: []string{"syntax"}},
+		{ID: 2, Question: "{{c1::Go}} has goroutines", Answer: "Go", Type: store.CardTypeCloze, Ordinal: 1},
+	}
+	model := NewAdminModel(s, flashcards)
+	model.width = 80
+	model.height = 40
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.view != adminDetail {
+		t.Fatalf("Expected the detail view, got %v", model.view)
+	}
+	view := model.View()
+	for _, want := range []string{"Flashcard (ID 1)", "deck go", "tags syntax", "Q1 with code", "a │ b", "1 │ 2"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the detail view, got\n%s", want, view)
+		}
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if view := model.View(); !strings.Contains(view, "[...] has goroutines") || model.table.Cursor() != 1 {
+		t.Errorf("Expected the next card with its deletion hidden, got\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	if model.view != adminEdit || model.questionInput.Value() != flashcards[1].Question {
+		t.Errorf("Expected the card's edit form, got view %v", model.view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if model.view != adminList {
+		t.Errorf("Expected esc to go back to the list, got %v", model.view)
+	}
+}
+
+func TestReviewModelSummary(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	flashcards := []store.Flashcard{
+		{ID: 1, Question: "Capital of France?", Answer: "Paris"},
+		{ID: 2, Question: "Largest planet?", Answer: "Jupiter"},
+		{ID: 3, Question: "Smallest prime?", Answer: "2"},
+	}
+	model := NewReviewModel(flashcards, WithTimer(func(store.Flashcard) time.Duration { return 30 * time.Second }))
+	model.now = func() time.Time { return now }
+	model.shownAt = now
+	model.width = 80
+	model.height = 34
+	key := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+
+	// 4s on the first card, its 10 minute pause not counted
+	now = now.Add(2 * time.Second)
+	model.Update(key('p'))
+	now = now.Add(10 * time.Minute)
+	model.Update(key('p'))
+	now = now.Add(2 * time.Second)
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('c'))
+	model.Update(key('3'))
+	// A card left open is capped
+	now = now.Add(time.Hour)
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('i'))
+	now = now.Add(time.Second)
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(key('c'))
+	model.Update(key('1'))
+	if model.view != viewDone {
+		t.Fatalf("Expected the review to be done, view %d", model.view)
+	}
+
+	var durations []time.Duration
+	for _, r := range model.Results() {
+		durations = append(durations, r.Duration)
+	}
+	if got := fmt.Sprint(durations); got != "[4s 5m0s 1s]" {
+		t.Errorf("Durations = %s, want pauses excluded and long answers capped", got)
+	}
+
+	view := model.View()
+	for _, want := range []string{"67% (2 of 3 card(s) right the first time)", "5m5s, 1m42s per card", "Slowest", "Failed", "Largest planet?", "✗ Largest planet?", "in 3 days"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q on the summary, got\n%s", want, view)
+		}
+	}
+
+}
+
+func TestReviewModelSummaryScroll(t *testing.T) {
+	var flashcards []store.Flashcard
+	for i := 1; i <= 5; i++ {
+		flashcards = append(flashcards, store.Flashcard{ID: i, Question: fmt.Sprintf("Question %d?", i), Answer: "A"})
+	}
+	model := NewReviewModel(flashcards)
+	now := time.Now()
+	model.now = func() time.Time { return now } // no slowest cards listed
+	model.width = 80
+	model.height = 30 // room for 3 cards
+	for range flashcards {
+		model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	}
+	if view := model.View(); !strings.Contains(view, "1-3 of 5") || strings.Contains(view, "Question 4?") {
+		t.Fatalf("Expected the first 3 cards listed, got\n%s", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if view := model.View(); !strings.Contains(view, "2-4 of 5") || !strings.Contains(view, "Question 4?") {
+		t.Errorf("Expected the list scrolled by one card, got\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	if model.summaryTop != 2 {
+		t.Errorf("summaryTop = %d, want the last page at 2", model.summaryTop)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if model.summaryTop != 0 {
+		t.Errorf("summaryTop = %d, want 0", model.summaryTop)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	card := func(id int, question string) store.Flashcard {
+		return store.Flashcard{ID: id, Question: question, DueAt: now.AddDate(0, 0, -1)}
+	}
+	due := func(fc store.Flashcard, days int) store.Flashcard {
+		fc.DueAt = now.AddDate(0, 0, days)
+		return fc
+	}
+	a, b, c := card(1, "What is a | pipe?"), card(2, "Second?"), card(3, "Third?")
+	results := []Result{
+		{Flashcard: a, Correct: false, Scheduled: due(a, 0), Duration: 20 * time.Second},
+		{Flashcard: b, Correct: true, Scheduled: due(b, 4), Duration: 2 * time.Second},
+		{Flashcard: a, Correct: true, Scheduled: due(a, 1), Duration: 4 * time.Second},
+		{Flashcard: c, Correct: true, Scheduled: due(c, 4), Duration: 3 * time.Second},
+	}
+
+	s := summarize(results, now, false)
+	if len(s.Cards) != 3 || s.Answers != 4 || s.FirstTry() != 2 || s.Time != 29*time.Second {
+		t.Fatalf("summarize() = %d cards, %d answers, %d first try, %v", len(s.Cards), s.Answers, s.FirstTry(), s.Time)
+	}
+	if got := fmt.Sprint(s.Cards[0].Grades); got != "[false true]" {
+		t.Errorf("Grades = %s, want the grades of both answers", got)
+	}
+	if s.AverageTime() != 29*time.Second/3 {
+		t.Errorf("AverageTime() = %v", s.AverageTime())
+	}
+	var slowest []int
+	for _, c := range s.Slowest(2) {
+		slowest = append(slowest, c.Flashcard.ID)
+	}
+	if fmt.Sprint(slowest) != "[1 3]" || len(s.Failed()) != 1 {
+		t.Errorf("Slowest(2) = %v, Failed() = %d card(s)", slowest, len(s.Failed()))
+	}
+	if got := fmt.Sprint(s.DueCounts(now)); got != "[tomorrow 1 in 4 days 2]" {
+		t.Errorf("DueCounts() = %s", got)
+	}
+
+	report := s.Report(now)
+	for _, want := range []string{"# Review of 2024-03-01 09:00", "- Accuracy: 67%", "## Failed cards", `| What is a \| pipe? | ✗✓ | 24.0s | tomorrow (2024-03-02) |`} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in the report, got\n%s", want, report)
+		}
+	}
+
+	// Crammed cards keep their schedule
+	if s := summarize(results, now, true); !s.Cards[1].Due.Equal(b.DueAt) || !strings.HasPrefix(s.Report(now), "# Cram session") {
+		t.Errorf("Crammed card due %v, want %v", s.Cards[1].Due, b.DueAt)
+	}
+	// A crammed new card has no due date
+	fresh := store.Flashcard{ID: 4, Question: "Fresh?"}
+	crammed := summarize(append(results, Result{Flashcard: fresh, Correct: true, Scheduled: fresh}), now, true)
+	if got := fmt.Sprint(crammed.DueCounts(now)); got != "[today 3 new 1]" {
+		t.Errorf("DueCounts() = %s, want the new card counted apart", got)
+	}
+	if report := crammed.Report(now); !strings.Contains(report, "| Fresh? | ✓ | 0.0s | new |") || strings.Contains(report, "0001") {
+		t.Errorf("Expected the new card listed as new, got\n%s", report)
+	}
+	if s := summarize(nil, now, false); s.Accuracy() != 0 || s.AverageTime() != 0 {
+		t.Error("An empty summary should have no accuracy nor time")
+	}
+}
+
+func TestStatsModel(t *testing.T) {
+	report := &stats.Report{
+		Date:      "2024-03-10",
+		Cards:     stats.Cards{Total: 12, New: 4, Young: 5, Mature: 3, Due: 2},
+		Retention: []stats.Retention{{Days: 7, Recalls: 4, Recalled: 3, Rate: 0.75}, {Days: 30}},
+		Goal:      stats.GoalStatus{Goal: stats.Goal{Cards: 20}, Answers: 25, Streak: stats.Streak{Current: 3, Longest: 5, Today: true}},
+		Decks:     []stats.Group{{Name: "", Cards: 8}, {Name: "vocab", Cards: 4, Answers: 6, Recalls: 2, Retention: 0.5}},
+		Files:     []stats.Group{{Name: "/home/user/notes/" + strings.Repeat("very-long-folder/", 5) + "tcp.md", Cards: 12}},
+	}
+	start := time.Date(2024, 2, 26, 12, 0, 0, 0, time.UTC) // a Monday
+	for i := range 14 {
+		report.Activity = append(report.Activity, stats.Day{Date: start.AddDate(0, 0, i).Format("2006-01-02"), Answers: i})
+	}
+	for i := range 30 {
+		report.Forecast = append(report.Forecast, stats.Due{Date: start.AddDate(0, 0, 13+i).Format("2006-01-02"), Cards: i % 3})
+	}
+
+	model := NewStatsModel(report)
+	model.Update(tea.WindowSizeMsg{Width: 100, Height: 200})
+	view := model.View()
+	for _, want := range []string{"12 card(s): 4 new", "7d 75% (3/4)", "30d – (0/0)", "25/20 cards ✓", "🔥 3 day(s) in a row, longest 5",
+		"Mon", "Feb", "today", "+7", "30 card(s) due, 0 today", "(default)", "vocab", "50%", "tcp.md", "…"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the stats, got\n%s", want, view)
+		}
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := ansi.StringWidth(line); w > 100 {
+			t.Errorf("Line %q is %d columns wide", line, w)
+		}
+	}
+
+	// A short screen scrolls through the report
+	model.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	model.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if model.top != model.rows()+1 || !strings.Contains(model.View(), "↑/↓: Scroll") {
+		t.Errorf("Expected the report scrolled to line %d, at %d", model.rows()+1, model.top)
+	}
+	for range 20 {
+		model.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	}
+	if !strings.Contains(model.View(), "Answers and recall over the last 30 days") {
+		t.Error("Expected the end of the report after scrolling down")
+	}
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}}); cmd == nil {
+		t.Error("q should quit")
+	}
+}
+
+func TestGoalView(t *testing.T) {
+	tests := []struct {
+		name   string
+		status stats.GoalStatus
+		want   []string
+	}{
+		{"no target", stats.GoalStatus{}, []string{"Review a card today", "No streak, meet today's goal to start one (longest 0 day(s))"}},
+		{"studied without target", stats.GoalStatus{Answers: 2, Streak: stats.Streak{Current: 1, Longest: 4, Today: true}},
+			[]string{"Studied today ✓ (2 answer(s))", "🔥 1 day(s) in a row, longest 4"}},
+		{"half way", stats.GoalStatus{Goal: stats.Goal{Cards: 20, Minutes: 15}, Answers: 10, Time: 3 * time.Minute, Minutes: 3,
+			Streak: stats.Streak{Current: 6, Frozen: 1, Longest: 6}},
+			[]string{"▰▰▰▰▰▱▱▱▱▱ 10/20 cards or 3/15 min", "6 day(s) in a row, longest 6, 1 frozen", "meet today's goal to keep it"}},
+		{"met", stats.GoalStatus{Goal: stats.Goal{Minutes: 10}, Time: 12 * time.Minute, Minutes: 12},
+			[]string{"▰▰▰▰▰▰▰▰▰▰ 12/10 min ✓"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := ansi.Strip(goalView(tt.status))
+			for _, want := range tt.want {
+				if !strings.Contains(view, want) {
+					t.Errorf("Expected %q in %q", want, view)
+				}
+			}
+		})
+	}
+}
+
+func TestReviewModelGoal(t *testing.T) {
+	flashcards := []store.Flashcard{{ID: 1, Question: "Q1", Answer: "A"}, {ID: 2, Question: "Q2", Answer: "A"}}
+	status := stats.GoalStatus{Goal: stats.Goal{Cards: 10}, Answers: 8, Streak: stats.Streak{Current: 2, Longest: 2}}
+	model := NewReviewModel(flashcards, WithGoal(status))
+	for range flashcards {
+		model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	}
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"10/10 cards ✓", "3 day(s) in a row, longest 3", "Daily goal reached"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q on the done screen, got\n%s", want, view)
+		}
+	}
+
+	// A goal met before the review is not celebrated again
+	status.Answers = 10
+	status.Streak = stats.Streak{Current: 3, Longest: 3, Today: true}
+	model = NewReviewModel(flashcards[:1], WithGoal(status))
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "11/10 cards") || strings.Contains(view, "Daily goal reached") {
+		t.Errorf("Expected today's progress without celebration, got\n%s", view)
+	}
+}
+
+func TestHeatLevel(t *testing.T) {
+	tests := []struct{ n, busiest, want int }{
+		{0, 10, 0}, {1, 10, 1}, {3, 10, 2}, {10, 10, 4}, {1, 1, 4}, {5, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := heatLevel(tt.n, tt.busiest); got != tt.want {
+			t.Errorf("heatLevel(%d, %d) = %d, want %d", tt.n, tt.busiest, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Package watch provides a file watcher used to regenerate flashcards when
+// notes change on disk. It is woken up by filesystem notifications (inotify,
+// kqueue, ReadDirectoryChangesW) and falls back to polling where they are not
+// available.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// newNotifier creates the filesystem notifier, replaced in tests to exercise
+// the polling fallback
+var newNotifier = fsnotify.NewWatcher
+
+// ListFunc returns the current set of files that should be watched
+type ListFunc func() ([]string, error)
+
+// Watcher scans a set of files and reports the ones whose modification time
+// changed, once they have been quiet for the debounce period
+type Watcher struct {
+	list     ListFunc
+	interval time.Duration
+	debounce time.Duration
+	roots    []string // directories watched for new notes besides those of the listed files
+
+	modTimes map[string]time.Time // last observed modification time per file
+	pending  map[string]time.Time // time of the most recent unreported change
+	primed   bool
+	watched  map[string]bool       // directories the notifier reports on
+	skip     func(dir string) bool // new directories left out of the watch
+	fallback func(error)           // told why notifications gave way to polling
+}
+
+// Option configures a Watcher
+type Option func(*Watcher)
+
+// WithRoot watches dir and its new subdirectories for notes created after the
+// watcher started
+func WithRoot(dir string) Option {
+	return func(w *Watcher) {
+		w.roots = append(w.roots, dir)
+	}
+}
+
+// SkipDirs leaves the new directories for which skip returns true out of the
+// watch, along with everything below them, such as ignored folders
+func SkipDirs(skip func(dir string) bool) Option {
+	return func(w *Watcher) {
+		w.skip = skip
+	}
+}
+
+// OnFallback calls fn with the reason when filesystem notifications are not
+// available and the files are polled instead
+func OnFallback(fn func(error)) Option {
+	return func(w *Watcher) {
+		w.fallback = fn
+	}
+}
+
+// New creates a Watcher of the files returned by list. It reports a change
+// after the file has not been modified for debounce. Without filesystem
+// notifications the files are polled every interval.
+func New(list ListFunc, interval, debounce time.Duration, opts ...Option) *Watcher {
+	w := &Watcher{
+		list:     list,
+		interval: interval,
+		debounce: debounce,
+		modTimes: make(map[string]time.Time),
+		pending:  make(map[string]time.Time),
+		watched:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Prime records the current state of all files without reporting them as changed
+func (w *Watcher) Prime() error {
+	if _, err := w.scan(time.Now()); err != nil {
+		return err
+	}
+	w.pending = make(map[string]time.Time)
+	w.primed = true
+	return nil
+}
+
+// Files returns the files seen during the last poll in sorted order
+func (w *Watcher) Files() []string {
+	files := make([]string, 0, len(w.modTimes))
+	for f := range w.modTimes {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Poll scans the files once and returns those whose changes have settled
+func (w *Watcher) Poll(now time.Time) ([]string, error) {
+	return w.scan(now)
+}
+
+func (w *Watcher) scan(now time.Time) ([]string, error) {
+	files, err := w.list()
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			// The file may have been removed between listing and stat
+			continue
+		}
+		current[f] = true
+		mt := info.ModTime()
+		if prev, ok := w.modTimes[f]; !ok || !prev.Equal(mt) {
+			w.modTimes[f] = mt
+			w.pending[f] = now
+		}
+	}
+
+	// Forget files that disappeared so a re-created file is reported again
+	for f := range w.modTimes {
+		if !current[f] {
+			delete(w.modTimes, f)
+			delete(w.pending, f)
+		}
+	}
+
+	var ready []string
+	for f, changedAt := range w.pending {
+		if now.Sub(changedAt) >= w.debounce {
+			ready = append(ready, f)
+			delete(w.pending, f)
+		}
+	}
+	sort.Strings(ready)
+	return ready, nil
+}
+
+// Run watches the files until ctx is cancelled, calling changed for every
+// settled file. Files that exist unchanged when Run starts are never reported.
+// Changes are picked up from filesystem notifications, or by polling every
+// interval when the notifier cannot be started.
+func (w *Watcher) Run(ctx context.Context, changed func(path string)) error {
+	if !w.primed {
+		if err := w.Prime(); err != nil {
+			return err
+		}
+	}
+
+	n, err := newNotifier()
+	if err != nil {
+		return w.pollInstead(ctx, err, changed)
+	}
+	defer func() {
+		_ = n.Close()
+	}()
+	err = w.notify(ctx, n, changed)
+	if errors.Is(err, errUnwatchable) {
+		return w.pollInstead(ctx, err, changed)
+	}
+	return err
+}
+
+// pollInstead polls the files after notifications failed with err
+func (w *Watcher) pollInstead(ctx context.Context, err error, changed func(path string)) error {
+	if w.fallback != nil {
+		w.fallback(err)
+	}
+	return w.poll(ctx, changed)
+}
+
+// errUnwatchable is returned by notify when a directory cannot be added to
+// the notifier, such as past the system's limit of watches
+var errUnwatchable = errors.New("directory cannot be watched")
+
+// poll scans the files every interval
+func (w *Watcher) poll(ctx context.Context, changed func(path string)) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			ready, err := w.Poll(now)
+			if err != nil {
+				return err
+			}
+			for _, f := range ready {
+				changed(f)
+			}
+		}
+	}
+}
+
+// notify scans the files when the notifier reports a change in one of their
+// directories, then again once the debounce period of pending changes is over
+func (w *Watcher) notify(ctx context.Context, n *fsnotify.Watcher, changed func(path string)) error {
+	if err := w.watchDirs(n); err != nil {
+		return err
+	}
+	settle := time.NewTimer(w.debounce)
+	settle.Stop()
+	defer settle.Stop()
+
+	scan := func(now time.Time) error {
+		ready, err := w.Poll(now)
+		if err != nil {
+			return err
+		}
+		for _, f := range ready {
+			changed(f)
+		}
+		// New files may live in directories not watched yet
+		if err := w.watchDirs(n); err != nil {
+			return err
+		}
+		if wait, ok := w.nextSettle(now); ok {
+			settle.Reset(wait)
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-n.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				w.watchTree(n, ev.Name)
+			}
+			if err := scan(time.Now()); err != nil {
+				return err
+			}
+		case _, ok := <-n.Errors:
+			if !ok {
+				return nil
+			}
+			// Events may have been dropped, such as on an overflow, rescan to catch up
+			if err := scan(time.Now()); err != nil {
+				return err
+			}
+		case now := <-settle.C:
+			if err := scan(now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// nextSettle returns how long until the earliest pending change settles,
+// false when none is pending
+func (w *Watcher) nextSettle(now time.Time) (time.Duration, bool) {
+	var wait time.Duration
+	found := false
+	for _, changedAt := range w.pending {
+		if d := changedAt.Add(w.debounce).Sub(now); !found || d < wait {
+			wait, found = d, true
+		}
+	}
+	return max(wait, 0), found
+}
+
+// watchDirs makes the notifier report on the roots and the directories of the
+// files seen during the last scan
+func (w *Watcher) watchDirs(n *fsnotify.Watcher) error {
+	dirs := append([]string(nil), w.roots...)
+	for f := range w.modTimes {
+		dirs = append(dirs, filepath.Dir(f))
+	}
+	for _, dir := range dirs {
+		if w.watched[dir] {
+			continue
+		}
+		if err := n.Add(dir); err != nil {
+			return fmt.Errorf("%w: %s: %v", errUnwatchable, dir, err)
+		}
+		w.watched[dir] = true
+	}
+	return nil
+}
+
+// watchTree makes the notifier report on a directory created under a watched
+// one and on its subdirectories, so notes saved in a new folder are seen.
+// Paths that are not directories, and skipped directories, are ignored.
+func (w *Watcher) watchTree(n *fsnotify.Watcher, path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return
+	}
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || w.watched[p] {
+			return nil
+		}
+		if w.skip != nil && w.skip(p) {
+			return filepath.SkipDir
+		}
+		// A directory that cannot be watched is left to the next scans of its parent
+		if n.Add(p) == nil {
+			w.watched[p] = true
+		}
+		return nil
+	})
+}
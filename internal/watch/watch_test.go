@@ -0,0 +1,263 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func writeFile(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+}
+
+func TestWatcherPrimeIgnoresExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "note.md")
+	writeFile(t, f, "# Note", time.Now().Add(-time.Hour))
+
+	w := New(func() ([]string, error) { return []string{f}, nil }, time.Second, time.Second)
+	if err := w.Prime(); err != nil {
+		t.Fatalf("Prime() error = %v", err)
+	}
+
+	ready, err := w.Poll(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(ready) != 0 {
+		t.Errorf("Expected no changes after prime, got %v", ready)
+	}
+	if files := w.Files(); len(files) != 1 || files[0] != f {
+		t.Errorf("Files() = %v, want [%s]", files, f)
+	}
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "note.md")
+	base := time.Now().Add(-time.Hour)
+	writeFile(t, f, "# Note", base)
+
+	w := New(func() ([]string, error) { return []string{f}, nil }, time.Second, 2*time.Second)
+	if err := w.Prime(); err != nil {
+		t.Fatalf("Prime() error = %v", err)
+	}
+
+	now := time.Now()
+	writeFile(t, f, "# Note v2", base.Add(time.Minute))
+	ready, _ := w.Poll(now)
+	if len(ready) != 0 {
+		t.Errorf("Change should not be reported before debounce, got %v", ready)
+	}
+
+	// A second edit resets the debounce window
+	writeFile(t, f, "# Note v3", base.Add(2*time.Minute))
+	ready, _ = w.Poll(now.Add(time.Second))
+	if len(ready) != 0 {
+		t.Errorf("Change should not be reported while still editing, got %v", ready)
+	}
+
+	ready, _ = w.Poll(now.Add(3 * time.Second))
+	if len(ready) != 1 || ready[0] != f {
+		t.Errorf("Expected %s to be reported, got %v", f, ready)
+	}
+
+	ready, _ = w.Poll(now.Add(10 * time.Second))
+	if len(ready) != 0 {
+		t.Errorf("Change should only be reported once, got %v", ready)
+	}
+}
+
+func TestWatcherNewAndRemovedFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	writeFile(t, a, "# A", time.Now().Add(-time.Hour))
+
+	files := []string{a}
+	w := New(func() ([]string, error) { return files, nil }, time.Second, 0)
+	if err := w.Prime(); err != nil {
+		t.Fatalf("Prime() error = %v", err)
+	}
+
+	writeFile(t, b, "# B", time.Now())
+	files = []string{a, b}
+	ready, _ := w.Poll(time.Now())
+	if len(ready) != 1 || ready[0] != b {
+		t.Errorf("Expected new file %s to be reported, got %v", b, ready)
+	}
+
+	files = []string{a}
+	if _, err := w.Poll(time.Now()); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if got := w.Files(); len(got) != 1 {
+		t.Errorf("Removed file should be forgotten, got %v", got)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "note.md")
+	writeFile(t, f, "# Note", time.Now().Add(-time.Hour))
+
+	w := New(func() ([]string, error) { return []string{f}, nil }, 10*time.Millisecond, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	changed := make(chan string, 1)
+	go func() {
+		_ = w.Run(ctx, func(path string) {
+			select {
+			case changed <- path:
+			default:
+			}
+		})
+	}()
+
+	// Give Run a chance to prime before modifying the file
+	time.Sleep(50 * time.Millisecond)
+	writeFile(t, f, "# Note v2", time.Now())
+
+	select {
+	case got := <-changed:
+		if got != f {
+			t.Errorf("Run() reported %s, want %s", got, f)
+		}
+	case <-ctx.Done():
+		t.Error("Run() did not report the change before timeout")
+	}
+}
+
+// runUntilChanged runs w until it reports a file or the timeout expires, after
+// act is called
+func runUntilChanged(t *testing.T, w *Watcher, act func()) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	changed := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Run(ctx, func(path string) {
+			select {
+			case changed <- path:
+			default:
+			}
+		})
+	}()
+	// Give Run a chance to prime and start watching
+	time.Sleep(100 * time.Millisecond)
+	act()
+
+	select {
+	case got := <-changed:
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+		return got
+	case <-ctx.Done():
+		t.Fatal("Run() did not report the change before timeout")
+		return ""
+	}
+}
+
+func TestWatcherRunNotifications(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "note.md")
+	writeFile(t, f, "# Note", time.Now().Add(-time.Hour))
+
+	// Polling an hour apart cannot be what reports the change
+	w := New(func() ([]string, error) { return []string{f}, nil }, time.Hour, 50*time.Millisecond)
+	if got := runUntilChanged(t, w, func() { writeFile(t, f, "# Note v2", time.Now()) }); got != f {
+		t.Errorf("Run() reported %s, want %s", got, f)
+	}
+}
+
+func TestWatcherRunNewFolder(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "topics", "go", "note.md")
+	list := func() ([]string, error) {
+		if _, err := os.Stat(f); err != nil {
+			return nil, nil
+		}
+		return []string{f}, nil
+	}
+
+	w := New(list, time.Hour, 0, WithRoot(dir))
+	got := runUntilChanged(t, w, func() {
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Errorf("Failed to create dir: %v", err)
+		}
+		// Let the new folders be watched before the note is saved in them
+		time.Sleep(100 * time.Millisecond)
+		writeFile(t, f, "# Note", time.Now())
+	})
+	if got != f {
+		t.Errorf("Run() reported %s, want %s", got, f)
+	}
+}
+
+func TestWatcherRunSkipDirs(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "topics", "note.md")
+	ignored := filepath.Join(dir, "node_modules")
+	list := func() ([]string, error) {
+		if _, err := os.Stat(f); err != nil {
+			return nil, nil
+		}
+		return []string{f}, nil
+	}
+
+	w := New(list, time.Hour, 0, WithRoot(dir), SkipDirs(func(p string) bool { return filepath.Base(p) == "node_modules" }))
+	got := runUntilChanged(t, w, func() {
+		if err := os.MkdirAll(filepath.Join(ignored, "pkg", "lib"), 0755); err != nil {
+			t.Errorf("Failed to create dir: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Errorf("Failed to create dir: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		writeFile(t, f, "# Note", time.Now())
+	})
+	if got != f {
+		t.Errorf("Run() reported %s, want %s", got, f)
+	}
+	for p := range w.watched {
+		if strings.HasPrefix(p, ignored) {
+			t.Errorf("Expected the skipped folder left unwatched, %s is watched", p)
+		}
+	}
+}
+
+func TestWatcherRunPollingFallback(t *testing.T) {
+	unavailable := errors.New("no notifications")
+	defer func(orig func() (*fsnotify.Watcher, error)) { newNotifier = orig }(newNotifier)
+	newNotifier = func() (*fsnotify.Watcher, error) { return nil, unavailable }
+
+	dir := t.TempDir()
+	f := filepath.Join(dir, "note.md")
+	writeFile(t, f, "# Note", time.Now().Add(-time.Hour))
+
+	var reason error
+	w := New(func() ([]string, error) { return []string{f}, nil }, 10*time.Millisecond, 0, OnFallback(func(err error) { reason = err }))
+	if got := runUntilChanged(t, w, func() { writeFile(t, f, "# Note v2", time.Now()) }); got != f {
+		t.Errorf("Run() reported %s, want %s", got, f)
+	}
+	if !errors.Is(reason, unavailable) {
+		t.Errorf("Expected the fallback to be reported, got %v", reason)
+	}
+}